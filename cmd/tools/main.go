@@ -3,15 +3,28 @@ package main
 import (
 	"aqua-speed-tools/internal/cli"
 	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/models"
 	"aqua-speed-tools/internal/service"
 	"aqua-speed-tools/internal/updater"
 	"aqua-speed-tools/internal/utils"
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -24,20 +37,73 @@ var (
 	githubRawMagicURL string
 	githubAPIMagicURL string
 	dohEndpoint       string
+	mirrorReleaseURL  string
+	downloadTimeout   int
+	discoverDoH       bool
 	debugMode         bool
 	useMirrors        bool
+	mirrorMode        = mirrorModeFlag(mirrorModeOff)
+	retries           int
+	parallel          = 1
+	warmCache         bool
+	promptTimeout     time.Duration
+	testTimeout       time.Duration
+	colorMode         = colorModeFlag(utils.ColorAuto)
+	testKind          = testKindFlag(models.TestKindBoth)
+	dnsCacheFile      string
+	preferIPv6        bool
+	outputDir         string
+	summaryOnly       bool
+	jsonOutput        bool
+	maxDownloads      = service.DefaultMaxConcurrentDownloads
+	keepDownloadDir   string
+	releaseCacheTTL   time.Duration
+	checksumAlgo      = checksumAlgoFlag(updater.ChecksumAuto)
+	explainFormat     explainFlag
+	proxyFlag         string
+	githubToken       string
+	headerFlags       []string
+	installDir        string
+	requestLogPath    string
+	quiet             bool
+	showNotes         bool
+	assumeYes         bool
+	resultsJSONL      string
+	versionCheck      = versionCheckFlag(versionCheckWarn)
+	profile           string
+	configURL         string
 
 	// Services
 	st     *service.SpeedTest
 	ts     *service.TestService
 	logger *zap.Logger
+
+	// mirrorSelection records the raw mirror chosen in mirror mode
+	mirrorSelection *service.MirrorSelection
+
+	// interactiveMode is set when no subcommand is given and the menu will be shown
+	interactiveMode bool
+
+	// stdinLines delivers standard input lines once inputLines has started reading them
+	stdinOnce  sync.Once
+	stdinLines <-chan lineResult
 )
 
 func main() {
-	// Set global app version
-	utils.SetAppVersion(version)
+	// Set global app version, preferring the one stamped by the release build
+	utils.SetAppVersion(cmp.Or(config.Version, version))
+	// 清理上次自更新留下的旧可执行文件 (仅 Windows)
+	updater.CleanupReplacedExecutable()
 
 	if err := execute(); err != nil {
+		var exitErr *cli.ExitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+		if errors.Is(err, context.Canceled) {
+			// 已输出已完成与跳过节点的汇总，按惯例以 130 退出
+			os.Exit(130)
+		}
 		fmt.Fprintf(os.Stderr, "Execution error: %v\n", err)
 		os.Exit(1)
 	}
@@ -45,66 +111,349 @@ func main() {
 
 // execute executes the main program logic
 func execute() error {
+	// 配置在解析命令行参数之前加载，因此需要预先取出 --profile 与 --config-url
+	if err := config.SetProfile(flagFromArgs(os.Args[1:], "profile")); err != nil {
+		return fmt.Errorf("failed to select config profile: %w", err)
+	}
+	if err := config.SetDefaultConfigURL(flagFromArgs(os.Args[1:], "config-url")); err != nil {
+		return fmt.Errorf("invalid --config-url: %w", err)
+	}
+
+	// 首先加载配置文件，命令版本号依赖于配置
+	configPath := config.GetConfigPath()
+	if updater.FileExists(configPath) {
+		utils.Explain(utils.ExplainConfig, "使用配置文件 %s", configPath)
+	} else if config.GetDefaultConfigURL() != "" {
+		utils.Explain(utils.ExplainConfig, "配置文件 %s 不存在，写入从 %s 获取的配置", configPath, config.GetDefaultConfigURL())
+	} else {
+		utils.Explain(utils.ExplainConfig, "配置文件 %s 不存在，写入远程获取的默认配置", configPath)
+	}
+	if err := config.LoadConfig(""); err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+	cfg := config.Get()
+	models.SetAllowUnknownCountryCodes(cfg.AllowUnknownCountryCodes)
+
+	// 执行命令，其余初始化在解析命令行参数后进行
+	rootCmd := newRootCmd(cfg.Script.Version)
+	err := rootCmd.Execute()
+
+	// 无论命令是否成功都输出决策说明，便于排查失败的运行
+	if explainFormat != "" {
+		if explainErr := utils.WriteExplain(os.Stderr, string(explainFormat)); explainErr != nil {
+			fmt.Fprintf(os.Stderr, "输出决策说明失败: %v\n", explainErr)
+		}
+	}
+	return err
+}
+
+// flagFromArgs returns the value of the flag name in args, for the flags needed before
+// the flags are parsed because they select the config the commands are built from
+func flagFromArgs(args []string, name string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--"+name+"="); ok {
+			return value
+		}
+		if arg == "--"+name && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// initialize sets up logging, configuration and services once flags are parsed
+func initialize() error {
+	if maxDownloads < 1 {
+		return fmt.Errorf("--max-concurrent-downloads must be positive, got %d", maxDownloads)
+	}
+	if parallel < 1 {
+		return fmt.Errorf("--parallel must be positive, got %d", parallel)
+	}
+	if jsonOutput {
+		// stdout 只保留 JSON 结果，其余提示改为输出到 stderr
+		utils.SetMessageOutput(os.Stderr)
+	}
+	if err := initNetwork(); err != nil {
+		return err
+	}
+
 	// 设置调试模式并初始化日志
 	utils.IsDebug = debugMode
+	if err := initOutputDir(); err != nil {
+		return err
+	}
 	utils.ResetLogger()
+	if headers := utils.GetHeaders(); len(headers) > 0 {
+		utils.Debug("使用自定义请求头", zap.Any("headers", utils.RedactHeaders(headers)))
+	}
 
-	// 初始化配置
-	if err := initConfig(); err != nil {
-		return fmt.Errorf("failed to initialize config: %w", err)
+	start := time.Now()
+	if warmCache {
+		// 并行执行相互独立的初始化步骤
+		if err := initWarm(); err != nil {
+			return fmt.Errorf("failed to initialize services: %w", err)
+		}
+	} else {
+		// 初始化配置
+		if err := initConfig(); err != nil {
+			return fmt.Errorf("failed to initialize config: %w", err)
+		}
+
+		// 初始化服务
+		if err := initServices(); err != nil {
+			return fmt.Errorf("failed to initialize services: %w", err)
+		}
 	}
+	utils.Debug("初始化完成",
+		zap.Bool("warmCache", warmCache),
+		zap.Duration("elapsed", time.Since(start)))
 
-	// 初始化服务
-	if err := initServices(); err != nil {
-		return fmt.Errorf("failed to initialize services: %w", err)
+	if err := checkInstalledVersion(); err != nil {
+		return err
 	}
 
-	// 执行命令
-	rootCmd := newRootCmd(config.ConfigReader.Script.Version)
-	return rootCmd.Execute()
+	ts.SetRetries(retries)
+	ts.SetParallel(parallel)
+	ts.SetTimeout(testTimeout)
+	ts.SetTestKind(models.TestKind(testKind))
+	ts.SetSummaryOnly(summaryOnly)
+	ts.SetJSONOutput(jsonOutput)
+	ts.SetProxy(utils.GetProxy())
+	if outputDir != "" {
+		ts.SetResultsFile(filepath.Join(outputDir, "results.json"))
+	}
+	ts.SetHistoryFile(resultsJSONL)
+	ts.SetSavedHistory(config.GetHistoryPath())
+	watchConfigReload()
+	return nil
 }
 
-// initConfig initializes the configuration
-func initConfig() error {
-	// 首先加载配置文件
-	if err := config.LoadConfig(""); err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+// initNetwork applies the proxy, request header, request log and retry settings
+// shared by every command making HTTP requests
+func initNetwork() error {
+	if proxyFlag != "" {
+		proxy, err := utils.ParseProxyURL(proxyFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --proxy: %w", err)
+		}
+		utils.SetProxy(proxy)
+	}
+	// 命令行的请求头覆盖配置文件中的同名请求头
+	cfg := config.Get()
+	headers, err := utils.ParseHeaders(append(slices.Clone(cfg.HTTPHeaders), headerFlags...))
+	if err != nil {
+		return fmt.Errorf("invalid --header: %w", err)
 	}
+	utils.SetHeaders(headers)
+	utils.SetGitHubToken(cmp.Or(githubToken, os.Getenv(utils.GitHubTokenEnv)))
+	if requestLogPath != "" {
+		requestLog, err := utils.OpenRequestLog(requestLogPath)
+		if err != nil {
+			return fmt.Errorf("invalid --dump-request-log: %w", err)
+		}
+		utils.SetRequestLog(requestLog)
+	}
+	utils.SetRetryPolicy(cfg.Retry.Policy())
+	// 更新器的下载、资产 HEAD 请求与校验文件下载共用同一个连接数上限
+	utils.SetConnectionLimit(maxDownloads)
+
+	// 在创建更新器与 GitHub 客户端之前覆盖，两者的超时时间都取自 download_timeout
+	if downloadTimeout < 0 {
+		return fmt.Errorf("--download-timeout must not be negative, got %d", downloadTimeout)
+	}
+	if downloadTimeout > 0 {
+		config.Update(func(cfg *config.Config) {
+			cfg.DownloadTimeout = downloadTimeout
+		})
+	}
+	return nil
+}
+
+// checkInstalledVersion compares the installed binary with version.txt according to
+// --version-check: skipped when off, a warning when warn and an error when strict
+func checkInstalledVersion() error {
+	if versionCheck == versionCheckOff {
+		return nil
+	}
+
+	err := st.GetUpdater().CheckInstalledVersion()
+	if err == nil {
+		return nil
+	}
+	if versionCheck == versionCheckStrict {
+		return fmt.Errorf("installed aqua-speed failed the version check: %w", err)
+	}
+	if errors.Is(err, updater.ErrVersionMismatch) {
+		utils.Warning("已安装的 aqua-speed 与 version.txt 记录的版本不一致，可能被手动替换或更新未完成", zap.Error(err))
+	} else {
+		utils.Debug("无法检查已安装的 aqua-speed 版本", zap.Error(err))
+	}
+	return nil
+}
+
+// initOutputDir creates the output directory and points the log file and,
+// unless set explicitly, the DNS cache file into it
+func initOutputDir() error {
+	if outputDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	utils.LogFile = filepath.Join(outputDir, "aqua-speed-tools.log")
+	if dnsCacheFile == "" {
+		dnsCacheFile = filepath.Join(outputDir, "dns-cache.json")
+	}
+	return nil
+}
+
+// cancelOnInterrupt cancels the context of cmd when the process receives SIGINT or SIGTERM,
+// so running speed tests kill their test binary and report the nodes they completed.
+// Only the first signal is caught; a second one terminates the process as usual.
+func cancelOnInterrupt(cmd *cobra.Command) {
+	ctx, stop := signal.NotifyContext(cmp.Or(cmd.Context(), context.Background()), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	cmd.SetContext(ctx)
+}
+
+// watchConfigReload reloads the config file whenever the process receives SIGHUP
+func watchConfigReload() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			changed, restart, err := config.Reload("")
+			if err != nil {
+				utils.Error("配置重新加载失败，继续使用当前配置", zap.Error(err))
+				continue
+			}
+			if len(restart) > 0 {
+				utils.Warning("以下配置需要重启后生效", zap.Strings("fields", restart))
+			}
+			if len(changed) == 0 {
+				utils.Info("配置已重新加载，没有可立即应用的变化")
+				continue
+			}
 
-	cfg := config.ConfigReader
+			utils.SetRetryPolicy(config.Get().Retry.Policy())
+			if err := initDNSResolver(); err != nil {
+				utils.Error("重新初始化 DNS 解析器失败", zap.Error(err))
+			}
+			utils.Info("配置已重新加载", zap.Strings("changed", changed))
+		}
+	}()
+}
+
+// initConfig applies command line flags and mirror selection to the loaded configuration
+func initConfig() error {
+	cfg := config.Get()
+	decideMirrors(&cfg)
 
 	// 如果启用镜像模式，使用配置文件中的镜像设置
 	if useMirrors {
 		utils.Info("正在使用 GitHub 镜像模式")
+		applyAPIMirror(&cfg)
+		selectRawMirror(context.Background(), &cfg)
+	}
 
-		// 设置 API 镜像
-		if githubAPIMagicURL != "" {
-			cfg.GithubAPIBaseURL = githubAPIMagicURL
-			utils.Debug("使用命令行指定的 API 镜像",
-				zap.String("url", githubAPIMagicURL))
-		} else if cfg.GithubAPIMagicURL != "" {
-			cfg.GithubAPIBaseURL = cfg.GithubAPIMagicURL
-			utils.Debug("使用配置文件中的 API 镜像",
-				zap.String("url", cfg.GithubAPIMagicURL))
-		}
-
-		// 测试并选择最快的 Raw 镜像
-		if len(cfg.GithubRawJsdelivrSet) > 0 {
-			mirrorTester := service.NewMirrorTester(utils.GetLogger(), 5*time.Second)
-			fastestMirror := mirrorTester.FindFastestMirror(cfg.GithubRawJsdelivrSet)
-
-			if fastestMirror != "" {
-				githubRawMagicURL = fastestMirror
-				cfg.GithubRawBaseURL = githubRawMagicURL
-				utils.Info("使用最快的 Raw 镜像",
-					zap.String("url", githubRawMagicURL))
-			} else {
-				utils.Warning("所有镜像都不可用，使用默认 GitHub URL")
-			}
+	applyDefaultURLs(&cfg)
+	saveBaseURLs(&cfg)
+	logConfig(&cfg)
+
+	return nil
+}
+
+// saveBaseURLs stores the base URLs chosen during startup in the global configuration
+func saveBaseURLs(cfg *config.Config) {
+	config.Update(func(current *config.Config) {
+		current.GithubAPIBaseURL = cfg.GithubAPIBaseURL
+		current.GithubRawBaseURL = cfg.GithubRawBaseURL
+	})
+}
+
+// decideMirrors sets useMirrors according to --mirror-mode. In auto mode direct GitHub
+// access is probed first and mirrors are only used when it fails or is too slow.
+// --use-mirrors always enables them.
+func decideMirrors(cfg *config.Config) {
+	if useMirrors || mirrorMode == mirrorModeOn {
+		useMirrors = true
+		return
+	}
+	if mirrorMode != mirrorModeAuto {
+		return
+	}
+
+	timeout := time.Duration(cfg.DirectProbeTimeout * float64(time.Second))
+	targets := []string{"https://api.github.com", "https://raw.githubusercontent.com"}
+	latency, err := service.ProbeDirect(context.Background(), targets, timeout)
+	if err != nil {
+		useMirrors = true
+		utils.Info("直连 GitHub 失败或过慢，改用镜像", zap.Duration("timeout", timeout), zap.Error(err))
+		utils.Explain(utils.ExplainMirror, "自动模式使用镜像：直连 GitHub 未在 %s 内成功 (%v)", timeout, err)
+		return
+	}
+	utils.Info("直连 GitHub 正常，不使用镜像", zap.Duration("latency", latency))
+	utils.Explain(utils.ExplainMirror, "自动模式直连 GitHub：探测耗时 %s", latency.Round(time.Millisecond))
+}
+
+// applyAPIMirror sets the API base URL from the command line or configured mirror
+func applyAPIMirror(cfg *config.Config) {
+	if githubAPIMagicURL != "" {
+		cfg.GithubAPIBaseURL = githubAPIMagicURL
+		utils.Debug("使用命令行指定的 API 镜像",
+			zap.String("url", githubAPIMagicURL))
+		utils.Explain(utils.ExplainMirror, "API 使用命令行指定的镜像 %s", githubAPIMagicURL)
+	} else if cfg.GithubAPIMagicURL != "" {
+		cfg.GithubAPIBaseURL = cfg.GithubAPIMagicURL
+		utils.Debug("使用配置文件中的 API 镜像",
+			zap.String("url", cfg.GithubAPIMagicURL))
+		utils.Explain(utils.ExplainMirror, "API 使用配置文件中的镜像 %s", cfg.GithubAPIMagicURL)
+	}
+}
+
+// selectRawMirror tests the configured raw mirrors and uses the fastest one
+func selectRawMirror(ctx context.Context, cfg *config.Config) {
+	if len(cfg.GithubRawJsdelivrSet) == 0 {
+		return
+	}
+
+	start := time.Now()
+	mirrorTester := service.NewMirrorTester(utils.GetLogger(), 5*time.Second)
+	mirrorTester.SetRounds(cfg.MirrorTestRounds)
+	mirrorTester.SetConcurrency(maxDownloads)
+	mirrorTester.SetReleaseSample(cmp.Or(mirrorReleaseURL, cfg.MirrorReleaseURL))
+	// 冷却时间为 0 时不跳过失败的镜像
+	if cooldown := time.Duration(cfg.MirrorFailureCooldown) * time.Second; cooldown > 0 {
+		if failures, err := service.LoadMirrorFailureCache(config.GetMirrorFailureCachePath(), cooldown); err != nil {
+			utils.Warning("加载镜像失败缓存失败，将测试所有镜像", zap.Error(err))
+		} else {
+			mirrorTester.SetFailureCache(failures)
 		}
 	}
+	mirrorSelection = mirrorTester.SelectMirrorContext(ctx, cfg.GithubRawJsdelivrSet)
+	utils.Debug("镜像测试完成", zap.Duration("elapsed", time.Since(start)))
 
-	// 确保基础 URL 不为空
+	if mirrorSelection.Selected != "" {
+		githubRawMagicURL = mirrorSelection.Selected
+		cfg.GithubRawBaseURL = githubRawMagicURL
+		utils.Info("使用最快的 Raw 镜像",
+			zap.String("url", githubRawMagicURL))
+		best := mirrorSelection.Candidates[0]
+		utils.Explain(utils.ExplainMirror, "Raw 使用镜像 %s：%d 个候选中评分最好 (延迟 %s，成功率 %.0f%%)",
+			best.URL, len(mirrorSelection.Candidates), best.Latency.Round(time.Millisecond), best.SuccessRate*100)
+	} else {
+		utils.Warning("所有镜像都不可用，使用默认 GitHub URL")
+		utils.Explain(utils.ExplainMirror, "Raw 不使用镜像：%d 个候选都不可用", len(mirrorSelection.Candidates))
+	}
+}
+
+// applyDefaultURLs ensures the base URLs are not empty
+func applyDefaultURLs(cfg *config.Config) {
 	if cfg.GithubAPIBaseURL == "" {
 		cfg.GithubAPIBaseURL = "https://api.github.com"
 		utils.Debug("使用默认 API URL", zap.String("url", cfg.GithubAPIBaseURL))
@@ -113,8 +462,10 @@ func initConfig() error {
 		cfg.GithubRawBaseURL = "https://raw.githubusercontent.com"
 		utils.Debug("使用默认 Raw URL", zap.String("url", cfg.GithubRawBaseURL))
 	}
+}
 
-	// 输出调试信息
+// logConfig outputs the effective configuration in debug mode
+func logConfig(cfg *config.Config) {
 	if debugMode {
 		utils.Debug("配置信息",
 			zap.String("版本", version),
@@ -127,20 +478,72 @@ func initConfig() error {
 			zap.Int("下载超时时间", cfg.DownloadTimeout),
 			zap.String("日志级别", cfg.LogLevel))
 	}
-
-	return nil
 }
 
-// initServices initializes all required services
-func initServices() error {
-	cfg := config.ConfigReader
+// initWarm initializes configuration and services, overlapping the raw mirror
+// test with the update check. Node fetching waits for the mirror result.
+func initWarm() error {
+	cfg := config.Get()
 
-	// 初始化 DNS 解析器
 	if err := initDNSResolver(); err != nil {
 		return err
 	}
+	decideMirrors(&cfg)
 
-	// 初始化更新器
+	// API 镜像只修改配置，不涉及网络请求，更新检查依赖它
+	if useMirrors {
+		utils.Info("正在使用 GitHub 镜像模式")
+		applyAPIMirror(&cfg)
+	}
+	applyDefaultURLs(&cfg)
+	saveBaseURLs(&cfg)
+
+	var err error
+	st, err = service.NewSpeedTest(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize speed test service: %w", err)
+	}
+	configureUpdater(st.GetUpdater())
+
+	// 更新检查只读取 API 配置，镜像测试只修改 Raw 配置，二者可以并行
+	g, ctx := errgroup.WithContext(context.Background())
+	if useMirrors {
+		g.Go(func() error {
+			selectRawMirror(ctx, &cfg)
+			return nil
+		})
+	}
+	g.Go(func() error {
+		start := time.Now()
+		st.CheckForUpdates()
+		utils.Debug("更新检查完成", zap.Duration("elapsed", time.Since(start)))
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	saveBaseURLs(&cfg)
+	logConfig(&cfg)
+
+	// 节点列表依赖镜像选择结果
+	st.SetConfig(cfg)
+	start := time.Now()
+	if err := loadNodes(); err != nil {
+		return fmt.Errorf("failed to initialize speed test environment: %w", err)
+	}
+	utils.Debug("节点加载完成", zap.Duration("elapsed", time.Since(start)))
+
+	updater, err := newUpdater(&cfg)
+	if err != nil {
+		return err
+	}
+	ts = service.NewTestService(st.GetNodes(), utils.GetLogger(), updater)
+
+	return nil
+}
+
+// newUpdater creates the updater used for running tests
+func newUpdater(cfg *config.Config) (*updater.Updater, error) {
 	urls := utils.NewGitHubURLs(
 		cfg.GithubRawBaseURL,
 		cfg.GithubAPIBaseURL,
@@ -148,16 +551,91 @@ func initServices() error {
 	)
 	updater, err := updater.NewWithLocalVersionAndURLs(version, urls)
 	if err != nil {
-		return fmt.Errorf("failed to create updater: %w", err)
+		return nil, fmt.Errorf("failed to create updater: %w", err)
+	}
+	configureUpdater(updater)
+	return updater, nil
+}
+
+// newSelfUpdater creates the updater replacing this program, after applying the
+// network and mirror settings the download needs
+func newSelfUpdater() (*updater.Updater, error) {
+	if err := initNetwork(); err != nil {
+		return nil, err
+	}
+	if err := initConfig(); err != nil {
+		return nil, fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	cfg := config.Get()
+	urls := utils.NewGitHubURLs(
+		cfg.GithubRawBaseURL,
+		cfg.GithubAPIBaseURL,
+		cfg.GithubRawJsdelivrSet,
+	)
+	u, err := updater.NewSelfUpdater(utils.AppVersion, urls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create updater: %w", err)
+	}
+	configureUpdater(u)
+	return u, nil
+}
+
+// newUpdateSpeedTest creates the speed test service whose updater installs aqua-speed,
+// after applying the network and mirror settings the download needs
+func newUpdateSpeedTest() (*service.SpeedTest, error) {
+	if err := initNetwork(); err != nil {
+		return nil, err
+	}
+	if err := initConfig(); err != nil {
+		return nil, fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	speedTest, err := service.NewSpeedTest(config.Get())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize speed test service: %w", err)
+	}
+	configureUpdater(speedTest.GetUpdater())
+	return speedTest, nil
+}
+
+// configureUpdater applies the update related flags to u
+func configureUpdater(u *updater.Updater) {
+	u.SetKeepDownloadDir(keepDownloadDir)
+	u.SetReleaseCacheTTL(releaseCacheTTL)
+	u.SetChecksumAlgorithm(updater.ChecksumAlgorithm(checksumAlgo))
+	u.SetQuiet(quiet)
+	u.SetShowNotes(showNotes)
+	if mirrorSelection != nil && mirrorSelection.ReleaseChecked {
+		// 最快的 Raw 镜像不一定能提供 Release 文件，使用通过检查的镜像
+		u.SetReleaseMirror(mirrorSelection.ReleaseMirror)
+	}
+}
+
+// initServices initializes all required services
+func initServices() error {
+	cfg := config.Get()
+
+	// 初始化 DNS 解析器
+	if err := initDNSResolver(); err != nil {
+		return err
+	}
+
+	// 初始化更新器
+	updater, err := newUpdater(&cfg)
+	if err != nil {
+		return err
 	}
 
 	// 初始化速度测试服务
-	st, err = service.NewSpeedTest(*cfg)
+	st, err = service.NewSpeedTest(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to initialize speed test service: %w", err)
 	}
+	configureUpdater(st.GetUpdater())
 
-	if err := st.Init(); err != nil {
+	st.CheckForUpdates()
+	if err := loadNodes(); err != nil {
 		return fmt.Errorf("failed to initialize speed test environment: %w", err)
 	}
 
@@ -167,26 +645,92 @@ func initServices() error {
 	return nil
 }
 
+// loadNodes loads the node list, telling an empty node source apart from a failed
+// fetch. In interactive mode the user may retry or fall back to the cached node list.
+func loadNodes() error {
+	for {
+		err := st.LoadNodes()
+		if err == nil {
+			utils.Explain(utils.ExplainNodes, "从 %s 获取了 %d 个节点", config.Get().GithubRawBaseURL, len(st.GetNodes()))
+			return nil
+		}
+		utils.Explain(utils.ExplainNodes, "获取节点列表失败: %v", err)
+		if errors.Is(err, service.ErrNoNodes) {
+			utils.Yellow.Println("节点列表获取成功，但其中没有可用节点，节点源可能暂时为空")
+		} else {
+			utils.Red.Println("获取节点列表失败，请检查网络连接或镜像设置")
+		}
+		if !interactiveMode {
+			return err
+		}
+
+		utils.Green.Println("请选择:")
+		fmt.Printf("1) %s重试%s\n", utils.Bold, utils.Reset)
+		fmt.Printf("2) %s使用缓存的节点列表%s\n", utils.Bold, utils.Reset)
+		fmt.Printf("3) %s退出%s\n", utils.Bold, utils.Reset)
+		line, inputErr := waitForLine(context.Background(), inputLines(), promptTimeout)
+		if inputErr != nil {
+			return err
+		}
+
+		switch strings.TrimSpace(line) {
+		case "1":
+			continue
+		case "2":
+			cacheErr := st.LoadCachedNodes()
+			if cacheErr == nil {
+				utils.Explain(utils.ExplainNodes, "获取节点列表失败，使用缓存的 %d 个节点", len(st.GetNodes()))
+				return nil
+			}
+			utils.Red.Printf("加载缓存的节点列表失败: %v\n", cacheErr)
+		default:
+			return err
+		}
+	}
+}
+
 // initDNSResolver initializes the DNS resolver
 func initDNSResolver() error {
 	if dohEndpoint != "" {
 		// 使用命令行指定的 DoH 端点
 		utils.Debug("使用命令行指定的 DoH 端点", zap.String("endpoint", dohEndpoint))
-		resolver, err := utils.NewDNSResolver(dohEndpoint, 10, 3)
+		resolver, err := newDNSResolver(defaultDNSEndpoint(dohEndpoint))
 		if err != nil {
-			return fmt.Errorf("failed to initialize DNS resolver: %w", err)
+			return err
 		}
 		utils.SetDNSResolver(resolver)
-	} else if len(config.ConfigReader.DNSOverHTTPSSet) > 0 {
-		// 使用配置文件中的第一个 DoH 端点
-		doh := config.ConfigReader.DNSOverHTTPSSet[0]
-		utils.Debug("使用配置文件中的 DoH 端点",
-			zap.String("endpoint", doh.Endpoint),
-			zap.Int("timeout", doh.Timeout),
-			zap.Int("retries", doh.Retries))
-		resolver, err := utils.NewDNSResolver(doh.Endpoint, doh.Timeout, doh.Retries)
+	} else if dohSet := config.Get().DNSOverHTTPSSet; len(dohSet) > 0 {
+		// 按顺序使用配置文件中的 DoH 端点，前一个超时或无结果时切换到下一个
+		endpoints := make([]utils.DNSEndpoint, 0, len(dohSet))
+		for _, doh := range dohSet {
+			utils.Debug("使用配置文件中的 DoH 端点",
+				zap.String("endpoint", doh.Endpoint),
+				zap.Int("timeout", doh.Timeout),
+				zap.Int("retries", doh.Retries))
+			endpoints = append(endpoints, utils.DNSEndpoint{
+				Address: doh.Endpoint,
+				Timeout: time.Duration(doh.Timeout) * time.Second,
+				Retries: doh.Retries,
+			})
+		}
+		resolver, err := newDNSResolver(endpoints...)
 		if err != nil {
-			return fmt.Errorf("failed to initialize DNS resolver: %w", err)
+			return err
+		}
+		utils.SetDNSResolver(resolver)
+	} else if discoverDoH {
+		// 尝试使用系统 DNS 服务器对应的 DoH 端点，失败时使用系统 DNS
+		endpoint, server, err := utils.DiscoverDoHEndpoint()
+		if err != nil {
+			utils.Info("未发现可用的系统 DoH 端点，使用系统 DNS", zap.Error(err))
+			return nil
+		}
+		utils.Info("使用系统 DNS 服务器对应的 DoH 端点",
+			zap.String("server", server),
+			zap.String("endpoint", endpoint))
+		resolver, err := newDNSResolver(defaultDNSEndpoint(endpoint))
+		if err != nil {
+			return err
 		}
 		utils.SetDNSResolver(resolver)
 	}
@@ -194,53 +738,318 @@ func initDNSResolver() error {
 	return nil
 }
 
+// defaultDNSEndpoint returns a DoH endpoint not from the config file, with the default timeout and retries
+func defaultDNSEndpoint(address string) utils.DNSEndpoint {
+	return utils.DNSEndpoint{Address: address, Timeout: 10 * time.Second, Retries: 3}
+}
+
+// newDNSResolver creates a DoH resolver failing over between endpoints, backed by the DNS cache file if one is given
+func newDNSResolver(endpoints ...utils.DNSEndpoint) (*utils.DNSResolver, error) {
+	resolver, err := utils.NewDNSResolver(endpoints...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize DNS resolver: %w", err)
+	}
+	resolver.SetPreferIPv6(preferIPv6)
+	resolver.SetMaxCacheTTL(time.Duration(config.Get().DNSCacheMaxTTL) * time.Second)
+
+	if dnsCacheFile != "" {
+		cache, err := utils.LoadDNSCache(dnsCacheFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load DNS cache: %w", err)
+		}
+		resolver.SetCache(cache)
+		utils.Debug("使用 DNS 缓存文件", zap.String("path", dnsCacheFile))
+	}
+
+	return resolver, nil
+}
+
 // newRootCmd creates the root command
 func newRootCmd(version string) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "aqua-speed-tools",
-		Short:   "Network Speed Test Tool - Supports testing network speed for specific nodes or all nodes",
-		Version: version,
+		Use:           "aqua-speed-tools",
+		Short:         "Network Speed Test Tool - Supports testing network speed for specific nodes or all nodes",
+		Version:       version,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// 初始化失败时无需打印用法
+			cmd.SilenceUsage = true
+			interactiveMode = !cmd.HasParent()
+			return initialize()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// 默认进入交互模式
-			return runInteractiveMode()
+			cancelOnInterrupt(cmd)
+			return runInteractiveMode(cmd.Context())
 		},
 	}
 
+	// 安装目录与自动确认影响所有命令 (包括跳过初始化的子命令)，在解析参数后、执行命令前应用
+	cobra.OnInitialize(func() {
+		updater.SetInstallDir(installDir)
+		utils.SetAssumeYes(assumeYes)
+	})
+
 	// Add flags
 	cmd.PersistentFlags().StringVar(&githubRawMagicURL, "github-raw-magic-url", "", "设置 GitHub Raw Magic URL")
 	cmd.PersistentFlags().StringVar(&githubAPIMagicURL, "github-api-magic-url", "", "设置 GitHub API Magic URL")
 	cmd.PersistentFlags().StringVar(&dohEndpoint, "doh-endpoint", "", "设置 DNS over HTTPS 端点")
+	cmd.PersistentFlags().BoolVar(&discoverDoH, "discover-doh", false, "未配置 DoH 端点时，尝试使用系统 DNS 服务器 (resolvectl 或 resolv.conf) 对应的 DoH 端点")
+	cmd.PersistentFlags().BoolVar(&preferIPv6, "prefer-ipv6", false, "DoH 解析同时查询 A 与 AAAA 记录，结果中 IPv6 地址排在 IPv4 地址之前")
+	cmd.PersistentFlags().StringVar(&dnsCacheFile, "dns-cache-file", "", "DNS 缓存文件路径，用于在重启后保留解析结果")
+	cmd.PersistentFlags().StringVar(&outputDir, "output-dir", "", "保存本次运行的测速结果、日志与 DNS 缓存的目录，不存在时自动创建")
 	cmd.PersistentFlags().BoolVarP(&debugMode, "debug", "d", false, "开启调试模式")
-	cmd.PersistentFlags().BoolVar(&useMirrors, "use-mirrors", false, "启用配置文件中的镜像设置")
+	cmd.PersistentFlags().BoolVar(&useMirrors, "use-mirrors", false, "启用配置文件中的镜像设置 (等同于 --mirror-mode on)")
+	cmd.PersistentFlags().Var(&mirrorMode, "mirror-mode", "镜像模式 (off|on|auto)，auto 先直连 GitHub，失败或超过 direct_probe_timeout 时改用镜像")
+	cmd.PersistentFlags().StringVar(&mirrorReleaseURL, "mirror-release-url", "", "镜像测试时额外检查该 GitHub Release 下载地址，只有能提供该文件的镜像才用于下载 Release (覆盖配置文件的 mirror_release_url)")
+	cmd.PersistentFlags().IntVar(&retries, "retries", 0, "节点测试失败时的重试次数")
+	cmd.PersistentFlags().IntVar(&parallel, "parallel", 1, "测试全部节点时同时测试的节点数，大于 1 时各节点共享带宽，测得的速度会偏低")
+	cmd.PersistentFlags().BoolVar(&warmCache, "warm-cache", false, "并行执行初始化以加快启动速度")
+	cmd.PersistentFlags().Var(&colorMode, "color", "彩色输出模式 (auto|always|never)")
+	cmd.PersistentFlags().BoolVar(&summaryOnly, "summary-only", false, "仅显示最终的测速结果表格，隐藏每个节点的输出")
+	cmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "以 JSON 格式将测速结果输出到 stdout (单个节点为对象，测试全部节点为数组)，其余提示输出到 stderr")
+	cmd.PersistentFlags().Var(&testKind, "test-kind", "测速方向 (download|upload|both)，SingleFile 类型节点不支持 upload")
+	cmd.PersistentFlags().DurationVar(&testTimeout, "test-timeout", 0, "单个节点测速的超时时间 (例如 2m)，0 表示不超时，节点配置的 timeout 优先")
+	cmd.PersistentFlags().DurationVar(&releaseCacheTTL, "release-cache-ttl", updater.DefaultReleaseCacheTTL, "在该时长内复用安装目录中缓存的最新版本信息而不请求 GitHub API (例如 10m)，0 表示不使用缓存")
+	cmd.PersistentFlags().StringVar(&keepDownloadDir, "keep-download", "", "保留校验通过的 aqua-speed 发布压缩包的目录，再次更新到同一版本时直接使用")
+	cmd.PersistentFlags().Var(&checksumAlgo, "checksum-algo", "校验 aqua-speed 时使用的哈希算法 (sha1|sha256|auto)，auto 根据校验值长度自动判断")
+	cmd.PersistentFlags().Var(&explainFormat, "explain", "运行结束时输出本次运行的关键决策 (text|json)，不带值时为 text")
+	cmd.PersistentFlags().Lookup("explain").NoOptDefVal = "text"
+	cmd.PersistentFlags().StringVar(&resultsJSONL, "results-jsonl", "", "将每次测速结果以 JSON Lines 格式追加到该文件，可用 report 命令汇总")
+	cmd.PersistentFlags().Var(&versionCheck, "version-check", "启动时核对 aqua-speed --version 与 version.txt (off|warn|strict)，strict 不一致时退出，off 跳过以加快启动")
+	cmd.PersistentFlags().StringVar(&profile, "profile", config.DefaultProfile, "使用的配置 (配置目录下 profiles/<名称>.json)，default 为 base.json")
+	cmd.PersistentFlags().StringVar(&configURL, "config-url", "", "配置文件不存在时从该地址 (http 或 https) 获取默认配置，而非本工具的 GitHub 仓库")
+	cmd.PersistentFlags().StringArrayVar(&headerFlags, "header", nil, "添加到所有 HTTP 请求的请求头 (格式 \"名称: 值\")，可重复指定")
+	cmd.PersistentFlags().StringVar(&installDir, "install-dir", "", "aqua-speed 的安装目录，默认按系统选择 (见 paths 命令)")
+	cmd.PersistentFlags().StringVar(&requestLogPath, "dump-request-log", "", "将每个 HTTP 请求的方法、URL、状态码、耗时、重定向链与字节数以 JSON Lines 格式追加到该文件，不受日志级别影响")
+	cmd.PersistentFlags().BoolVar(&showNotes, "show-notes", false, "更新 aqua-speed 后显示新版本的更新说明 (过长时截断)")
+	cmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "不显示更新 aqua-speed 后的版本变化与更新说明")
+	cmd.PersistentFlags().BoolVarP(&assumeYes, "assume-yes", "y", false, "自动确认所有需要确认的操作 (例如 config reset)，用于脚本")
+	cmd.PersistentFlags().StringVar(&githubToken, "github-token", "", "访问 GitHub API 使用的令牌，未设置时读取 GITHUB_TOKEN 环境变量；使用令牌时速率限制由每小时 60 次提高到 5000 次，令牌不会发送给镜像")
+	cmd.PersistentFlags().StringVar(&proxyFlag, "proxy", "", "代理地址 (例如 socks5://127.0.0.1:1080)，用于本工具的请求与 aqua-speed 测速")
+	cmd.PersistentFlags().IntVar(&downloadTimeout, "download-timeout", 0, "下载 aqua-speed 与请求 GitHub API 的超时时间 (秒)，覆盖配置文件的 download_timeout，0 表示使用配置文件的值")
+	cmd.PersistentFlags().IntVar(&maxDownloads, "max-concurrent-downloads", service.DefaultMaxConcurrentDownloads, "同时进行的下载与镜像测试连接数上限")
+	cmd.PersistentFlags().DurationVar(&promptTimeout, "prompt-timeout", 0, "交互模式等待输入的超时时间 (例如 5m)，0 表示不超时")
+
+	// Add commands
+	cmd.AddCommand(cli.NewListCmd(func() *service.SpeedTest {
+		return st
+	}))
+	testCmd := cli.NewTestCmd(func() *service.TestService {
+		return ts
+	})
+	testCmd.PreRun = func(cmd *cobra.Command, args []string) {
+		cancelOnInterrupt(cmd)
+	}
+	cmd.AddCommand(testCmd)
+	cmd.AddCommand(cli.NewPingCmd(func() *service.TestService {
+		return ts
+	}))
+	cmd.AddCommand(cli.NewConfigCmd(func() *service.MirrorSelection {
+		return mirrorSelection
+	}))
+	cmd.AddCommand(cli.NewNodesCmd(func() *service.SpeedTest {
+		return st
+	}))
+	cmd.AddCommand(cli.NewPathsCmd())
+	cmd.AddCommand(cli.NewPlatformsCmd())
+	cmd.AddCommand(cli.NewReportCmd())
+	cmd.AddCommand(cli.NewHistoryCmd())
+	cmd.AddCommand(cli.NewVersionCmd())
+	cmd.AddCommand(cli.NewMirrorCmd())
+	localUpdater := func() (*updater.Updater, error) {
+		cfg := config.Get()
+		return newUpdater(&cfg)
+	}
+	cmd.AddCommand(cli.NewVerifyCmd(localUpdater))
+	cmd.AddCommand(cli.NewInstallCmd(localUpdater))
+	cmd.AddCommand(cli.NewDoctorCmd(localUpdater))
+	cmd.AddCommand(cli.NewBenchCmd(localUpdater))
+	cmd.AddCommand(cli.NewRollbackCmd(localUpdater))
+	cmd.AddCommand(cli.NewSelfUpdateCmd(newSelfUpdater))
+	cmd.AddCommand(cli.NewUpdateCmd(newUpdateSpeedTest))
 
 	return cmd
 }
 
-// runInteractiveMode runs the interactive mode
-func runInteractiveMode() error {
+// colorModeFlag is a --color flag value applied as soon as it is parsed
+type colorModeFlag string
+
+func (f *colorModeFlag) String() string { return string(*f) }
+
+func (f *colorModeFlag) Set(mode string) error {
+	if err := utils.SetColorMode(mode); err != nil {
+		return err
+	}
+	*f = colorModeFlag(mode)
+	return nil
+}
+
+func (f *colorModeFlag) Type() string { return "string" }
+
+// testKindFlag is a --test-kind flag value validated when it is parsed
+type testKindFlag models.TestKind
+
+func (f *testKindFlag) String() string { return string(*f) }
+
+func (f *testKindFlag) Set(value string) error {
+	kind, err := models.ParseTestKind(value)
+	if err != nil {
+		return err
+	}
+	*f = testKindFlag(kind)
+	return nil
+}
+
+func (f *testKindFlag) Type() string { return "string" }
+
+// --version-check modes
+const (
+	versionCheckOff    = "off"
+	versionCheckWarn   = "warn"
+	versionCheckStrict = "strict"
+)
+
+// versionCheckFlag is a --version-check flag value validated when it is parsed
+type versionCheckFlag string
+
+func (f *versionCheckFlag) String() string { return string(*f) }
+
+func (f *versionCheckFlag) Set(value string) error {
+	switch value {
+	case versionCheckOff, versionCheckWarn, versionCheckStrict:
+		*f = versionCheckFlag(value)
+		return nil
+	default:
+		return fmt.Errorf("invalid version check mode %q: must be off, warn or strict", value)
+	}
+}
+
+func (f *versionCheckFlag) Type() string { return "string" }
+
+// --mirror-mode modes
+const (
+	mirrorModeOff  = "off"
+	mirrorModeOn   = "on"
+	mirrorModeAuto = "auto"
+)
+
+// mirrorModeFlag is a --mirror-mode flag value validated when it is parsed
+type mirrorModeFlag string
+
+func (f *mirrorModeFlag) String() string { return string(*f) }
+
+func (f *mirrorModeFlag) Set(value string) error {
+	switch value {
+	case mirrorModeOff, mirrorModeOn, mirrorModeAuto:
+		*f = mirrorModeFlag(value)
+		return nil
+	default:
+		return fmt.Errorf("invalid mirror mode %q: must be off, on or auto", value)
+	}
+}
+
+func (f *mirrorModeFlag) Type() string { return "string" }
+
+// explainFlag is an --explain flag value validated when it is parsed, empty when not given
+type explainFlag string
+
+func (f *explainFlag) String() string { return string(*f) }
+
+func (f *explainFlag) Set(value string) error {
+	switch value {
+	case "text", "json":
+		*f = explainFlag(value)
+		return nil
+	default:
+		return fmt.Errorf("invalid explain format %q: must be text or json", value)
+	}
+}
+
+func (f *explainFlag) Type() string { return "string" }
+
+// checksumAlgoFlag is a --checksum-algo flag value validated when it is parsed
+type checksumAlgoFlag updater.ChecksumAlgorithm
+
+func (f *checksumAlgoFlag) String() string { return string(*f) }
+
+func (f *checksumAlgoFlag) Set(value string) error {
+	algorithm, err := updater.ParseChecksumAlgorithm(value)
+	if err != nil {
+		return err
+	}
+	*f = checksumAlgoFlag(algorithm)
+	return nil
+}
+
+func (f *checksumAlgoFlag) Type() string { return "string" }
+
+// runInteractiveMode runs the interactive mode until the user exits or ctx is cancelled
+func runInteractiveMode(ctx context.Context) error {
 	cli.ShowLogo(repo, version)
+	return runInteractiveLoop(ctx, inputLines())
+}
+
+// errPromptTimeout is returned when no input arrives within the prompt timeout
+var errPromptTimeout = errors.New("prompt timed out")
+
+// errInterrupted is returned when the interactive mode is interrupted while waiting for input
+var errInterrupted = errors.New("interrupted")
+
+// lineResult is a line read from the interactive input
+type lineResult struct {
+	line string
+	err  error
+}
+
+// runInteractiveLoop reads menu choices from lines until the user exits, input ends or a prompt times out
+func runInteractiveLoop(ctx context.Context, lines <-chan lineResult) error {
 	for {
 		cli.ShowMenu()
+		line, err := waitForLine(ctx, lines, promptTimeout)
+		if err != nil {
+			return handleInputError(err)
+		}
+
 		var choice int
-		fmt.Scanf("%d", &choice)
+		fmt.Sscanf(line, "%d", &choice)
 
 		switch choice {
 		case 1:
 			utils.Blue.Println("列出所有节点...")
-			if err := st.ListNodes(); err != nil {
+			if err := st.ListNodes(true); err != nil {
 				utils.Red.Printf("列出节点失败: %v\n", err)
 				continue
 			}
 		case 2:
 			utils.Blue.Print("请输入节点 ID (支持数字序号或英文ID): ")
-			var nodeID string
-			fmt.Scanf("%s", &nodeID)
+			nodeID, err := waitForLine(ctx, lines, promptTimeout)
+			if err != nil {
+				return handleInputError(err)
+			}
 
-			if err := ts.RunTest(nodeID); err != nil {
+			if err := ts.RunTest(ctx, nodeID); err != nil {
 				utils.Red.Printf("测试节点失败: %v\n", err)
 				continue
 			}
 		case 3:
+			utils.Blue.Print("请输入所在国家/地区代码 (例如 CN，留空则随机选择): ")
+			country, err := waitForLine(ctx, lines, promptTimeout)
+			if err != nil {
+				return handleInputError(err)
+			}
+
+			if err := ts.RunAutoTest(ctx, country); err != nil {
+				utils.Red.Printf("自动测试失败: %v\n", err)
+				continue
+			}
+		case 4:
+			utils.Blue.Println("测试所有节点...")
+			if err := ts.RunAllTest(ctx); err != nil {
+				utils.Red.Printf("测试所有节点失败: %v\n", err)
+				continue
+			}
+		case 5:
 			utils.Yellow.Println("正在退出...")
 			return nil
 		default:
@@ -248,3 +1057,81 @@ func runInteractiveMode() error {
 		}
 	}
 }
+
+// handleInputError ends the interactive loop gracefully when input is closed or times out
+func handleInputError(err error) error {
+	switch err {
+	case io.EOF:
+		// 输入已关闭 (例如管道输入结束)，直接退出以避免死循环
+		fmt.Println()
+		utils.Yellow.Println("输入已结束，正在退出...")
+		return nil
+	case errPromptTimeout:
+		fmt.Println()
+		utils.Yellow.Printf("等待输入超过 %s，正在退出...\n", promptTimeout)
+		return nil
+	case errInterrupted:
+		fmt.Println()
+		utils.Yellow.Println("已中断，正在退出...")
+		return nil
+	default:
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+}
+
+// inputLines returns the lines read from standard input, shared by every interactive prompt
+func inputLines() <-chan lineResult {
+	stdinOnce.Do(func() {
+		stdinLines = startLineReader(os.Stdin)
+	})
+	return stdinLines
+}
+
+// startLineReader reads lines from in in the background so that waiting for them can time out
+func startLineReader(in io.Reader) <-chan lineResult {
+	lines := make(chan lineResult)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(in)
+		for {
+			line, err := readLine(reader)
+			lines <- lineResult{line: line, err: err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return lines
+}
+
+// waitForLine waits for the next input line, giving up after timeout if it is positive
+// or when ctx is cancelled
+func waitForLine(ctx context.Context, lines <-chan lineResult, timeout time.Duration) (string, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case result, ok := <-lines:
+		if !ok {
+			return "", io.EOF
+		}
+		return result.line, result.err
+	case <-expired:
+		return "", errPromptTimeout
+	case <-ctx.Done():
+		return "", errInterrupted
+	}
+}
+
+// readLine reads a trimmed line, returning io.EOF only when no input is left
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
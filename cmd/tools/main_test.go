@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunInteractiveLoopEndsAtEOF(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty input", input: ""},
+		{name: "invalid choices", input: "9\nabc\n\n"},
+		{name: "node prompt", input: "2\n"},
+		{name: "country prompt", input: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			go func() {
+				done <- runInteractiveLoop(context.Background(), startLineReader(strings.NewReader(tt.input)))
+			}()
+
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Errorf("runInteractiveLoop() error = %v, want nil at end of input", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("runInteractiveLoop() did not return at end of input")
+			}
+		})
+	}
+}
+
+func TestRunInteractiveLoopEndsAtClosedStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	w.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- runInteractiveLoop(context.Background(), startLineReader(r)) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("runInteractiveLoop() error = %v, want nil for a closed stdin", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runInteractiveLoop() did not return for a closed stdin")
+	}
+}
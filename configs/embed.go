@@ -0,0 +1,10 @@
+// Package configs embeds the default configuration shipped with the tool.
+package configs
+
+import _ "embed"
+
+// DefaultBase is the content of base.json at build time, used when the
+// configuration file is missing and cannot be downloaded.
+//
+//go:embed base.json
+var DefaultBase []byte
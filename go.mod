@@ -9,6 +9,7 @@ require (
 	github.com/schollz/progressbar/v3 v3.17.1
 	github.com/ulikunitz/xz v0.5.12
 	go.uber.org/zap v1.27.0
+	golang.org/x/sync v0.10.0
 )
 
 require (
@@ -16,7 +17,6 @@ require (
 	go.uber.org/multierr v1.10.0 // indirect
 	golang.org/x/mod v0.18.0 // indirect
 	golang.org/x/net v0.31.0 // indirect
-	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/term v0.26.0 // indirect
 	golang.org/x/tools v0.22.0 // indirect
 )
@@ -31,6 +31,6 @@ require (
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/spf13/cobra v1.8.1
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.27.0 // indirect
+	golang.org/x/sys v0.27.0
 	golang.org/x/text v0.21.0 // indirect
 )
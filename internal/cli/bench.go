@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/updater"
+	"aqua-speed-tools/internal/utils"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NewBenchCmd creates the hidden bench command for diagnosing slow devices
+func NewBenchCmd(newUpdater func() (*updater.Updater, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "bench",
+		Short:  "Benchmark parts of the update process",
+		Hidden: true,
+		// 仅处理本地文件，无需初始化服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	extractCmd := &cobra.Command{
+		Use:   "extract <archive>",
+		Short: "Time fully reading a release archive, with decompress and tar throughput reported separately",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u, err := newUpdater()
+			if err != nil {
+				return err
+			}
+			bench, err := u.BenchmarkArchive(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to benchmark %s: %w", args[0], err)
+			}
+
+			table := utils.NewTable([]string{"阶段", "耗时", "数据量", "吞吐量"})
+			if bench.IsZip() {
+				table.AddRow([]string{"解压 (ZIP)", formatElapsed(bench.Total), formatMiB(bench.EntrySize), formatThroughput(bench.EntrySize, bench.Total)})
+			} else {
+				table.AddRow([]string{"XZ 解压", formatElapsed(bench.Decompress), formatMiB(bench.StreamSize), formatThroughput(bench.StreamSize, bench.Decompress)})
+				table.AddRow([]string{"TAR 读取", formatElapsed(bench.Unpack), formatMiB(bench.StreamSize), formatThroughput(bench.StreamSize, bench.Unpack)})
+				table.AddRow([]string{"完整读取", formatElapsed(bench.Total), formatMiB(bench.ArchiveSize), formatThroughput(bench.ArchiveSize, bench.Total)})
+			}
+			table.Print()
+
+			fmt.Printf("压缩包 %s，共 %d 个文件，解压后 %s\n", formatMiB(bench.ArchiveSize), bench.Entries, formatMiB(bench.EntrySize))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(extractCmd)
+	return cmd
+}
+
+// formatMiB formats a byte count in MiB
+func formatMiB(n int64) string {
+	return fmt.Sprintf("%.2f MiB", float64(n)/(1<<20))
+}
+
+// formatElapsed rounds d for display
+func formatElapsed(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// formatThroughput formats n bytes read in d as MiB/s
+func formatThroughput(n int64, d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f MiB/s", float64(n)/(1<<20)/d.Seconds())
+}
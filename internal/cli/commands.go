@@ -1,37 +1,169 @@
 package cli
 
 import (
+	"aqua-speed-tools/internal/models"
 	"aqua-speed-tools/internal/service"
 	"aqua-speed-tools/internal/utils"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 // NewListCmd creates the list command
-func NewListCmd(st *service.SpeedTest) *cobra.Command {
-	return &cobra.Command{
+func NewListCmd(speedTest func() *service.SpeedTest) *cobra.Command {
+	var silent bool
+
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all available nodes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return st.ListNodes()
+			return speedTest().ListNodes(!silent)
 		},
 	}
+
+	cmd.Flags().BoolVar(&silent, "silent", false, "只显示节点表格，不显示节点统计")
+	return cmd
 }
 
 // NewTestCmd creates the test command
-func NewTestCmd(ts *service.TestService) *cobra.Command {
-	return &cobra.Command{
+func NewTestCmd(testService func() *service.TestService) *cobra.Command {
+	var auto bool
+	var country, isp, nodeType string
+	var include, exclude []string
+
+	cmd := &cobra.Command{
 		Use:   "test [nodeID]",
 		Short: "Test the speed of a specific node",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := models.NewNodeFilter(include, exclude)
+			if err != nil {
+				return err
+			}
+			filter.ISP = strings.TrimSpace(isp)
+			filter.Type = models.NodeType(strings.TrimSpace(nodeType))
+			if !auto {
+				// 未使用 --auto 时，--country 用于筛选要测试的节点
+				filter.CountryCode = strings.TrimSpace(country)
+			}
+			if !filter.IsEmpty() && (auto || len(args) > 0) {
+				return fmt.Errorf("--include, --exclude, --isp, --country and --type only apply when testing all nodes")
+			}
+
+			if auto {
+				if len(args) > 0 {
+					return fmt.Errorf("--auto cannot be combined with a node ID")
+				}
+				return testService().RunAutoTest(cmd.Context(), country)
+			}
 			if len(args) == 0 {
-				return ts.RunAllTest()
+				return testService().RunFilteredTest(cmd.Context(), filter)
+			}
+			return testService().RunTest(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&auto, "auto", false, "自动选择一个可访问的节点进行测试，优先选择 --country 指定国家/地区中测试文件较大的节点")
+	cmd.Flags().StringVar(&country, "country", "", "测试全部节点时只测试该国家/地区代码 (例如 CN) 的节点；与 --auto 一起使用时为优先选择的国家/地区")
+	cmd.Flags().StringVar(&isp, "isp", "", "测试全部节点时只测试该运营商 (中文或英文名称，不区分大小写) 的节点")
+	cmd.Flags().StringVar(&nodeType, "type", "", "测试全部节点时只测试该类型 (SingleFile|LibreSpeed，不区分大小写) 的节点")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "测试全部节点时只测试匹配的节点：节点 ID 通配符，或 country:<代码>、isp:<运营商>，可用逗号分隔多个")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "测试全部节点时跳过匹配的节点，格式同 --include，与 --include 冲突时以排除为准")
+
+	return cmd
+}
+
+// NewPingCmd creates the ping command
+func NewPingCmd(testService func() *service.TestService) *cobra.Command {
+	var concurrency int
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "ping",
+		Short: "Measure the latency of every node without running speed tests",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if concurrency < 1 {
+				return fmt.Errorf("--concurrency must be positive, got %d", concurrency)
+			}
+			if timeout <= 0 {
+				return fmt.Errorf("--timeout must be positive, got %s", timeout)
 			}
-			return ts.RunTest(args[0])
+			return testService().RunPing(concurrency, timeout)
 		},
 	}
+
+	cmd.Flags().IntVar(&concurrency, "concurrency", service.DefaultPingConcurrency, "同时探测的节点数")
+	cmd.Flags().DurationVar(&timeout, "timeout", service.DefaultPingTimeout, "单个节点的探测超时时间")
+
+	return cmd
+}
+
+// UpdateAvailableExitCode is the exit code of `update --check-only` when a newer release exists
+const UpdateAvailableExitCode = 10
+
+// ExitCodeError ends the program with Code instead of the generic failure exit code.
+// The command has already reported the outcome, so nothing more is printed.
+type ExitCodeError struct {
+	Code int
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// NewUpdateCmd creates the update command
+func NewUpdateCmd(speedTest func() (*service.SpeedTest, error)) *cobra.Command {
+	var checkOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Update aqua-speed to the latest release without running a test",
+		Args:  cobra.NoArgs,
+		// 只需要网络与镜像设置，无需加载节点
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			st, err := speedTest()
+			if err != nil {
+				return err
+			}
+			u := st.GetUpdater()
+
+			if checkOnly {
+				latest, _, _, err := u.GetLatestVersion()
+				if err != nil {
+					return fmt.Errorf("failed to check for updates: %w", err)
+				}
+				if latest.GT(u.Version) {
+					utils.Yellow.Printf("aqua-speed 有可用更新: %s → %s\n", u.Version, latest)
+					return &ExitCodeError{Code: UpdateAvailableExitCode}
+				}
+				utils.Green.Printf("aqua-speed %s 已是最新版本\n", u.Version)
+				return nil
+			}
+
+			before := u.Version.String()
+			fmt.Printf("当前版本: %s\n", before)
+			if err := u.CheckAndUpdate(); err != nil {
+				utils.Red.Println("更新 aqua-speed 失败")
+				return fmt.Errorf("failed to update aqua-speed: %w", err)
+			}
+			if after := installedBinaryVersion(); after != "" && after != before {
+				utils.Green.Printf("更新后版本: %s\n", after)
+			} else {
+				utils.Green.Printf("aqua-speed %s 已是最新版本\n", before)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&checkOnly, "check-only", false, fmt.Sprintf("只检查是否有可用更新而不安装：已是最新版本时以 0 退出，有可用更新时以 %d 退出", UpdateAvailableExitCode))
+	return cmd
 }
 
 // ShowLogo displays the program logo
@@ -54,5 +186,7 @@ func ShowMenu() {
 	utils.Green.Println("请输入要执行选项的数字:")
 	fmt.Printf("1) %s列出所有节点%s\n", utils.Bold, utils.Reset)
 	fmt.Printf("2) %s测试指定节点%s\n", utils.Bold, utils.Reset)
-	fmt.Printf("3) %s退出%s\n", utils.Bold, utils.Reset)
+	fmt.Printf("3) %s自动选择节点测试%s\n", utils.Bold, utils.Reset)
+	fmt.Printf("4) %s测试所有节点%s\n", utils.Bold, utils.Reset)
+	fmt.Printf("5) %s退出%s\n", utils.Bold, utils.Reset)
 }
@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/service"
+	"aqua-speed-tools/internal/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// mirrorReport is the machine-readable form of a mirror test result
+type mirrorReport struct {
+	URL         string  `json:"url"`
+	LatencyMs   float64 `json:"latency_ms,omitempty"`
+	JitterMs    float64 `json:"jitter_ms,omitempty"`
+	SuccessRate float64 `json:"success_rate"`
+	Score       float64 `json:"score,omitempty"`
+	Reachable   bool    `json:"reachable"`
+	Error       string  `json:"error,omitempty"`
+	// ReleaseCapable is set when mirrors were checked against a release sample
+	ReleaseCapable *bool  `json:"release_capable,omitempty"`
+	ReleaseError   string `json:"release_error,omitempty"`
+}
+
+// mirrorSelectionReport is the machine-readable form of the mirror selection
+type mirrorSelectionReport struct {
+	Selected    string  `json:"selected"`
+	LatencyMs   float64 `json:"latency_ms,omitempty"`
+	JitterMs    float64 `json:"jitter_ms,omitempty"`
+	SuccessRate float64 `json:"success_rate,omitempty"`
+	Score       float64 `json:"score,omitempty"`
+	// ReleaseMirror is the mirror used for release downloads when mirrors were checked
+	// against a release sample, empty if none can serve it
+	ReleaseMirror *string        `json:"release_mirror,omitempty"`
+	Alternatives  []mirrorReport `json:"alternatives"`
+}
+
+// configReport is the output of `config show --json`
+type configReport struct {
+	Config *config.Config         `json:"config"`
+	Mirror *mirrorSelectionReport `json:"mirror"`
+}
+
+// NewConfigCmd creates the config command
+func NewConfigCmd(selection func() *service.MirrorSelection) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the effective configuration",
+	}
+
+	var asJSON bool
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective configuration and the selected mirror",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.Get()
+			// 自定义请求头可能包含凭据，输出前屏蔽其值
+			cfg.HTTPHeaders = utils.RedactHeaderLines(cfg.HTTPHeaders)
+			report := configReport{
+				Config: &cfg,
+				Mirror: newMirrorSelectionReport(selection()),
+			}
+			if asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(report)
+			}
+			printConfigReport(report)
+			return nil
+		},
+	}
+	showCmd.Flags().BoolVar(&asJSON, "json", false, "以 JSON 格式输出")
+
+	resetCmd := &cobra.Command{
+		Use:   "reset",
+		Short: "Replace the local config with the default one, backing up the current file",
+		Args:  cobra.NoArgs,
+		// 仅替换配置文件，无需初始化服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := config.GetConfigPath()
+			// 全局的 -y/--assume-yes 使确认直接通过
+			confirmed, err := utils.Confirm(cmd.InOrStdin(), fmt.Sprintf("将使用默认配置覆盖 %s，当前配置会先备份。是否继续?", path))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				utils.Yellow.Println("已取消")
+				return nil
+			}
+
+			backupPath, err := config.ResetConfig(path)
+			if err != nil {
+				return fmt.Errorf("failed to reset config: %w", err)
+			}
+			if backupPath != "" {
+				utils.Green.Printf("已备份原配置到 %s\n", backupPath)
+			}
+			utils.Green.Printf("已恢复默认配置: %s\n", path)
+			return nil
+		},
+	}
+
+	profilesCmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "List the config profiles selectable with --profile",
+		Args:  cobra.NoArgs,
+		// 仅读取配置文件，无需初始化服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := config.ListProfiles()
+			if err != nil {
+				return err
+			}
+
+			table := utils.NewTable([]string{"配置", "路径", "当前", "状态"})
+			for _, profile := range profiles {
+				active, status := "", utils.Green.Sprint("有效")
+				if profile.Active {
+					active = "*"
+				}
+				if profile.Err != nil {
+					status = utils.Red.Sprint(profile.Err.Error())
+				}
+				table.AddRow([]string{profile.Name, profile.Path, active, status})
+			}
+			table.Print()
+			return nil
+		},
+	}
+
+	cmd.AddCommand(showCmd)
+	cmd.AddCommand(resetCmd)
+	cmd.AddCommand(profilesCmd)
+	return cmd
+}
+
+// newMirrorSelectionReport converts a mirror selection into its report form
+func newMirrorSelectionReport(selection *service.MirrorSelection) *mirrorSelectionReport {
+	if selection == nil {
+		return nil
+	}
+
+	report := &mirrorSelectionReport{
+		Selected:     selection.Selected,
+		Alternatives: make([]mirrorReport, 0, len(selection.Candidates)),
+	}
+	if selection.Selected != "" {
+		report.LatencyMs = durationToMs(selection.Latency)
+	}
+	if selection.ReleaseChecked {
+		report.ReleaseMirror = &selection.ReleaseMirror
+	}
+
+	for _, candidate := range selection.Candidates {
+		if candidate.URL == selection.Selected {
+			report.JitterMs = durationToMs(candidate.Jitter)
+			report.SuccessRate = candidate.SuccessRate
+			report.Score = candidate.Score
+			continue
+		}
+		alternative := mirrorReport{
+			URL:         candidate.URL,
+			SuccessRate: candidate.SuccessRate,
+			Reachable:   candidate.Reachable,
+			Error:       candidate.Error,
+		}
+		if selection.ReleaseChecked && candidate.Reachable {
+			alternative.ReleaseCapable = &candidate.ReleaseCapable
+			alternative.ReleaseError = candidate.ReleaseError
+		}
+		if candidate.Reachable {
+			alternative.LatencyMs = durationToMs(candidate.Latency)
+			alternative.JitterMs = durationToMs(candidate.Jitter)
+			alternative.Score = candidate.Score
+		}
+		report.Alternatives = append(report.Alternatives, alternative)
+	}
+
+	return report
+}
+
+// durationToMs converts a duration to fractional milliseconds
+func durationToMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// printConfigReport prints the config report in a human-readable form
+func printConfigReport(report configReport) {
+	cfg := report.Config
+	utils.Green.Println("当前配置:")
+	fmt.Printf("  GitHub API URL: %s\n", cfg.GithubAPIBaseURL)
+	fmt.Printf("  GitHub Raw URL: %s\n", cfg.GithubRawBaseURL)
+	fmt.Printf("  下载超时时间: %ds\n", cfg.DownloadTimeout)
+	fmt.Printf("  日志级别: %s\n", cfg.LogLevel)
+
+	if report.Mirror == nil {
+		utils.Yellow.Println("未启用镜像模式 (使用 --use-mirrors 开启)")
+		return
+	}
+
+	utils.Green.Println("镜像选择:")
+	if report.Mirror.Selected == "" {
+		utils.Red.Println("  所有镜像都不可用")
+	} else {
+		fmt.Printf("  已选择: %s (%s)\n", report.Mirror.Selected,
+			formatMirrorScore(report.Mirror.LatencyMs, report.Mirror.JitterMs, report.Mirror.SuccessRate, report.Mirror.Score))
+	}
+	if report.Mirror.ReleaseMirror != nil {
+		if *report.Mirror.ReleaseMirror == "" {
+			fmt.Printf("  Release 下载: %s\n", utils.Yellow.Sprint("没有镜像能提供 Release 文件，直接从 GitHub 下载"))
+		} else {
+			fmt.Printf("  Release 下载: %s\n", *report.Mirror.ReleaseMirror)
+		}
+	}
+	for _, alternative := range report.Mirror.Alternatives {
+		if alternative.ReleaseCapable != nil && !*alternative.ReleaseCapable {
+			fmt.Printf("  备选: %s (%s, %s)\n", alternative.URL,
+				formatMirrorScore(alternative.LatencyMs, alternative.JitterMs, alternative.SuccessRate, alternative.Score),
+				utils.Yellow.Sprint("仅 Raw 可用: "+alternative.ReleaseError))
+			continue
+		}
+		if alternative.Reachable {
+			fmt.Printf("  备选: %s (%s)\n", alternative.URL,
+				formatMirrorScore(alternative.LatencyMs, alternative.JitterMs, alternative.SuccessRate, alternative.Score))
+		} else {
+			fmt.Printf("  备选: %s (%s)\n", alternative.URL, utils.Red.Sprint("不可用: "+alternative.Error))
+		}
+	}
+}
+
+// formatMirrorScore describes the measurements a mirror was ranked by
+func formatMirrorScore(latencyMs, jitterMs, successRate, score float64) string {
+	return fmt.Sprintf("中位延迟 %.0f ms, 抖动 %.0f ms, 成功率 %.0f%%, 评分 %.1f",
+		latencyMs, jitterMs, successRate*100, score)
+}
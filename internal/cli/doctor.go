@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/updater"
+	"aqua-speed-tools/internal/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// Health check names accepted by `doctor --require`
+const (
+	checkConfig  = "config"  // The config file on disk parses and validates
+	checkNetwork = "network" // The GitHub API is reachable
+	checkBinary  = "binary"  // The aqua-speed binary is installed
+	checkUpdate  = "update"  // No newer aqua-speed release is pending
+)
+
+// doctorChecks lists every health check in the order they run
+var doctorChecks = []string{checkConfig, checkNetwork, checkBinary, checkUpdate}
+
+// doctorNetworkTimeout bounds the network reachability probe
+const doctorNetworkTimeout = 10 * time.Second
+
+// checkResult is the outcome of a single health check
+type checkResult struct {
+	Name     string `json:"name"`
+	OK       bool   `json:"ok"`
+	Required bool   `json:"required"`
+	Message  string `json:"message"`
+}
+
+// doctorReport is the output of `doctor --format json`
+type doctorReport struct {
+	Healthy bool          `json:"healthy"`
+	Checks  []checkResult `json:"checks"`
+}
+
+// NewDoctorCmd creates the doctor command
+func NewDoctorCmd(newUpdater func() (*updater.Updater, error)) *cobra.Command {
+	var require []string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the health of the installation; exits non-zero if a required check fails",
+		Args:  cobra.NoArgs,
+		// 自行检查网络与配置，无需初始化服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range require {
+				if !slices.Contains(doctorChecks, name) {
+					return fmt.Errorf("unknown check %q, expected one of: %s", name, strings.Join(doctorChecks, ", "))
+				}
+			}
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid format %q, expected text or json", format)
+			}
+
+			cmd.SilenceUsage = true
+			// 日志输出到标准输出，JSON 格式下需静默以免破坏输出
+			report := runDoctorChecks(newUpdater, require, format == "json")
+			if format == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				printDoctorReport(report)
+			}
+
+			if !report.Healthy {
+				var failed []string
+				for _, check := range report.Checks {
+					if check.Required && !check.OK {
+						failed = append(failed, check.Name)
+					}
+				}
+				return fmt.Errorf("required checks failed: %s", strings.Join(failed, ", "))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&require, "require", doctorChecks,
+		"必须通过才以 0 退出的检查项，逗号分隔 ("+strings.Join(doctorChecks, "|")+")")
+	cmd.Flags().StringVar(&format, "format", "text", "输出格式 (text|json)")
+
+	return cmd
+}
+
+// runDoctorChecks runs every health check, marking those listed in require.
+// With quiet set, the checks do not log, leaving stdout to the report.
+func runDoctorChecks(newUpdater func() (*updater.Updater, error), require []string, quiet bool) doctorReport {
+	report := doctorReport{Healthy: true}
+	add := func(name string, err error, okMessage string) {
+		result := checkResult{Name: name, OK: err == nil, Required: slices.Contains(require, name), Message: okMessage}
+		if err != nil {
+			result.Message = err.Error()
+			if result.Required {
+				report.Healthy = false
+			}
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	configPath := config.GetConfigPath()
+	add(checkConfig, config.ValidateFile(configPath), configPath)
+
+	apiURL := config.Get().GithubAPIBaseURL
+	add(checkNetwork, probeURL(apiURL), apiURL)
+
+	binaryPath := updater.GetBinaryPath()
+	var binaryErr error
+	if _, err := os.Stat(binaryPath); err != nil {
+		binaryErr = fmt.Errorf("aqua-speed is not installed at %s", binaryPath)
+	}
+	add(checkBinary, binaryErr, binaryPath)
+
+	message, err := checkPendingUpdate(newUpdater, quiet)
+	add(checkUpdate, err, message)
+
+	return report
+}
+
+// probeURL checks that url answers without a server error
+func probeURL(url string) error {
+	client := utils.NewHTTPClient(doctorNetworkTimeout, utils.ProbeTransportOptions)
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return nil
+}
+
+// checkPendingUpdate fails if a newer aqua-speed release than the installed one exists
+func checkPendingUpdate(newUpdater func() (*updater.Updater, error), quiet bool) (string, error) {
+	u, err := newUpdater()
+	if err != nil {
+		return "", err
+	}
+	if quiet {
+		u.SetLogger(zap.NewNop())
+	}
+	latest, _, _, err := u.GetLatestVersion()
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest version: %w", err)
+	}
+	if latest.GT(u.Version) {
+		return "", fmt.Errorf("update pending: %s -> %s", u.Version, latest)
+	}
+	return fmt.Sprintf("up to date (%s)", u.Version), nil
+}
+
+// printDoctorReport prints the health checks in a human-readable form
+func printDoctorReport(report doctorReport) {
+	for _, check := range report.Checks {
+		status := utils.Green.Sprint("✓")
+		if !check.OK {
+			status = utils.Red.Sprint("✗")
+			if !check.Required {
+				status = utils.Yellow.Sprint("!")
+			}
+		}
+		fmt.Printf("%s %-8s %s\n", status, check.Name, check.Message)
+	}
+
+	if report.Healthy {
+		utils.Green.Println("所有必需检查均已通过")
+	} else {
+		utils.Red.Println("存在未通过的必需检查")
+	}
+}
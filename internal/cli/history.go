@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/history"
+	"aqua-speed-tools/internal/service"
+	"aqua-speed-tools/internal/utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewHistoryCmd creates the history command
+func NewHistoryCmd() *cobra.Command {
+	var (
+		nodeID string
+		limit  int
+	)
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the latest speed test results of each node",
+		Args:  cobra.NoArgs,
+		// 仅读取本地历史文件，无需初始化配置与服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if limit < 0 {
+				return fmt.Errorf("--limit must not be negative, got %d", limit)
+			}
+			cmd.SilenceUsage = true
+
+			path := config.GetHistoryPath()
+			runs, err := history.Recent(path, nodeID, limit)
+			if err != nil {
+				if history.IsNotExist(err) {
+					utils.Yellow.Println("暂无测速历史，完成一次测速后将记录到", path)
+					return nil
+				}
+				return err
+			}
+			if runs.Skipped > 0 {
+				utils.Yellow.Printf("跳过了 %d 行无法解析的记录\n", runs.Skipped)
+			}
+			if len(runs.Results) == 0 {
+				if nodeID != "" {
+					utils.Yellow.Printf("节点 %s 没有测速历史\n", nodeID)
+				} else {
+					utils.Yellow.Println("暂无测速历史")
+				}
+				return nil
+			}
+
+			service.PrintTestHistory(runs.Results)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&nodeID, "node", "", "仅显示该节点 ID 的历史")
+	cmd.Flags().IntVar(&limit, "limit", 5, "每个节点显示最近的测速次数，0 表示全部")
+	return cmd
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/updater"
+	"aqua-speed-tools/internal/utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewInstallCmd creates the install command
+func NewInstallCmd(newUpdater func() (*updater.Updater, error)) *cobra.Command {
+	var from, version string
+
+	cmd := &cobra.Command{
+		Use:   "install --from <archive>",
+		Short: "Install aqua-speed from a pre-downloaded release archive",
+		Args:  cobra.NoArgs,
+		// 从本地文件安装，无需初始化服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u, err := newUpdater()
+			if err != nil {
+				return err
+			}
+			if err := u.InstallFromArchive(from, version); err != nil {
+				utils.Red.Printf("安装失败: %s\n", from)
+				return fmt.Errorf("failed to install from %s: %w", from, err)
+			}
+			utils.Green.Printf("已安装 aqua-speed %s\n", u.Version)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "本地的 aqua-speed 发布压缩包路径")
+	cmd.Flags().StringVar(&version, "version", "", "安装的版本号，默认从压缩包文件名解析")
+	_ = cmd.MarkFlagRequired("from")
+
+	return cmd
+}
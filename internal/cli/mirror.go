@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/service"
+	"aqua-speed-tools/internal/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultMirrorCheckTimeout bounds the probe of the rewritten URL
+const defaultMirrorCheckTimeout = 10 * time.Second
+
+// NewMirrorCmd creates the hidden mirror command for trying out mirrors
+func NewMirrorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "mirror",
+		Short:  "Inspect how URLs are rewritten to mirrors",
+		Hidden: true,
+		// 仅测试给定的镜像，无需初始化服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	var sample string
+	var timeout time.Duration
+	checkCmd := &cobra.Command{
+		Use:   "check <mirror-url>",
+		Short: "Rewrite a sample release or raw URL to a mirror and probe whether it can be fetched",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if sample == "" {
+				sample = fmt.Sprintf("https://raw.githubusercontent.com/%s/main/configs/base.json", config.DefaultGithubToolsRepo)
+			}
+			check, err := service.CheckMirror(context.Background(), args[0], sample, timeout)
+			if err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+
+			fmt.Printf("类型:     %s\n", check.Kind)
+			fmt.Printf("原始 URL: %s\n", check.Sample)
+			fmt.Printf("镜像 URL: %s\n", check.Rewritten)
+			if check.Err != nil {
+				utils.Red.Printf("结果:     不可用 (%v)\n", check.Err)
+				return fmt.Errorf("mirror %s cannot serve %s", args[0], sample)
+			}
+			utils.Green.Printf("结果:     可用 (HTTP %d，%s)\n", check.Status, check.Latency.Round(time.Millisecond))
+			return nil
+		},
+	}
+	checkCmd.Flags().StringVar(&sample, "url", "", "用于测试的 GitHub Release 下载地址或 raw.githubusercontent.com 地址，默认为本工具的默认配置文件")
+	checkCmd.Flags().DurationVar(&timeout, "timeout", defaultMirrorCheckTimeout, "探测镜像地址的超时时间")
+
+	cmd.AddCommand(checkCmd)
+	return cmd
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/service"
+	"aqua-speed-tools/internal/utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewNodesCmd creates the nodes command
+func NewNodesCmd(speedTest func() *service.SpeedTest) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "nodes",
+		Short: "Manage the speed test node list",
+	}
+
+	var format string
+	var bom bool
+	exportCmd := &cobra.Command{
+		Use:   "export <file>",
+		Short: "Export the current node list to a file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch format {
+			case service.ExportFormatJSON, service.ExportFormatCSV, service.ExportFormatHTML:
+			default:
+				return fmt.Errorf("unsupported export format: %s (expected json, csv or html)", format)
+			}
+			if bom && format != service.ExportFormatCSV {
+				return fmt.Errorf("--bom only applies to csv export")
+			}
+			if err := speedTest().ExportNodes(args[0], format, bom); err != nil {
+				return err
+			}
+			utils.Green.Printf("Exported %d nodes to %s\n", len(speedTest().GetNodes()), args[0])
+			return nil
+		},
+	}
+	exportCmd.Flags().StringVar(&format, "format", service.ExportFormatJSON, "导出格式 (json|csv|html)，json 保留节点的 headers 与 authToken 以便通过 --nodes 重新加载，csv 与 html 中的凭据会被隐藏")
+	exportCmd.Flags().BoolVar(&bom, "bom", false, "CSV 文件开头写入 UTF-8 BOM，使 Windows 上的 Excel 正确显示中文")
+
+	diffCmd := &cobra.Command{
+		Use:   "diff <old> <new>",
+		Short: "Show added, removed and changed nodes between two node list files",
+		Args:  cobra.ExactArgs(2),
+		// 比较本地文件，无需初始化配置与服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			oldNodes, err := service.LoadNodeListFile(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid old node list %s: %w", args[0], err)
+			}
+			newNodes, err := service.LoadNodeListFile(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid new node list %s: %w", args[1], err)
+			}
+
+			printNodeListDiff(service.DiffNodeLists(oldNodes, newNodes))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(exportCmd)
+	cmd.AddCommand(diffCmd)
+	return cmd
+}
+
+// printNodeListDiff renders a node list diff as a table
+func printNodeListDiff(diff service.NodeListDiff) {
+	if diff.Empty() {
+		utils.Green.Println("节点列表没有变化")
+		return
+	}
+
+	table := utils.NewTable([]string{"变更", "节点ID", "字段", "旧值", "新值"})
+	for _, node := range diff.Added {
+		table.AddRow([]string{utils.Green.Sprint("新增"), node.Id, "", "", node.Name.Zh})
+	}
+	for _, node := range diff.Removed {
+		table.AddRow([]string{utils.Red.Sprint("删除"), node.Id, "", node.Name.Zh, ""})
+	}
+	for _, change := range diff.Changed {
+		for _, field := range change.Changes {
+			table.AddRow([]string{utils.Yellow.Sprint("修改"), change.ID, field.Field, field.Old, field.New})
+		}
+	}
+	table.Print()
+
+	fmt.Printf("新增 %d 个，删除 %d 个，修改 %d 个节点\n",
+		len(diff.Added), len(diff.Removed), len(diff.Changed))
+}
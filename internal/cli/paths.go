@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/updater"
+	"aqua-speed-tools/internal/utils"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPathsCmd creates the paths command
+func NewPathsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "paths",
+		Short: "Print the directories and files the tool reads and writes",
+		// 仅输出路径信息，无需初始化配置与服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := [][2]string{
+				{"安装目录", updater.GetInstallDir()},
+				{"配置目录", config.GetConfigDir()},
+				{"配置文件", config.GetConfigPath()},
+				{"镜像失败缓存", config.GetMirrorFailureCachePath()},
+				{"节点列表缓存", config.GetNodeCachePath()},
+				{"测速历史", config.GetHistoryPath()},
+				{"测速程序", updater.GetBinaryPath()},
+				{"版本文件", updater.GetVersionFilePath()},
+			}
+			if executable, err := os.Executable(); err == nil {
+				paths = append(paths, [2]string{"当前程序", executable})
+			}
+
+			table := utils.NewTable([]string{"名称", "路径", "存在", "权限"})
+			for _, path := range paths {
+				exists, mode := "否", "-"
+				if info, err := os.Stat(path[1]); err == nil {
+					exists, mode = "是", info.Mode().String()
+				}
+				table.AddRow([]string{path[0], path[1], exists, mode})
+			}
+			table.Print()
+			return nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/updater"
+	"aqua-speed-tools/internal/utils"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+// NewPlatformsCmd creates the platforms command
+func NewPlatformsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "platforms",
+		Short: "List the OS/arch combinations the updater can name and fetch releases for",
+		// 仅输出平台信息，无需初始化配置与服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			host := updater.HostPlatform()
+			template := config.Get().Binary.AssetTemplate
+
+			table := utils.NewTable([]string{"系统", "架构", "测速程序", "发布压缩包", "当前"})
+			for _, platform := range updater.SupportedPlatforms {
+				arch := updater.NormalizeArch(platform.Arch)
+				current := ""
+				if platform == host {
+					current = "✓"
+				}
+				table.AddRow([]string{
+					platform.OS,
+					arch,
+					updater.FormatBinaryName("aqua-speed", platform.OS, arch),
+					updater.FormatCompressedName(template, platform.OS, arch, "{version}"),
+					current,
+				})
+			}
+			table.Print()
+
+			if !slices.Contains(updater.SupportedPlatforms, host) {
+				utils.Yellow.Printf("当前平台 %s-%s 没有对应的发布版本\n", host.OS, host.Arch)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/service"
+	"aqua-speed-tools/internal/utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewReportCmd creates the report command
+func NewReportCmd() *cobra.Command {
+	var metric string
+	cmd := &cobra.Command{
+		Use:   "report <jsonl-file>",
+		Short: "Aggregate speed test results saved with --results-jsonl into per-node statistics",
+		Args:  cobra.ExactArgs(1),
+		// 仅读取本地文件，无需初始化配置与服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch metric {
+			case service.ReportMetricDownload, service.ReportMetricUpload, service.ReportMetricLatency:
+			default:
+				return fmt.Errorf("unsupported metric: %s (expected download, upload or latency)", metric)
+			}
+			cmd.SilenceUsage = true
+
+			report, err := service.LoadHistoryReport(args[0], metric)
+			if err != nil {
+				return err
+			}
+			if report.Skipped > 0 {
+				utils.Yellow.Printf("跳过了 %d 行无法解析的记录\n", report.Skipped)
+			}
+			if len(report.Nodes) == 0 {
+				utils.Yellow.Printf("%s 中没有包含 %s 数据的测速结果\n", args[0], metric)
+				return nil
+			}
+
+			utils.Green.Printf("共 %d 条测速结果，%d 个节点 (%s)\n", report.Results, len(report.Nodes), metric)
+			service.PrintHistoryReport(report)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&metric, "metric", service.ReportMetricDownload, "统计的指标 (download|upload|latency)")
+	return cmd
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/updater"
+	"aqua-speed-tools/internal/utils"
+	"cmp"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewRollbackCmd creates the rollback command
+func NewRollbackCmd(newUpdater func() (*updater.Updater, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore the aqua-speed binary replaced by the last update",
+		Args:  cobra.NoArgs,
+		// 仅操作本地文件，无需初始化服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			u, err := newUpdater()
+			if err != nil {
+				return err
+			}
+
+			previous, err := u.BackupVersion()
+			if errors.Is(err, updater.ErrNoBackup) {
+				utils.Yellow.Println("没有可回滚的版本，更新后才会保留上一版本的测速程序")
+				return err
+			}
+			current := cmp.Or(installedBinaryVersion(), "未知")
+
+			if err := u.Rollback(); err != nil {
+				utils.Red.Println("回滚失败")
+				return fmt.Errorf("failed to roll back: %w", err)
+			}
+			utils.Green.Printf("已回滚 aqua-speed: %s -> %s\n", current, cmp.Or(previous, "未知"))
+			utils.Yellow.Println("再次执行 rollback 可恢复回滚前的版本")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/updater"
+	"aqua-speed-tools/internal/utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewSelfUpdateCmd creates the self-update command
+func NewSelfUpdateCmd(newSelfUpdater func() (*updater.Updater, error)) *cobra.Command {
+	var noRestart bool
+
+	cmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update aqua-speed-tools itself to the latest release",
+		Args:  cobra.NoArgs,
+		// 只需要网络与镜像设置，无需初始化测速服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			u, err := newSelfUpdater()
+			if err != nil {
+				return err
+			}
+
+			exe, updated, err := u.SelfUpdate()
+			if err != nil {
+				utils.Red.Println("更新 aqua-speed-tools 失败")
+				return fmt.Errorf("failed to update aqua-speed-tools: %w", err)
+			}
+			if !updated {
+				utils.Green.Printf("aqua-speed-tools %s 已是最新版本\n", u.Version)
+				return nil
+			}
+			if noRestart {
+				return nil
+			}
+
+			// 启动新版本输出版本信息，确认替换后的程序可以正常运行
+			return updater.ExecReplaced(exe, []string{"version"})
+		},
+	}
+
+	cmd.Flags().BoolVar(&noRestart, "no-restart", false, "更新后不启动新版本显示版本信息")
+	return cmd
+}
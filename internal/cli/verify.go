@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/updater"
+	"aqua-speed-tools/internal/utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// NewVerifyCmd creates the verify command
+func NewVerifyCmd(newUpdater func() (*updater.Updater, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify <archive> <checksum>",
+		Short: "Verify a downloaded release archive against a SHA1 or SHA256 checksum without installing it",
+		Args:  cobra.ExactArgs(2),
+		// 仅校验本地文件，无需初始化服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u, err := newUpdater()
+			if err != nil {
+				return err
+			}
+			if err := u.VerifyArchive(args[0], args[1]); err != nil {
+				utils.Red.Printf("校验失败: %s\n", args[0])
+				return fmt.Errorf("failed to verify %s: %w", args[0], err)
+			}
+			utils.Green.Printf("校验通过: %s\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/updater"
+	"aqua-speed-tools/internal/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// versionInfo is the output of `version --json`
+type versionInfo struct {
+	Version       string `json:"version"`                 // Compiled tool version
+	ConfigVersion string `json:"configVersion"`           // Script version of the loaded config
+	BinaryVersion string `json:"binaryVersion,omitempty"` // Installed aqua-speed version, empty if not installed
+	GoVersion     string `json:"goVersion"`
+	OS            string `json:"os"`
+	Arch          string `json:"arch"`
+	Commit        string `json:"commit,omitempty"` // Build commit, empty unless injected with ldflags
+}
+
+// NewVersionCmd creates the version command
+func NewVersionCmd() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the tool, config and installed aqua-speed versions",
+		Args:  cobra.NoArgs,
+		// 仅输出版本信息，无需初始化服务
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := versionInfo{
+				Version:       utils.AppVersion,
+				ConfigVersion: config.Get().Script.Version,
+				BinaryVersion: installedBinaryVersion(),
+				GoVersion:     runtime.Version(),
+				OS:            runtime.GOOS,
+				Arch:          runtime.GOARCH,
+				Commit:        utils.Commit,
+			}
+
+			if asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(info)
+			}
+
+			fmt.Printf("aqua-speed-tools %s (配置版本 %s)\n", info.Version, info.ConfigVersion)
+			if info.BinaryVersion != "" {
+				fmt.Printf("aqua-speed       %s\n", info.BinaryVersion)
+			} else {
+				fmt.Println("aqua-speed       未安装")
+			}
+			fmt.Printf("Go               %s %s/%s\n", info.GoVersion, info.OS, info.Arch)
+			if info.Commit != "" {
+				fmt.Printf("Commit           %s\n", info.Commit)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "以 JSON 格式输出")
+	return cmd
+}
+
+// installedBinaryVersion returns the aqua-speed version recorded in version.txt, empty if none
+func installedBinaryVersion() string {
+	content, err := updater.ReadFileContent(updater.GetVersionFilePath())
+	if err != nil {
+		return ""
+	}
+	if fields := strings.Fields(content); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
@@ -4,26 +4,48 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 
+	"aqua-speed-tools/configs"
 	"aqua-speed-tools/internal/github"
+	"aqua-speed-tools/internal/utils"
+
+	"go.uber.org/zap"
 )
 
+// apiRetryMaxBackoff caps the wait between attempts of a latest release lookup
+const apiRetryMaxBackoff = 30 * time.Second
+
 // Config represents the application configuration
 type Config struct {
-	Script               ScriptConfig         `json:"script"`
-	GithubRawJsdelivrSet []string             `json:"github_raw_jsdelivr_set"`
-	DNSOverHTTPSSet      []DNSOverHTTPSConfig `json:"dns_over_https_set"`
-	GithubRawBaseURL     string               `json:"github_raw_base_url"`
-	GithubAPIBaseURL     string               `json:"github_api_base_url"`
-	GithubAPIMagicURL    string               `json:"github_api_magic_url"`
-	TablePadding         int                  `json:"table_padding"`
-	LogLevel             string               `json:"log_level"`
-	DownloadTimeout      int                  `json:"download_timeout"`
+	Binary                   BinaryConfig         `json:"binary"`
+	Script                   ScriptConfig         `json:"script"`
+	GithubRawJsdelivrSet     []string             `json:"github_raw_jsdelivr_set"`
+	MirrorReleaseURL         string               `json:"mirror_release_url,omitempty"`
+	DNSOverHTTPSSet          []DNSOverHTTPSConfig `json:"dns_over_https_set"`
+	GithubRawBaseURL         string               `json:"github_raw_base_url"`
+	GithubAPIBaseURL         string               `json:"github_api_base_url"`
+	GithubAPIMagicURL        string               `json:"github_api_magic_url"`
+	TablePadding             int                  `json:"table_padding"`
+	LogLevel                 string               `json:"log_level"`
+	DownloadTimeout          int                  `json:"download_timeout"`
+	SpeedThresholds          SpeedThresholds      `json:"speed_thresholds"`
+	Retry                    RetryConfig          `json:"retry"`
+	APIRetryAttempts         int                  `json:"api_retry_attempts"` // Attempts of a latest release lookup, including the first one
+	APIRetryBaseMs           int                  `json:"api_retry_base_ms"`  // Milliseconds before the first retry of a release lookup, doubled per retry
+	DNSCacheMaxTTL           int                  `json:"dns_cache_max_ttl"`  // Longest time in seconds a DNS answer is cached for, whatever its TTL
+	MirrorTestRounds         int                  `json:"mirror_test_rounds"`
+	MirrorFailureCooldown    int                  `json:"mirror_failure_cooldown"`
+	DirectProbeTimeout       float64              `json:"direct_probe_timeout"` // Seconds direct GitHub access may take in auto mirror mode
+	AllowUnknownCountryCodes bool                 `json:"allow_unknown_country_codes"`
+	Download                 DownloadConfig       `json:"download"`
+	// HTTPHeaders are added to every HTTP request, in the "Name: Value" form
+	HTTPHeaders []string `json:"http_headers,omitempty"`
 }
 
 // ScriptConfig represents the script configuration
@@ -32,6 +54,21 @@ type ScriptConfig struct {
 	Prefix  string `json:"prefix"`
 }
 
+// BinaryConfig represents the aqua-speed binary configuration
+type BinaryConfig struct {
+	Prefix string `json:"prefix"`
+	// AssetTemplate is the release asset name without extension, supporting
+	// the {os}, {arch} and {version} placeholders
+	AssetTemplate string `json:"asset_template"`
+	// ChecksumsPublicKey is the minisign public key the release checksums.txt
+	// signature is verified with. When set, every install requires the signed file
+	// to cover the binaries or the archive; empty to skip verification
+	ChecksumsPublicKey string `json:"checksums_public_key,omitempty"`
+	// ExtraBinaries lists helper binaries bundled in the release archive that are
+	// installed next to the main binary, each verified against its checksum entry
+	ExtraBinaries []string `json:"extra_binaries,omitempty"`
+}
+
 // DNSOverHTTPSConfig represents the DNS over HTTPS configuration
 type DNSOverHTTPSConfig struct {
 	Endpoint string `json:"endpoint"`
@@ -39,6 +76,47 @@ type DNSOverHTTPSConfig struct {
 	Retries  int    `json:"retries"`
 }
 
+// DownloadConfig restricts where release downloads may connect to
+type DownloadConfig struct {
+	MaxRedirects int `json:"max_redirects"` // Redirects a download may follow
+	// AllowedHosts are the only hosts downloads may connect to, together with the
+	// hosts of the configured mirrors; empty allows any host not denied.
+	// An entry also matches its subdomains.
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
+	DeniedHosts  []string `json:"denied_hosts,omitempty"` // Hosts downloads never connect to, including subdomains
+}
+
+// SpeedThresholds represents the speed color thresholds in Mbps
+type SpeedThresholds struct {
+	Good float64 `json:"good"` // Speeds above this are shown in green
+	Fair float64 `json:"fair"` // Speeds above this are shown in yellow, otherwise red
+}
+
+// RetryConfig represents the retry and backoff configuration for network requests
+type RetryConfig struct {
+	Attempts    int     `json:"attempts"`     // Total attempts, including the first one
+	BaseBackoff float64 `json:"base_backoff"` // Seconds to wait before the first retry, doubled per retry
+	MaxBackoff  float64 `json:"max_backoff"`  // Upper bound in seconds for the wait between attempts
+}
+
+// Policy converts the retry configuration into a utils.RetryPolicy
+func (r RetryConfig) Policy() utils.RetryPolicy {
+	return utils.RetryPolicy{
+		Attempts:    r.Attempts,
+		BaseBackoff: time.Duration(r.BaseBackoff * float64(time.Second)),
+		MaxBackoff:  time.Duration(r.MaxBackoff * float64(time.Second)),
+	}
+}
+
+// APIRetryPolicy returns the retry policy of latest release lookups
+func (c *Config) APIRetryPolicy() utils.RetryPolicy {
+	return utils.RetryPolicy{
+		Attempts:    c.APIRetryAttempts,
+		BaseBackoff: time.Duration(c.APIRetryBaseMs) * time.Millisecond,
+		MaxBackoff:  apiRetryMaxBackoff,
+	}
+}
+
 // ConfigError represents a configuration error
 type ConfigError struct {
 	Field   string
@@ -49,10 +127,60 @@ func (e *ConfigError) Error() string {
 	return fmt.Sprintf("Configuration error: %s - %s", e.Field, e.Message)
 }
 
+// FirstRunError is returned when no configuration file exists and no default could be obtained
+type FirstRunError struct {
+	Path string // Where the configuration file is expected
+	Err  error  // Why the default configuration could not be downloaded
+}
+
+func (e *FirstRunError) Error() string {
+	return fmt.Sprintf("no configuration found at %s and the default configuration could not be downloaded (%v); "+
+		"this looks like a first run: connect to the network and try again, or place a config file at %s manually",
+		e.Path, e.Err, e.Path)
+}
+
+func (e *FirstRunError) Unwrap() error {
+	return e.Err
+}
+
 var (
+	// Version is the tools version stamped by the release build with -ldflags,
+	// matching the release tag; empty in development builds
+	Version string
+
 	// ConfigReader is the global configuration reader
 	ConfigReader = &Config{}
 
+	// DefaultSpeedThresholds is used when the config file has no speed thresholds
+	DefaultSpeedThresholds = SpeedThresholds{Good: 100, Fair: 20}
+
+	// DefaultAssetTemplate matches the release asset naming of aqua-speed
+	DefaultAssetTemplate = "aqua-speed-{os}-{arch}_v{version}"
+
+	// DefaultMirrorTestRounds is the number of times each mirror is probed during selection
+	DefaultMirrorTestRounds = 3
+
+	// DefaultMirrorFailureCooldown is how long, in seconds, a failed mirror is skipped during selection
+	DefaultMirrorFailureCooldown = 300
+
+	// DefaultDirectProbeTimeout is how long, in seconds, direct GitHub access may take before auto mirror mode uses mirrors
+	DefaultDirectProbeTimeout = 3.0
+
+	// DefaultMaxRedirects is the number of redirects a download may follow
+	DefaultMaxRedirects = 10
+
+	// DefaultRetry is used for retry fields missing from the config file
+	DefaultRetry = RetryConfig{Attempts: 3, BaseBackoff: 2, MaxBackoff: 2}
+
+	// DefaultAPIRetryAttempts is the number of attempts of a latest release lookup
+	DefaultAPIRetryAttempts = 4
+
+	// DefaultAPIRetryBaseMs is how long, in milliseconds, to wait before retrying a latest release lookup
+	DefaultAPIRetryBaseMs = 500
+
+	// DefaultDNSCacheMaxTTL is the longest time, in seconds, a DNS answer is cached for
+	DefaultDNSCacheMaxTTL = int(utils.DefaultDNSMaxCacheTTL / time.Second)
+
 	// 硬编码的仓库信息
 	DefaultGithubRepo      = "alice39s/aqua-speed"
 	DefaultGithubToolsRepo = "alice39s/aqua-speed-tools"
@@ -73,11 +201,31 @@ func GetConfigDir() string {
 	}
 }
 
+// GetConfigPath returns the configuration file path of the selected profile
+func GetConfigPath() string {
+	return GetProfilePath(activeProfile)
+}
+
+// GetMirrorFailureCachePath returns the file recording recently failed mirrors
+func GetMirrorFailureCachePath() string {
+	return filepath.Join(GetConfigDir(), "mirror-failures.json")
+}
+
+// GetHistoryPath returns the JSONL file keeping the results of completed speed tests
+func GetHistoryPath() string {
+	return filepath.Join(GetConfigDir(), "history.jsonl")
+}
+
+// GetNodeCachePath returns the file keeping the last successfully fetched node list
+func GetNodeCachePath() string {
+	return filepath.Join(GetConfigDir(), "nodes-cache.json")
+}
+
 // LoadConfig loads the configuration from a file
 func LoadConfig(configPath string) error {
 	// 如果没有指定配置路径，使用默认路径
 	if configPath == "" {
-		configPath = filepath.Join(GetConfigDir(), "base.json")
+		configPath = GetConfigPath()
 	}
 
 	data, err := os.ReadFile(configPath)
@@ -88,14 +236,9 @@ func LoadConfig(configPath string) error {
 				return fmt.Errorf("failed to create config directory: %w", err)
 			}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-
-			client := github.NewClient(nil, "", "")
-			owner, repo := splitRepo(DefaultGithubToolsRepo)
-			data, err = client.GetDefaultConfig(ctx, owner, repo)
+			data, err = FetchDefaultConfig()
 			if err != nil {
-				return fmt.Errorf("failed to download default config: %w", err)
+				return &FirstRunError{Path: configPath, Err: err}
 			}
 
 			if err := os.WriteFile(configPath, data, 0644); err != nil {
@@ -106,17 +249,150 @@ func LoadConfig(configPath string) error {
 		}
 	}
 
-	if err := json.Unmarshal(data, ConfigReader); err != nil {
-		return fmt.Errorf("failed to parse config file: %w", err)
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return err
 	}
 
-	if err := validateConfig(ConfigReader); err != nil {
-		return fmt.Errorf("config validation failed: %w", err)
-	}
+	mu.Lock()
+	*ConfigReader = *cfg
+	mu.Unlock()
 
 	return nil
 }
 
+// FetchDefaultConfig downloads the default configuration from the tools repository,
+// falling back to the embedded default when the download fails or is invalid. A URL set with
+// SetDefaultConfigURL is used instead, without the fallback.
+func FetchDefaultConfig() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	httpClient := utils.NewHTTPClient(30*time.Second, utils.APITransportOptions)
+	if defaultConfigURL != "" {
+		// 自定义地址的配置不回退到内置默认配置，以免静默使用上游仓库的设置
+		return fetchConfigURL(ctx, httpClient, defaultConfigURL)
+	}
+
+	client := github.NewClient(httpClient, "", "")
+	owner, repo := splitRepo(DefaultGithubToolsRepo)
+	data, err := client.GetDefaultConfig(ctx, owner, repo)
+	if err == nil {
+		// 写入前先校验，避免将无法使用的配置保存为配置文件
+		if _, parseErr := parseConfig(data); parseErr != nil {
+			err = fmt.Errorf("downloaded default config is invalid: %w", parseErr)
+		}
+	}
+	if err != nil {
+		// 无法下载时使用内置的默认配置
+		if _, parseErr := parseConfig(configs.DefaultBase); parseErr != nil {
+			return nil, err
+		}
+		utils.Warning("无法下载默认配置，使用内置默认配置", zap.Error(err))
+		return configs.DefaultBase, nil
+	}
+	return data, nil
+}
+
+// ResetConfig overwrites the configuration file at path with the default
+// configuration, first copying an existing file to a timestamped backup.
+// It returns the backup path, empty if there was no file to back up.
+func ResetConfig(path string) (string, error) {
+	data, err := FetchDefaultConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch default config: %w", err)
+	}
+	if _, err := parseConfig(data); err != nil {
+		return "", fmt.Errorf("default config is invalid: %w", err)
+	}
+
+	var backupPath string
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		backupPath = fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+		if err := os.WriteFile(backupPath, existing, 0644); err != nil {
+			return "", fmt.Errorf("failed to back up config: %w", err)
+		}
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("failed to create config directory: %w", err)
+		}
+	default:
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return backupPath, fmt.Errorf("failed to write default config: %w", err)
+	}
+	return backupPath, nil
+}
+
+// ValidateFile reads and validates the configuration file at path without
+// changing the loaded configuration
+func ValidateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	_, err = parseConfig(data)
+	return err
+}
+
+// parseConfig parses, completes and validates configuration file content
+func parseConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	presetDefaults(cfg)
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	applyDefaults(cfg)
+
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// presetDefaults sets the defaults of optional fields for which zero is a valid setting
+// before the file is decoded, so that only the fields missing from it get the default
+func presetDefaults(cfg *Config) {
+	cfg.MirrorFailureCooldown = DefaultMirrorFailureCooldown
+	cfg.Download.MaxRedirects = DefaultMaxRedirects
+	// JSON cannot encode NaN, so it marks a missing max_backoff, whose default depends on base_backoff
+	cfg.Retry = RetryConfig{Attempts: DefaultRetry.Attempts, BaseBackoff: DefaultRetry.BaseBackoff, MaxBackoff: math.NaN()}
+}
+
+// applyDefaults fills in optional fields missing from the configuration
+func applyDefaults(cfg *Config) {
+	if cfg.Binary.AssetTemplate == "" {
+		cfg.Binary.AssetTemplate = DefaultAssetTemplate
+	}
+	if cfg.SpeedThresholds.Good == 0 && cfg.SpeedThresholds.Fair == 0 {
+		cfg.SpeedThresholds = DefaultSpeedThresholds
+	}
+	if cfg.MirrorTestRounds == 0 {
+		cfg.MirrorTestRounds = DefaultMirrorTestRounds
+	}
+	if cfg.DirectProbeTimeout == 0 {
+		cfg.DirectProbeTimeout = DefaultDirectProbeTimeout
+	}
+	if math.IsNaN(cfg.Retry.MaxBackoff) {
+		cfg.Retry.MaxBackoff = max(DefaultRetry.MaxBackoff, cfg.Retry.BaseBackoff)
+	}
+	if cfg.APIRetryAttempts == 0 {
+		cfg.APIRetryAttempts = DefaultAPIRetryAttempts
+	}
+	if cfg.APIRetryBaseMs == 0 {
+		cfg.APIRetryBaseMs = DefaultAPIRetryBaseMs
+	}
+	if cfg.DNSCacheMaxTTL == 0 {
+		cfg.DNSCacheMaxTTL = DefaultDNSCacheMaxTTL
+	}
+}
+
 // validateConfig validates the configuration
 func validateConfig(cfg *Config) error {
 	// Validate Script
@@ -127,6 +403,16 @@ func validateConfig(cfg *Config) error {
 		return &ConfigError{Field: "Script.Prefix", Message: "cannot be empty"}
 	}
 
+	// Validate Binary
+	if !strings.Contains(cfg.Binary.AssetTemplate, "{os}") || !strings.Contains(cfg.Binary.AssetTemplate, "{arch}") {
+		return &ConfigError{Field: "Binary.AssetTemplate", Message: "must contain the {os} and {arch} placeholders"}
+	}
+	for i, name := range cfg.Binary.ExtraBinaries {
+		if name == "" || filepath.Base(name) != name {
+			return &ConfigError{Field: fmt.Sprintf("Binary.ExtraBinaries[%d]", i), Message: "must be a file name"}
+		}
+	}
+
 	// Validate GitHubRawJsdelivrSet
 	if len(cfg.GithubRawJsdelivrSet) == 0 {
 		return &ConfigError{Field: "GitHubRawJsdelivrSet", Message: "must contain at least one URL"}
@@ -155,6 +441,73 @@ func validateConfig(cfg *Config) error {
 		return &ConfigError{Field: "DownloadTimeout", Message: "must be greater than 0"}
 	}
 
+	// Validate SpeedThresholds
+	if cfg.SpeedThresholds.Fair < 0 {
+		return &ConfigError{Field: "SpeedThresholds.Fair", Message: "cannot be negative"}
+	}
+	if cfg.SpeedThresholds.Good < cfg.SpeedThresholds.Fair {
+		return &ConfigError{Field: "SpeedThresholds.Good", Message: "must not be less than SpeedThresholds.Fair"}
+	}
+
+	// Validate MirrorTestRounds
+	if cfg.MirrorTestRounds < 1 {
+		return &ConfigError{Field: "MirrorTestRounds", Message: "must be at least 1"}
+	}
+	if cfg.MirrorFailureCooldown < 0 {
+		return &ConfigError{Field: "MirrorFailureCooldown", Message: "cannot be negative"}
+	}
+	if cfg.DirectProbeTimeout < 0 {
+		return &ConfigError{Field: "DirectProbeTimeout", Message: "cannot be negative"}
+	}
+
+	// Validate Download
+	if cfg.Download.MaxRedirects < 0 {
+		return &ConfigError{Field: "Download.MaxRedirects", Message: "cannot be negative"}
+	}
+	if err := validateHosts("Download.AllowedHosts", cfg.Download.AllowedHosts); err != nil {
+		return err
+	}
+	if err := validateHosts("Download.DeniedHosts", cfg.Download.DeniedHosts); err != nil {
+		return err
+	}
+
+	// Validate HTTPHeaders
+	for i, header := range cfg.HTTPHeaders {
+		if _, _, err := utils.ParseHeader(header); err != nil {
+			return &ConfigError{Field: fmt.Sprintf("HTTPHeaders[%d]", i), Message: err.Error()}
+		}
+	}
+
+	// Validate Retry
+	if cfg.Retry.Attempts < 1 {
+		return &ConfigError{Field: "Retry.Attempts", Message: "must be at least 1"}
+	}
+	if cfg.Retry.BaseBackoff < 0 {
+		return &ConfigError{Field: "Retry.BaseBackoff", Message: "cannot be negative"}
+	}
+	if cfg.Retry.MaxBackoff < cfg.Retry.BaseBackoff {
+		return &ConfigError{Field: "Retry.MaxBackoff", Message: "must not be less than Retry.BaseBackoff"}
+	}
+	if cfg.APIRetryAttempts < 1 {
+		return &ConfigError{Field: "APIRetryAttempts", Message: "must be at least 1"}
+	}
+	if cfg.APIRetryBaseMs < 0 {
+		return &ConfigError{Field: "APIRetryBaseMs", Message: "cannot be negative"}
+	}
+	if cfg.DNSCacheMaxTTL < 0 {
+		return &ConfigError{Field: "DNSCacheMaxTTL", Message: "cannot be negative"}
+	}
+
+	return nil
+}
+
+// validateHosts checks that every entry of a host list is a bare hostname
+func validateHosts(field string, hosts []string) error {
+	for i, host := range hosts {
+		if host == "" || strings.ContainsAny(host, "/:") {
+			return &ConfigError{Field: fmt.Sprintf("%s[%d]", field, i), Message: "must be a hostname without scheme, port or path"}
+		}
+	}
 	return nil
 }
 
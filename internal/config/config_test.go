@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"aqua-speed-tools/internal/utils"
+)
+
+// parseBaseConfig parses configs/base.json with changes applied
+func parseBaseConfig(t *testing.T, changes map[string]any) (*Config, error) {
+	t.Helper()
+	data, err := os.ReadFile(writeBaseConfig(t, t.TempDir(), changes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parseConfig(data)
+}
+
+func TestParseConfigZeroSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]any
+		got     func(cfg *Config) int
+		want    int
+	}{
+		{
+			name:    "mirror_failure_cooldown missing",
+			changes: map[string]any{"mirror_failure_cooldown": nil},
+			got:     func(cfg *Config) int { return cfg.MirrorFailureCooldown },
+			want:    DefaultMirrorFailureCooldown,
+		},
+		{
+			name:    "mirror_failure_cooldown disabled",
+			changes: map[string]any{"mirror_failure_cooldown": 0},
+			got:     func(cfg *Config) int { return cfg.MirrorFailureCooldown },
+			want:    0,
+		},
+		{
+			name:    "mirror_failure_cooldown custom",
+			changes: map[string]any{"mirror_failure_cooldown": 60},
+			got:     func(cfg *Config) int { return cfg.MirrorFailureCooldown },
+			want:    60,
+		},
+		{
+			name:    "download.max_redirects missing",
+			changes: map[string]any{"download": map[string]any{}},
+			got:     func(cfg *Config) int { return cfg.Download.MaxRedirects },
+			want:    DefaultMaxRedirects,
+		},
+		{
+			name:    "download.max_redirects disabled",
+			changes: map[string]any{"download": map[string]any{"max_redirects": 0}},
+			got:     func(cfg *Config) int { return cfg.Download.MaxRedirects },
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseBaseConfig(t, tt.changes)
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+			if got := tt.got(cfg); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfigRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   map[string]any
+		want    RetryConfig
+		wantErr bool
+	}{
+		{name: "missing", retry: map[string]any{}, want: DefaultRetry},
+		{
+			name:  "no backoff",
+			retry: map[string]any{"base_backoff": 0, "max_backoff": 0},
+			want:  RetryConfig{Attempts: 3, BaseBackoff: 0, MaxBackoff: 0},
+		},
+		{
+			name:  "max_backoff follows base_backoff",
+			retry: map[string]any{"attempts": 5, "base_backoff": 4},
+			want:  RetryConfig{Attempts: 5, BaseBackoff: 4, MaxBackoff: 4},
+		},
+		{name: "no attempts", retry: map[string]any{"attempts": 0}, wantErr: true},
+		{name: "negative base_backoff", retry: map[string]any{"base_backoff": -1}, wantErr: true},
+		{name: "max_backoff below base_backoff", retry: map[string]any{"base_backoff": 2, "max_backoff": 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseBaseConfig(t, map[string]any{"retry": tt.retry})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseConfig() retry = %+v, want error", cfg.Retry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+			if cfg.Retry != tt.want {
+				t.Errorf("Retry = %+v, want %+v", cfg.Retry, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchConfigURLGzip(t *testing.T) {
+	data, err := os.ReadFile(writeBaseConfig(t, t.TempDir(), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(data)
+	gz.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+
+	// Without transport compression the body arrives still compressed
+	client := utils.NewHTTPClient(0, utils.DownloadTransportOptions)
+	got, err := fetchConfigURL(context.Background(), client, srv.URL)
+	if err != nil {
+		t.Fatalf("fetchConfigURL() error = %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("fetchConfigURL() returned %d bytes, want the %d decompressed bytes", len(got), len(data))
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"aqua-speed-tools/internal/github"
+	"aqua-speed-tools/internal/utils"
+)
+
+// defaultConfigURL is the URL set with SetDefaultConfigURL, empty to use the tools repository
+var defaultConfigURL string
+
+// SetDefaultConfigURL sets where FetchDefaultConfig downloads the default config from,
+// instead of the tools repository. An empty URL restores the tools repository.
+func SetDefaultConfigURL(rawURL string) error {
+	if rawURL != "" {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("invalid config URL: %w", err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("invalid config URL %q: scheme must be http or https", rawURL)
+		}
+		if parsed.Host == "" {
+			return fmt.Errorf("invalid config URL %q: missing host", rawURL)
+		}
+	}
+	defaultConfigURL = rawURL
+	return nil
+}
+
+// GetDefaultConfigURL returns the URL set with SetDefaultConfigURL, empty if none
+func GetDefaultConfigURL() string {
+	return defaultConfigURL
+}
+
+// fetchConfigURL downloads the config at configURL and checks that it is valid,
+// so that an unusable config is never written as the local config file
+func fetchConfigURL(ctx context.Context, client *http.Client, configURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, configURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", utils.GetUserAgent("Aqua-Speed-Tools"))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config from %s: %w", configURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch config from %s: HTTP %d", configURL, resp.StatusCode)
+	}
+
+	body, err := utils.ResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, github.MaxConfigSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config from %s: %w", configURL, err)
+	}
+	if len(data) > github.MaxConfigSize {
+		return nil, fmt.Errorf("config from %s exceeds %d bytes", configURL, github.MaxConfigSize)
+	}
+
+	if _, err := parseConfig(data); err != nil {
+		return nil, fmt.Errorf("config from %s is invalid: %w", configURL, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// setDefaultConfigURL points the default config at rawURL for the test
+func setDefaultConfigURL(t *testing.T, rawURL string) {
+	t.Helper()
+	previous := GetDefaultConfigURL()
+	if err := SetDefaultConfigURL(rawURL); err != nil {
+		t.Fatalf("SetDefaultConfigURL() error = %v", err)
+	}
+	t.Cleanup(func() { SetDefaultConfigURL(previous) })
+}
+
+func TestSetDefaultConfigURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: ""},
+		{url: "https://config.example.com/base.json"},
+		{url: "http://127.0.0.1:8080/base.json"},
+		{url: "ftp://config.example.com/base.json", wantErr: true},
+		{url: "file:///etc/aqua-speed/base.json", wantErr: true},
+		{url: "config.example.com/base.json", wantErr: true},
+		{url: "https:///base.json", wantErr: true},
+		{url: "https://config.example.com/%zz", wantErr: true},
+	}
+
+	t.Cleanup(func() { SetDefaultConfigURL("") })
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			SetDefaultConfigURL("https://previous.example.com/base.json")
+			err := SetDefaultConfigURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetDefaultConfigURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			want := tt.url
+			if tt.wantErr {
+				want = "https://previous.example.com/base.json"
+			}
+			if got := GetDefaultConfigURL(); got != want {
+				t.Errorf("GetDefaultConfigURL() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromConfigURL(t *testing.T) {
+	previous := Get()
+	t.Cleanup(func() { Update(func(cfg *Config) { *cfg = previous }) })
+
+	valid, err := os.ReadFile(writeBaseConfig(t, t.TempDir(), map[string]any{"mirror_test_rounds": 7}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid, err := os.ReadFile(writeBaseConfig(t, t.TempDir(), map[string]any{"mirror_test_rounds": -1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		local        bool   // Whether a local config file already exists
+		remote       []byte // Config served at the config URL
+		wantErr      bool
+		wantRounds   int
+		wantRequests int32
+	}{
+		{name: "missing local file fetched from the URL", remote: valid, wantRounds: 7, wantRequests: 1},
+		{name: "invalid remote config rejected", remote: invalid, wantErr: true, wantRequests: 1},
+		{name: "local file used without the URL", local: true, remote: invalid, wantRounds: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Write(tt.remote)
+			}))
+			t.Cleanup(srv.Close)
+			setDefaultConfigURL(t, srv.URL+"/base.json")
+
+			dir := filepath.Join(t.TempDir(), "configs")
+			path := filepath.Join(dir, "base.json")
+			if tt.local {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				writeBaseConfig(t, dir, map[string]any{"mirror_test_rounds": 2})
+			}
+
+			err := LoadConfig(path)
+			if n := requests.Load(); n != tt.wantRequests {
+				t.Errorf("LoadConfig() sent %d requests to the config URL, want %d", n, tt.wantRequests)
+			}
+			if tt.wantErr {
+				var firstRun *FirstRunError
+				var configErr *ConfigError
+				if !errors.As(err, &firstRun) || !errors.As(err, &configErr) || configErr.Field != "MirrorTestRounds" {
+					t.Fatalf("LoadConfig() error = %v, want a FirstRunError caused by the invalid MirrorTestRounds", err)
+				}
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("invalid remote config written to %s", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if rounds := Get().MirrorTestRounds; rounds != tt.wantRounds {
+				t.Errorf("MirrorTestRounds = %d, want %d", rounds, tt.wantRounds)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("config file not written to %s: %v", path, err)
+			}
+		})
+	}
+}
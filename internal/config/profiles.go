@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultProfile names the base.json config, used when no profile is selected
+const DefaultProfile = "default"
+
+// profileNamePattern restricts profile names to safe file names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// activeProfile is the profile selected with SetProfile, empty for the default one
+var activeProfile string
+
+// Profile is a named config file
+type Profile struct {
+	Name   string
+	Path   string
+	Active bool
+	Err    error // Why the file does not load, nil if it is valid
+}
+
+// GetProfilesDir returns the directory holding the named config profiles
+func GetProfilesDir() string {
+	return filepath.Join(GetConfigDir(), "profiles")
+}
+
+// GetProfilePath returns the config file of the named profile
+func GetProfilePath(name string) string {
+	if name == "" || name == DefaultProfile {
+		return filepath.Join(GetConfigDir(), "base.json")
+	}
+	return filepath.Join(GetProfilesDir(), name+".json")
+}
+
+// SetProfile selects the profile GetConfigPath returns and LoadConfig reads.
+// Unlike the default profile, a named profile must already exist.
+func SetProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		activeProfile = ""
+		return nil
+	}
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	path := GetProfilePath(name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("profile %s not found at %s", name, path)
+	}
+	activeProfile = name
+	return nil
+}
+
+// GetProfile returns the name of the selected profile
+func GetProfile() string {
+	if activeProfile == "" {
+		return DefaultProfile
+	}
+	return activeProfile
+}
+
+// ListProfiles returns the default profile followed by the named profiles sorted
+// by name, each validated
+func ListProfiles() ([]Profile, error) {
+	names := []string{DefaultProfile}
+
+	entries, err := os.ReadDir(GetProfilesDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+	var named []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() || name == DefaultProfile || !profileNamePattern.MatchString(name) {
+			continue
+		}
+		named = append(named, name)
+	}
+	slices.Sort(named)
+	names = append(names, named...)
+
+	profiles := make([]Profile, 0, len(names))
+	for _, name := range names {
+		path := GetProfilePath(name)
+		profiles = append(profiles, Profile{
+			Name:   name,
+			Path:   path,
+			Active: name == GetProfile(),
+			Err:    ValidateFile(path),
+		})
+	}
+	return profiles, nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// mu guards ConfigReader against concurrent reloads. Once loaded, the configuration
+// is read with Get and changed with Update.
+var mu sync.RWMutex
+
+// Get returns a snapshot of the current configuration, safe to call during a reload
+func Get() Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return *ConfigReader
+}
+
+// Update applies fn to the current configuration, safe to call during a reload.
+// fn must not block, readers wait for it.
+func Update(fn func(cfg *Config)) {
+	mu.Lock()
+	defer mu.Unlock()
+	fn(ConfigReader)
+}
+
+// Reload re-reads the configuration file and applies the fields that are safe to change
+// at runtime: DoH endpoints, speed thresholds, retry policy and download restrictions.
+// URLs derived during startup, such as the selected mirror, are kept. If the new file is
+// invalid the current configuration stays in place. It returns the names of the fields
+// that changed and were applied, and of those that changed but only take effect after a
+// restart, which are left unchanged: the mirror set, probe rounds and failure cooldown,
+// as the mirror is selected once at startup, and the log level and download timeout, as
+// the logger and HTTP clients are created at startup.
+func Reload(configPath string) (applied, restart []string, err error) {
+	if configPath == "" {
+		configPath = GetConfigPath()
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	next, err := parseConfig(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	cfg := ConfigReader
+	apply := func(name string, current, updated any, set func()) {
+		if !reflect.DeepEqual(current, updated) {
+			applied = append(applied, name)
+			set()
+		}
+	}
+	requireRestart := func(name string, current, updated any) {
+		if !reflect.DeepEqual(current, updated) {
+			restart = append(restart, name)
+		}
+	}
+
+	apply("dns_over_https_set", cfg.DNSOverHTTPSSet, next.DNSOverHTTPSSet, func() {
+		cfg.DNSOverHTTPSSet = next.DNSOverHTTPSSet
+	})
+	apply("speed_thresholds", cfg.SpeedThresholds, next.SpeedThresholds, func() {
+		cfg.SpeedThresholds = next.SpeedThresholds
+	})
+	apply("retry", cfg.Retry, next.Retry, func() {
+		cfg.Retry = next.Retry
+	})
+	apply("download", cfg.Download, next.Download, func() {
+		cfg.Download = next.Download
+	})
+	requireRestart("github_raw_jsdelivr_set", cfg.GithubRawJsdelivrSet, next.GithubRawJsdelivrSet)
+	requireRestart("mirror_test_rounds", cfg.MirrorTestRounds, next.MirrorTestRounds)
+	requireRestart("mirror_failure_cooldown", cfg.MirrorFailureCooldown, next.MirrorFailureCooldown)
+	requireRestart("download_timeout", cfg.DownloadTimeout, next.DownloadTimeout)
+	requireRestart("log_level", cfg.LogLevel, next.LogLevel)
+
+	return applied, restart, nil
+}
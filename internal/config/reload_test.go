@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// writeBaseConfig writes configs/base.json with changes applied to dir and returns its path.
+// A nil change removes the key.
+func writeBaseConfig(t *testing.T, dir string, changes map[string]any) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "configs", "base.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(raw, key)
+			continue
+		}
+		raw[key] = value
+	}
+	if data, err = json.Marshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "base.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReload(t *testing.T) {
+	previous := Get()
+	t.Cleanup(func() { Update(func(cfg *Config) { *cfg = previous }) })
+
+	dir := t.TempDir()
+	path := writeBaseConfig(t, dir, map[string]any{"log_level": "info", "download_timeout": 30, "mirror_test_rounds": 3, "retry": map[string]any{"attempts": 3}})
+	if err := LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	Update(func(cfg *Config) { cfg.GithubRawBaseURL = "https://mirror.example/gh" })
+
+	writeBaseConfig(t, dir, map[string]any{"log_level": "debug", "download_timeout": 60, "mirror_test_rounds": 5, "retry": map[string]any{"attempts": 5}})
+	applied, restart, err := Reload(path)
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if !slices.Equal(applied, []string{"retry"}) {
+		t.Errorf("Reload() applied = %v, want [retry]", applied)
+	}
+	slices.Sort(restart)
+	if !slices.Equal(restart, []string{"download_timeout", "log_level", "mirror_test_rounds"}) {
+		t.Errorf("Reload() restart = %v, want [download_timeout log_level mirror_test_rounds]", restart)
+	}
+
+	cfg := Get()
+	if cfg.Retry.Attempts != 5 {
+		t.Errorf("Retry.Attempts = %d, want 5", cfg.Retry.Attempts)
+	}
+	if cfg.LogLevel != "info" || cfg.DownloadTimeout != 30 || cfg.MirrorTestRounds != 3 {
+		t.Errorf("LogLevel, DownloadTimeout, MirrorTestRounds = %s, %d, %d, want them unchanged until a restart",
+			cfg.LogLevel, cfg.DownloadTimeout, cfg.MirrorTestRounds)
+	}
+	if cfg.GithubRawBaseURL != "https://mirror.example/gh" {
+		t.Errorf("GithubRawBaseURL = %s, want the URL chosen at startup kept", cfg.GithubRawBaseURL)
+	}
+}
+
+func TestReloadConcurrentReaders(t *testing.T) {
+	previous := Get()
+	t.Cleanup(func() { Update(func(cfg *Config) { *cfg = previous }) })
+
+	dir := t.TempDir()
+	path := writeBaseConfig(t, dir, nil)
+	if err := LoadConfig(path); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	// Run with -race: readers going through Get must not race with Reload
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				_ = Get().Retry.Attempts
+			}
+		}()
+	}
+	for i := range 20 {
+		writeBaseConfig(t, dir, map[string]any{"retry": map[string]any{"attempts": i%3 + 1}})
+		if _, _, err := Reload(path); err != nil {
+			t.Errorf("Reload() error = %v", err)
+		}
+	}
+	wg.Wait()
+}
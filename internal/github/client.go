@@ -7,8 +7,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
+
+	"go.uber.org/zap"
 )
 
+// MaxConfigSize bounds the size of a downloaded config file, which is a few kilobytes
+const MaxConfigSize = 1 << 20
+
 // Client represents a GitHub API client
 type Client struct {
 	httpClient *http.Client
@@ -52,6 +58,7 @@ func (c *Client) GetDefaultConfig(ctx context.Context, owner, repo string) ([]by
 
 	// Set proper User-Agent header
 	req.Header.Set("User-Agent", c.userAgent)
+	utils.AuthorizeGitHubRequest(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -63,44 +70,72 @@ func (c *Client) GetDefaultConfig(ctx context.Context, owner, repo string) ([]by
 		return nil, fmt.Errorf("failed to fetch config: HTTP %d", resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	body, err := utils.ResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, MaxConfigSize+1))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if len(data) > MaxConfigSize {
+		return nil, fmt.Errorf("config exceeds %d bytes", MaxConfigSize)
+	}
+	// 镜像可能以 200 状态返回 HTML 错误页面
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("config is not valid JSON")
+	}
 
 	return data, nil
 }
 
+// Release is the part of a GitHub release the tools use
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Body        string    `json:"body"`         // Release notes in Markdown
+	HTMLURL     string    `json:"html_url"`     // Release page
+	PublishedAt time.Time `json:"published_at"` // Zero for unpublished drafts
+	Prerelease  bool      `json:"prerelease"`
+}
+
 // GetLatestRelease fetches the latest release information
-func (c *Client) GetLatestRelease(ctx context.Context, owner, repo string) (string, error) {
+func (c *Client) GetLatestRelease(ctx context.Context, owner, repo string) (*Release, error) {
 	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", c.baseURL, owner, repo)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set proper User-Agent header
 	req.Header.Set("User-Agent", c.userAgent)
+	utils.AuthorizeGitHubRequest(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch latest release: %w", err)
+		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
 	}
 	defer resp.Body.Close()
+	logRateLimit(resp)
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to fetch latest release: HTTP %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to fetch latest release: HTTP %d", resp.StatusCode)
 	}
 
-	var release struct {
-		TagName string `json:"tag_name"`
+	var release Release
+	body, err := utils.ResponseBody(resp)
+	if err != nil {
+		return nil, err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	defer body.Close()
+
+	if err := json.NewDecoder(body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return release.TagName, nil
+	return &release, nil
 }
 
 // GetRawContent fetches raw content from GitHub
@@ -114,6 +149,7 @@ func (c *Client) GetRawContent(ctx context.Context, owner, repo, branch, filepat
 
 	// Set proper User-Agent header
 	req.Header.Set("User-Agent", c.userAgent)
+	utils.AuthorizeGitHubRequest(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -125,10 +161,26 @@ func (c *Client) GetRawContent(ctx context.Context, owner, repo, branch, filepat
 		return nil, fmt.Errorf("failed to fetch content: HTTP %d", resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	body, err := utils.ResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	return data, nil
 }
+
+// logRateLimit logs the GitHub API rate limit left after resp, if the response reports it
+func logRateLimit(resp *http.Response) {
+	if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+		utils.Debug("GitHub API 剩余请求次数",
+			zap.String("url", resp.Request.URL.Redacted()),
+			zap.String("remaining", remaining),
+			zap.String("limit", resp.Header.Get("X-RateLimit-Limit")))
+	}
+}
@@ -0,0 +1,96 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// paddedJSON returns a valid JSON object of size bytes
+func paddedJSON(size int) []byte {
+	const prefix, suffix = `{"pad": "`, `"}`
+	return []byte(prefix + strings.Repeat("x", size-len(prefix)-len(suffix)) + suffix)
+}
+
+func TestGetDefaultConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []byte
+		wantErr string
+	}{
+		{name: "json", body: []byte(`{"log_level": "info"}`)},
+		{name: "html error page", body: []byte("<!DOCTYPE html><html><body>Rate limited</body></html>"), wantErr: "not valid JSON"},
+		{name: "oversized", body: paddedJSON(MaxConfigSize + 1), wantErr: "exceeds"},
+		{name: "at the size limit", body: paddedJSON(MaxConfigSize)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/owner/repo/main/configs/base.json" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write(tt.body)
+			}))
+			t.Cleanup(srv.Close)
+
+			client := NewClient(srv.Client(), "", srv.URL)
+			data, err := client.GetDefaultConfig(context.Background(), "owner", "repo")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetDefaultConfig() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetDefaultConfig() error = %v", err)
+			}
+			if !bytes.Equal(data, tt.body) {
+				t.Errorf("GetDefaultConfig() returned %d bytes, want %d", len(data), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestGetLatestRelease(t *testing.T) {
+	payload, err := os.ReadFile("testdata/latest_release.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/Alice39s/aqua-speed/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(payload)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewClient(srv.Client(), srv.URL, "")
+	release, err := client.GetLatestRelease(context.Background(), "Alice39s", "aqua-speed")
+	if err != nil {
+		t.Fatalf("GetLatestRelease() error = %v", err)
+	}
+
+	want := Release{
+		TagName:     "v1.4.2",
+		Body:        "## What's Changed\r\n* Fix upload test on LibreSpeed nodes\r\n\r\n**Full Changelog**: https://github.com/Alice39s/aqua-speed/compare/v1.4.1...v1.4.2",
+		HTMLURL:     "https://github.com/Alice39s/aqua-speed/releases/tag/v1.4.2",
+		PublishedAt: time.Date(2024, 10, 12, 8, 47, 55, 0, time.UTC),
+		Prerelease:  true,
+	}
+	if !release.PublishedAt.Equal(want.PublishedAt) {
+		t.Errorf("PublishedAt = %s, want %s", release.PublishedAt, want.PublishedAt)
+	}
+	release.PublishedAt = want.PublishedAt
+	if *release != want {
+		t.Errorf("GetLatestRelease() = %+v, want %+v", *release, want)
+	}
+}
@@ -0,0 +1,99 @@
+// Package history keeps the results of completed speed tests as JSON Lines,
+// so that runs can be compared over time
+package history
+
+import (
+	"aqua-speed-tools/internal/models"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxLine bounds the length of a single line in a history file
+const maxLine = 1 << 20
+
+// Append appends a test result to path as a single line of JSON, creating the file
+// and its directory if needed
+func Append(path string, result models.TestResult) error {
+	line, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	// 整行一次写入，避免并发运行时行内容交错
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return file.Close()
+}
+
+// Runs is the outcome of reading a history file
+type Runs struct {
+	Results []models.TestResult // Kept results in the order they were recorded
+	Skipped int                 // Lines that could not be parsed, such as one cut short by a crash
+}
+
+// Recent reads the history file at path and keeps the last limit results of every node,
+// or of nodeID alone when it is not empty. A limit of 0 or less keeps all results.
+// A missing file is reported as an error wrapping os.ErrNotExist.
+func Recent(path, nodeID string, limit int) (*Runs, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	runs := &Runs{}
+	perNode := make(map[string]int)
+	var all []models.TestResult
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var result models.TestResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil || result.NodeID == "" {
+			runs.Skipped++
+			continue
+		}
+		if nodeID != "" && result.NodeID != nodeID {
+			continue
+		}
+		all = append(all, result)
+		perNode[result.NodeID]++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	// 第二遍按节点跳过较早的记录，只保留每个节点最近的 limit 条
+	for _, result := range all {
+		if limit > 0 && perNode[result.NodeID] > limit {
+			perNode[result.NodeID]--
+			continue
+		}
+		runs.Results = append(runs.Results, result)
+	}
+	return runs, nil
+}
+
+// IsNotExist reports whether err means the history file has not been created yet
+func IsNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
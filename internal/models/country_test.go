@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestGeoInfoValidateCountryCode(t *testing.T) {
+	tests := []struct {
+		code         string
+		wantErr      bool
+		allowUnknown bool // Accepted when unknown codes are allowed
+	}{
+		{code: "CN"},
+		{code: "HK"},
+		{code: "us"},
+		{code: "GB"},
+		{code: "ZZ", wantErr: true, allowUnknown: true},
+		{code: "XX", wantErr: true, allowUnknown: true},
+		{code: "UK", wantErr: true, allowUnknown: true}, // Reserved, the assigned code is GB
+		{code: "", wantErr: true},
+		{code: "CHN", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			geo := GeoInfo{CountryCode: tt.code, Type: "IDC"}
+
+			SetAllowUnknownCountryCodes(false)
+			if err := geo.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			SetAllowUnknownCountryCodes(true)
+			t.Cleanup(func() { SetAllowUnknownCountryCodes(false) })
+			if err := geo.Validate(); (err != nil) != (tt.wantErr && !tt.allowUnknown) {
+				t.Errorf("Validate() with unknown codes allowed error = %v", err)
+			}
+			if known := IsKnownCountryCode(tt.code); known == tt.wantErr {
+				t.Errorf("IsKnownCountryCode(%q) = %v", tt.code, known)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DuplicateNodeID is a node ID used for more than one entry of a node list
+type DuplicateNodeID struct {
+	ID    string
+	Lines []int // Lines the ID appears on, in order; only the last entry survives decoding
+}
+
+// FindDuplicateNodeIDs scans a node list document for IDs used more than once.
+// Decoding it into a NodeList keeps only the last entry of each, so the others
+// would otherwise disappear without an error. A document that is not a JSON
+// object yields no duplicates, leaving the error to the decoder.
+func FindDuplicateNodeIDs(data []byte) []DuplicateNodeID {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	lines := make(map[string][]int)
+	var order []string
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		id, ok := token.(string)
+		if !ok {
+			return nil
+		}
+		line := bytes.Count(data[:dec.InputOffset()], []byte("\n")) + 1
+		if _, seen := lines[id]; !seen {
+			order = append(order, id)
+		}
+		lines[id] = append(lines[id], line)
+
+		// Skip the node itself
+		var node json.RawMessage
+		if err := dec.Decode(&node); err != nil {
+			return nil
+		}
+	}
+
+	var duplicates []DuplicateNodeID
+	for _, id := range order {
+		if len(lines[id]) > 1 {
+			duplicates = append(duplicates, DuplicateNodeID{ID: id, Lines: lines[id]})
+		}
+	}
+	return duplicates
+}
+
+// CheckDuplicateNodeIDs returns an error naming every node ID used more than once
+// in a node list document, together with the lines it appears on
+func CheckDuplicateNodeIDs(data []byte) error {
+	var errs []error
+	for _, duplicate := range FindDuplicateNodeIDs(data) {
+		lines := make([]string, len(duplicate.Lines))
+		for i, line := range duplicate.Lines {
+			lines[i] = fmt.Sprintf("%d", line)
+		}
+		errs = append(errs, fmt.Errorf("duplicate node id %s on lines %s", duplicate.ID, strings.Join(lines, ", ")))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const duplicateNodeList = `{
+  "a": {"id": "a"},
+  "b": {"id": "b"},
+  "a": {"id": "a"},
+  "c": {"id": "c"},
+  "b": {"id": "b"},
+  "a": {"id": "a"}
+}`
+
+func TestFindDuplicateNodeIDs(t *testing.T) {
+	duplicates := FindDuplicateNodeIDs([]byte(duplicateNodeList))
+
+	want := []DuplicateNodeID{
+		{ID: "a", Lines: []int{2, 4, 7}},
+		{ID: "b", Lines: []int{3, 6}},
+	}
+	if !slices.EqualFunc(duplicates, want, func(got, want DuplicateNodeID) bool {
+		return got.ID == want.ID && slices.Equal(got.Lines, want.Lines)
+	}) {
+		t.Errorf("FindDuplicateNodeIDs() = %+v, want %+v", duplicates, want)
+	}
+
+	for _, data := range []string{`{"a": {}, "b": {}}`, `[]`, `{"a": `, ``} {
+		if duplicates := FindDuplicateNodeIDs([]byte(data)); len(duplicates) != 0 {
+			t.Errorf("FindDuplicateNodeIDs(%q) = %+v, want none", data, duplicates)
+		}
+	}
+}
+
+func TestCheckDuplicateNodeIDs(t *testing.T) {
+	err := CheckDuplicateNodeIDs([]byte(duplicateNodeList))
+	if err == nil {
+		t.Fatal("CheckDuplicateNodeIDs() error = nil, want the duplicated IDs")
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("CheckDuplicateNodeIDs() error = %T, want one joined by errors.Join", err)
+	}
+	errs := joined.Unwrap()
+	want := []string{"duplicate node id a on lines 2, 4, 7", "duplicate node id b on lines 3, 6"}
+	if len(errs) != len(want) {
+		t.Fatalf("CheckDuplicateNodeIDs() joined %d errors, want %d: %v", len(errs), len(want), err)
+	}
+	for i, e := range errs {
+		if e.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, e, want[i])
+		}
+	}
+	if strings.Contains(err.Error(), "node id c") {
+		t.Errorf("CheckDuplicateNodeIDs() error = %v, names the unique ID c", err)
+	}
+
+	if err := CheckDuplicateNodeIDs([]byte(`{"a": {}, "b": {}}`)); err != nil {
+		t.Errorf("CheckDuplicateNodeIDs() without duplicates error = %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Node pattern prefixes matching fields other than the node ID
+const (
+	patternCountry = "country:"
+	patternIsp     = "isp:"
+)
+
+// NodeFilter selects nodes by patterns and exact field values. A pattern is a glob
+// matched against the node ID, or against the country code or ISP name when prefixed
+// with "country:" or "isp:". Matching is case-insensitive.
+type NodeFilter struct {
+	Include []string // Nodes must match one of these, empty includes every node
+	Exclude []string // Nodes matching any of these are left out, even when included
+
+	ISP         string   // Chinese or English ISP name nodes must have, empty for any
+	CountryCode string   // Country code nodes must have, empty for any
+	Type        NodeType // Type nodes must have, empty for any
+}
+
+// NewNodeFilter creates a filter from include and exclude patterns, checking their syntax
+func NewNodeFilter(include, exclude []string) (NodeFilter, error) {
+	filter := NodeFilter{}
+	for _, list := range []struct {
+		patterns []string
+		dst      *[]string
+	}{{include, &filter.Include}, {exclude, &filter.Exclude}} {
+		for _, pattern := range list.patterns {
+			pattern = strings.ToLower(strings.TrimSpace(pattern))
+			if pattern == "" {
+				continue
+			}
+			if _, err := path.Match(patternGlob(pattern), ""); err != nil {
+				return NodeFilter{}, fmt.Errorf("invalid node pattern %q: %w", pattern, err)
+			}
+			*list.dst = append(*list.dst, pattern)
+		}
+	}
+	return filter, nil
+}
+
+// IsEmpty reports whether the filter keeps every node
+func (f NodeFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && !f.hasFields()
+}
+
+// hasFields reports whether the filter restricts any node field
+func (f NodeFilter) hasFields() bool {
+	return f.ISP != "" || f.CountryCode != "" || f.Type != ""
+}
+
+// matchFields reports whether node has the ISP, country code and type the filter asks for
+func (f NodeFilter) matchFields(node Node) bool {
+	if f.ISP != "" && !strings.EqualFold(f.ISP, node.Isp.Zh) && !strings.EqualFold(f.ISP, node.Isp.En) {
+		return false
+	}
+	if f.CountryCode != "" && !strings.EqualFold(f.CountryCode, node.GeoInfo.CountryCode) {
+		return false
+	}
+	return f.Type == "" || strings.EqualFold(string(f.Type), string(node.Type))
+}
+
+// Keep reports whether node passes the filter. Exclusion wins over inclusion.
+func (f NodeFilter) Keep(node Node) bool {
+	if !f.matchFields(node) {
+		return false
+	}
+	for _, pattern := range f.Exclude {
+		if MatchNode(pattern, node) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, pattern := range f.Include {
+		if MatchNode(pattern, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchNode reports whether node matches a single pattern
+func MatchNode(pattern string, node Node) bool {
+	pattern = strings.ToLower(pattern)
+	var candidates []string
+	switch {
+	case strings.HasPrefix(pattern, patternCountry):
+		candidates = []string{node.GeoInfo.CountryCode}
+	case strings.HasPrefix(pattern, patternIsp):
+		candidates = []string{node.Isp.Zh, node.Isp.En}
+	default:
+		candidates = []string{node.Id}
+	}
+
+	glob := patternGlob(pattern)
+	for _, candidate := range candidates {
+		if matched, _ := path.Match(glob, strings.ToLower(candidate)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// patternGlob strips the field prefix from pattern
+func patternGlob(pattern string) string {
+	pattern = strings.TrimPrefix(pattern, patternCountry)
+	return strings.TrimPrefix(pattern, patternIsp)
+}
@@ -0,0 +1,100 @@
+package models
+
+import "testing"
+
+// filterNode returns a node with the given ID, country code and English ISP name
+func filterNode(id, countryCode, isp string) Node {
+	node := Node{Id: id, GeoInfo: GeoInfo{CountryCode: countryCode}}
+	node.Isp.En = isp
+	return node
+}
+
+func TestNodeFilterKeep(t *testing.T) {
+	tokyo := filterNode("jp-tokyo-iij", "JP", "IIJ")
+	shanghai := filterNode("cn-shanghai-ct", "CN", "China Telecom")
+	// A node whose ID looks like another country, to tell ID and country patterns apart
+	misleading := filterNode("cn-proxy", "JP", "IIJ")
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		keep    []Node
+		drop    []Node
+	}{
+		{
+			name: "no patterns",
+			keep: []Node{tokyo, shanghai, misleading},
+		},
+		{
+			name:    "id glob",
+			include: []string{"cn-*"},
+			keep:    []Node{shanghai, misleading},
+			drop:    []Node{tokyo},
+		},
+		{
+			name:    "country pattern matches the country code, not the id",
+			include: []string{"country:cn"},
+			keep:    []Node{shanghai},
+			drop:    []Node{tokyo, misleading},
+		},
+		{
+			name:    "bare country code matches only the id",
+			include: []string{"cn"},
+			drop:    []Node{tokyo, shanghai, misleading},
+		},
+		{
+			name:    "exclude overrides include",
+			include: []string{"cn-*"},
+			exclude: []string{"cn-proxy"},
+			keep:    []Node{shanghai},
+			drop:    []Node{tokyo, misleading},
+		},
+		{
+			name:    "excluded country overrides an included id",
+			include: []string{"cn-proxy", "jp-*"},
+			exclude: []string{"country:JP"},
+			drop:    []Node{tokyo, shanghai, misleading},
+		},
+		{
+			name:    "exclude only",
+			exclude: []string{"isp:iij"},
+			keep:    []Node{shanghai},
+			drop:    []Node{tokyo, misleading},
+		},
+		{
+			name:    "case-insensitive",
+			include: []string{"JP-*", "Country:CN"},
+			keep:    []Node{tokyo, shanghai},
+			drop:    []Node{misleading},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewNodeFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("NewNodeFilter() error = %v", err)
+			}
+			for _, node := range tt.keep {
+				if !filter.Keep(node) {
+					t.Errorf("Keep(%s) = false, want true", node.Id)
+				}
+			}
+			for _, node := range tt.drop {
+				if filter.Keep(node) {
+					t.Errorf("Keep(%s) = true, want false", node.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestNewNodeFilterInvalidPattern(t *testing.T) {
+	if _, err := NewNodeFilter([]string{"cn-["}, nil); err == nil {
+		t.Error("NewNodeFilter() error = nil, want an invalid pattern error")
+	}
+	if _, err := NewNodeFilter(nil, []string{"country:["}); err == nil {
+		t.Error("NewNodeFilter() error = nil, want an invalid pattern error")
+	}
+}
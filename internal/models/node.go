@@ -2,8 +2,14 @@ package models
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Size in MB, with custom parsing
@@ -48,6 +54,12 @@ func (s *Size) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("size.value field not found")
 }
 
+// MarshalJSON writes Size as a bare number, the canonical form of the two accepted
+// by UnmarshalJSON, so that exported node lists round-trip without spurious diffs
+func (s Size) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, s.Value, 10), nil
+}
+
 type GeoInfo struct {
 	CountryCode string  `json:"countryCode"`
 	Region      *string `json:"region"`
@@ -63,6 +75,9 @@ func (g *GeoInfo) Validate() error {
 	if len(g.CountryCode) != 2 {
 		return fmt.Errorf("countryCode must be 2 characters: %s", g.CountryCode)
 	}
+	if err := validateCountryCode(g.CountryCode); err != nil {
+		return err
+	}
 	if g.Type == "" {
 		return fmt.Errorf("type cannot be empty")
 	}
@@ -76,6 +91,12 @@ const (
 	LibreSpeed NodeType = "LibreSpeed"
 )
 
+// Supports reports whether nodes of this type can run the given test kind.
+// SingleFile nodes only serve a file to download, so they cannot test upload.
+func (t NodeType) Supports(kind TestKind) bool {
+	return t != SingleFile || kind != TestKindUpload
+}
+
 type Node struct {
 	Id   string `json:"id"`
 	Name struct {
@@ -91,6 +112,13 @@ type Node struct {
 	Threads uint16   `json:"threads"`
 	Type    NodeType `json:"type"`
 	GeoInfo GeoInfo  `json:"geoInfo"`
+	Timeout *int     `json:"timeout,omitempty"` // Seconds, overrides the global per-test timeout
+	// HealthPath is probed on the node's host to check it is reachable before testing,
+	// so servers can expose a cheap health endpoint; empty probes the URL root
+	HealthPath string `json:"healthPath,omitempty"`
+	// Headers and AuthToken are passed to aqua-speed for private servers requiring them
+	Headers   map[string]string `json:"headers,omitempty"`
+	AuthToken string            `json:"authToken,omitempty"`
 }
 
 // Validate checks if Node fields are valid
@@ -111,8 +139,10 @@ func (n *Node) Validate() error {
 		return fmt.Errorf("at least one ISP name (zh or en) must be provided")
 	}
 
-	if n.Url != "" && !strings.HasPrefix(n.Url, "http") {
-		return fmt.Errorf("invalid URL format: %s", n.Url)
+	if n.Url != "" {
+		if err := validateNodeURL(n.Url); err != nil {
+			return err
+		}
 	}
 
 	if n.Threads == 0 {
@@ -123,6 +153,22 @@ func (n *Node) Validate() error {
 		return fmt.Errorf("type cannot be empty")
 	}
 
+	if n.Timeout != nil && *n.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive: %d", *n.Timeout)
+	}
+
+	if n.HealthPath != "" {
+		if err := validateHealthPath(n.HealthPath); err != nil {
+			return err
+		}
+	}
+
+	for name := range n.Headers {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("header name cannot be empty")
+		}
+	}
+
 	if err := n.GeoInfo.Validate(); err != nil {
 		return fmt.Errorf("invalid geoInfo: %v", err)
 	}
@@ -130,6 +176,81 @@ func (n *Node) Validate() error {
 	return nil
 }
 
+// nodeURLSchemes are the URL schemes aqua-speed accepts for a node's server
+var nodeURLSchemes = []string{"http", "https", "ws", "wss"}
+
+// validateNodeURL checks that a node URL is absolute, uses a supported scheme
+// and has a host with a valid port, if any
+func validateNodeURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %v", rawURL, err)
+	}
+	if !slices.Contains(nodeURLSchemes, strings.ToLower(parsed.Scheme)) {
+		return fmt.Errorf("invalid URL %q: scheme must be one of %s", rawURL, strings.Join(nodeURLSchemes, ", "))
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("invalid URL %q: missing host", rawURL)
+	}
+	if strings.HasSuffix(parsed.Host, ":") {
+		return fmt.Errorf("invalid URL %q: empty port", rawURL)
+	}
+	if port := parsed.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid URL %q: port must be between 1 and 65535", rawURL)
+		}
+	}
+	return nil
+}
+
+// validateHealthPath checks that a health path is an absolute path without a scheme or host
+func validateHealthPath(healthPath string) error {
+	parsed, err := url.Parse(healthPath)
+	if err != nil {
+		return fmt.Errorf("invalid healthPath %q: %v", healthPath, err)
+	}
+	if parsed.Scheme != "" || parsed.Host != "" || !strings.HasPrefix(healthPath, "/") || strings.HasPrefix(healthPath, "//") {
+		return fmt.Errorf("healthPath must be a path starting with /: %s", healthPath)
+	}
+	return nil
+}
+
+// ProbeURL returns the URL used to check the node is reachable: HealthPath on the
+// node's host, or the root of the node's URL if it has no health path
+func (n *Node) ProbeURL() (string, error) {
+	parsed, err := url.Parse(n.Url)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %s: %w", n.Url, err)
+	}
+
+	// WebSocket servers are probed over plain HTTP(S) on the same host
+	scheme := parsed.Scheme
+	switch strings.ToLower(scheme) {
+	case "ws":
+		scheme = "http"
+	case "wss":
+		scheme = "https"
+	}
+
+	probe := &url.URL{Scheme: scheme, User: parsed.User, Host: parsed.Host, Path: "/"}
+	if n.HealthPath != "" {
+		health, err := url.Parse(n.HealthPath)
+		if err != nil {
+			return "", fmt.Errorf("invalid healthPath %s: %w", n.HealthPath, err)
+		}
+		probe.Path, probe.RawPath, probe.RawQuery = health.Path, health.RawPath, health.RawQuery
+	}
+	return probe.String(), nil
+}
+
+// TestTimeout returns the node's own test timeout, or fallback if it has none
+func (n *Node) TestTimeout(fallback time.Duration) time.Duration {
+	if n.Timeout != nil {
+		return time.Duration(*n.Timeout) * time.Second
+	}
+	return fallback
+}
+
 type NodeList map[string]Node
 
 // Validate checks if all nodes in the NodeList are valid
@@ -138,14 +259,28 @@ func (nl NodeList) Validate() error {
 		return fmt.Errorf("nodeList cannot be empty")
 	}
 
-	for id, node := range nl {
+	// Report every invalid node, in a stable order, rather than only the first found
+	var errs []error
+	for _, id := range slices.Sorted(maps.Keys(nl)) {
+		node := nl[id]
 		if id != node.Id {
-			return fmt.Errorf("node id mismatch: map key %s != node id %s", id, node.Id)
+			errs = append(errs, fmt.Errorf("node id mismatch: map key %s != node id %s", id, node.Id))
+			continue
 		}
 		if err := node.Validate(); err != nil {
-			return fmt.Errorf("invalid node %s: %v", id, err)
+			errs = append(errs, fmt.Errorf("invalid node %s: %v", id, err))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// Normalize trims surrounding whitespace from the nodes' URLs and health paths,
+// which would otherwise only fail once passed to aqua-speed
+func (nl NodeList) Normalize() {
+	for id, node := range nl {
+		node.Url = strings.TrimSpace(node.Url)
+		node.HealthPath = strings.TrimSpace(node.HealthPath)
+		nl[id] = node
+	}
 }
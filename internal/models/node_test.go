@@ -0,0 +1,124 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+func TestSizeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "number", input: `100`, want: 100},
+		{name: "object", input: `{"value":100}`, want: 100},
+		{name: "object with a quoted number", input: `{"value":"100"}`, want: 100},
+		{name: "zero", input: `0`, want: 0},
+		{name: "negative number", input: `-1`, wantErr: true},
+		{name: "negative object", input: `{"value":-1}`, wantErr: true},
+		{name: "object without value", input: `{"size":100}`, wantErr: true},
+		{name: "string", input: `"100"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var size Size
+			err := json.Unmarshal([]byte(tt.input), &size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if size.Value != tt.want {
+				t.Fatalf("UnmarshalJSON(%s) = %d, want %d", tt.input, size.Value, tt.want)
+			}
+
+			// Both forms are written back as a bare number, which decodes to the same size
+			data, err := json.Marshal(size)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+			if want := strconv.FormatInt(tt.want, 10); string(data) != want {
+				t.Errorf("MarshalJSON() = %s, want %s", data, want)
+			}
+			var decoded Size
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded != size {
+				t.Errorf("round trip = %+v, %v, want %+v", decoded, err, size)
+			}
+		})
+	}
+}
+
+func TestValidateNodeURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://speed.example.com/file.bin"},
+		{url: "http://speed.example.com:8080"},
+		{url: "wss://speed.example.com/ws"},
+		{url: "HTTPS://speed.example.com"},
+		{url: "speed.example.com/file.bin", wantErr: true},
+		{url: "//speed.example.com/file.bin", wantErr: true},
+		{url: "ftp://speed.example.com/file.bin", wantErr: true},
+		{url: "file:///tmp/file.bin", wantErr: true},
+		{url: "https://", wantErr: true},
+		{url: "https:///file.bin", wantErr: true},
+		{url: "https://:8080/file.bin", wantErr: true},
+		{url: "https://speed.example.com:/file.bin", wantErr: true},
+		{url: "https://speed.example.com:0", wantErr: true},
+		{url: "https://speed.example.com:65536", wantErr: true},
+		{url: "https://speed example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := validateNodeURL(tt.url); (err != nil) != tt.wantErr {
+				t.Errorf("validateNodeURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateHealthPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: "/"},
+		{path: "/health"},
+		{path: "/health?full=1"},
+		{path: "health", wantErr: true},
+		{path: "./health", wantErr: true},
+		{path: "//other.example.com/health", wantErr: true},
+		{path: "https://other.example.com/health", wantErr: true},
+		{path: "/%zz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if err := validateHealthPath(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("validateHealthPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNodeListNormalize(t *testing.T) {
+	nodes := NodeList{"a": {Id: "a", Url: " https://speed.example.com/file.bin\n", HealthPath: "/health "}}
+	nodes.Normalize()
+
+	if got := nodes["a"].Url; got != "https://speed.example.com/file.bin" {
+		t.Errorf("Url = %q, want surrounding whitespace trimmed", got)
+	}
+	if got := nodes["a"].HealthPath; got != "/health" {
+		t.Errorf("HealthPath = %q, want surrounding whitespace trimmed", got)
+	}
+	if err := validateNodeURL(nodes["a"].Url); err != nil {
+		t.Errorf("validateNodeURL() after Normalize error = %v", err)
+	}
+}
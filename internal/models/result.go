@@ -0,0 +1,36 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// TestResult holds the outcome of a single node speed test
+type TestResult struct {
+	NodeID   string    `json:"nodeId"`
+	NodeName string    `json:"nodeName"`
+	Download float64   `json:"download"`          // Mbps, 0 if not reported
+	Upload   float64   `json:"upload"`            // Mbps, 0 if not reported
+	Latency  float64   `json:"latency"`           // Milliseconds, 0 if not reported
+	Jitter   float64   `json:"jitter"`            // Milliseconds, 0 if not reported
+	TestedAt time.Time `json:"testedAt,omitzero"` // When the test finished
+}
+
+// TestKind selects which directions a speed test measures
+type TestKind string
+
+const (
+	TestKindBoth     TestKind = "both"
+	TestKindDownload TestKind = "download"
+	TestKindUpload   TestKind = "upload"
+)
+
+// ParseTestKind parses a test kind name
+func ParseTestKind(kind string) (TestKind, error) {
+	switch TestKind(kind) {
+	case TestKindBoth, TestKindDownload, TestKindUpload:
+		return TestKind(kind), nil
+	default:
+		return "", fmt.Errorf("invalid test kind %q: must be download, upload or both", kind)
+	}
+}
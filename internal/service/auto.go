@@ -0,0 +1,141 @@
+package service
+
+import (
+	"aqua-speed-tools/internal/models"
+	"aqua-speed-tools/internal/utils"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// nodeProbeTimeout bounds the reachability probe of a candidate node
+const nodeProbeTimeout = 5 * time.Second
+
+// RunAutoTest picks a node and tests it, for users who do not want to choose one.
+// Nodes in the country given by the hint are preferred, picked at random weighted
+// by their test file size; if none of them is reachable, or there is no hint, a
+// random reachable node is tested instead.
+func (s *TestService) RunAutoTest(ctx context.Context, country string) error {
+	node, err := s.pickAutoNode(strings.ToUpper(strings.TrimSpace(country)))
+	if err != nil {
+		s.logger.Error("failed to pick a node automatically", zap.String("country", country), zap.Error(err))
+		return err
+	}
+
+	utils.Green.Printf("Automatically selected node: %s (%s)\n", node.Name.Zh, node.Id)
+	return s.runSingleTest(ctx, node)
+}
+
+// pickAutoNode returns the first reachable node, trying nodes in the hinted country
+// by size-weighted random order before the rest in uniformly random order.
+func (s *TestService) pickAutoNode(country string) (models.Node, error) {
+	var preferred, others []models.Node
+	for _, node := range s.nodes {
+		if !node.Type.Supports(s.kind) {
+			utils.Explain(utils.ExplainNodes, "排除节点 %s：%s 类型不支持 %s 测试", node.Id, node.Type, s.kind)
+			continue
+		}
+		if country != "" && strings.EqualFold(node.GeoInfo.CountryCode, country) {
+			preferred = append(preferred, node)
+		} else {
+			others = append(others, node)
+		}
+	}
+	if len(preferred) == 0 && len(others) == 0 {
+		return models.Node{}, fmt.Errorf("no available nodes support %s tests", s.kind)
+	}
+	if country != "" && len(preferred) == 0 {
+		utils.Yellow.Printf("No nodes in %s, selecting a random reachable node\n", country)
+	}
+
+	client := utils.NewHTTPClient(nodeProbeTimeout, utils.ProbeTransportOptions)
+	for len(preferred) > 0 {
+		i := weightedBySize(preferred)
+		if s.probeNode(client, preferred[i]) {
+			utils.Explain(utils.ExplainNodes, "自动选择节点 %s：位于 %s 且可访问，按测试文件大小加权随机选出", preferred[i].Id, country)
+			return preferred[i], nil
+		}
+		utils.Explain(utils.ExplainNodes, "排除节点 %s：无法访问", preferred[i].Id)
+		preferred = slices.Delete(preferred, i, i+1)
+	}
+	if country != "" {
+		s.logger.Info("no reachable node in the hinted country, falling back to any node", zap.String("country", country))
+	}
+
+	rand.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+	for _, node := range others {
+		if s.probeNode(client, node) {
+			utils.Explain(utils.ExplainNodes, "自动选择节点 %s：随机选出的可访问节点", node.Id)
+			return node, nil
+		}
+		utils.Explain(utils.ExplainNodes, "排除节点 %s：无法访问", node.Id)
+	}
+	return models.Node{}, fmt.Errorf("no reachable node found")
+}
+
+// weightedBySize returns the index of a random node, chosen with probability
+// proportional to its test file size so that larger, more accurate tests are favoured
+func weightedBySize(nodes []models.Node) int {
+	var total int64
+	for _, node := range nodes {
+		total += node.Size.Value
+	}
+	if total <= 0 {
+		return rand.IntN(len(nodes))
+	}
+
+	pick := rand.Int64N(total)
+	for i, node := range nodes {
+		pick -= node.Size.Value
+		if pick < 0 {
+			return i
+		}
+	}
+	return len(nodes) - 1
+}
+
+// probeNode reports whether the node's probe URL answers a HEAD request
+func (s *TestService) probeNode(client *http.Client, node models.Node) bool {
+	if _, err := s.measureNode(client, node, nodeProbeTimeout); err != nil {
+		s.logger.Debug("node is unreachable", zap.String("node", node.Id), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// measureNode sends a HEAD request to the node's probe URL and returns how long it
+// took to get the response headers. Server errors count as unreachable.
+func (s *TestService) measureNode(client *http.Client, node models.Node, timeout time.Duration) (time.Duration, error) {
+	probeURL, err := node.ProbeURL()
+	if err != nil {
+		return 0, fmt.Errorf("invalid probe URL: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, probeURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create probe: %w", err)
+	}
+	req.Header.Set("User-Agent", utils.GetUserAgent("Aqua-Speed-Tools"))
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return 0, fmt.Errorf("server error: %s", resp.Status)
+	}
+	return latency, nil
+}
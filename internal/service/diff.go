@@ -0,0 +1,101 @@
+package service
+
+import (
+	"aqua-speed-tools/internal/models"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// FieldChange describes a single changed field of a node
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// NodeChange describes a node present in both lists with different fields
+type NodeChange struct {
+	ID      string
+	Changes []FieldChange
+}
+
+// NodeListDiff is the difference between two node lists, keyed by node ID
+type NodeListDiff struct {
+	Added   []models.Node
+	Removed []models.Node
+	Changed []NodeChange
+}
+
+// Empty reports whether the two node lists are identical
+func (d NodeListDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// LoadNodeListFile reads and validates a node list file in the presets/config.json format
+func LoadNodeListFile(path string) (models.NodeList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node list: %w", err)
+	}
+
+	if err := models.CheckDuplicateNodeIDs(data); err != nil {
+		return nil, fmt.Errorf("node validation failed: %w", err)
+	}
+
+	var nodes models.NodeList
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to parse node list: %w", err)
+	}
+
+	nodes.Normalize()
+	if err := nodes.Validate(); err != nil {
+		return nil, fmt.Errorf("node validation failed: %w", err)
+	}
+
+	return nodes, nil
+}
+
+// DiffNodeLists compares two node lists and reports added, removed and changed nodes sorted by ID
+func DiffNodeLists(oldNodes, newNodes models.NodeList) NodeListDiff {
+	var diff NodeListDiff
+
+	for _, id := range sortedNodeIDs(oldNodes) {
+		oldNode := oldNodes[id]
+		newNode, ok := newNodes[id]
+		if !ok {
+			diff.Removed = append(diff.Removed, oldNode)
+			continue
+		}
+
+		oldFields, newFields := nodeFields(oldNode), nodeFields(newNode)
+		var changes []FieldChange
+		for i, field := range nodeCSVHeader {
+			if oldFields[i] != newFields[i] {
+				changes = append(changes, FieldChange{Field: field, Old: oldFields[i], New: newFields[i]})
+			}
+		}
+		if len(changes) > 0 {
+			diff.Changed = append(diff.Changed, NodeChange{ID: id, Changes: changes})
+		}
+	}
+
+	for _, id := range sortedNodeIDs(newNodes) {
+		if _, ok := oldNodes[id]; !ok {
+			diff.Added = append(diff.Added, newNodes[id])
+		}
+	}
+
+	return diff
+}
+
+// sortedNodeIDs returns the IDs of a node list in ascending order
+func sortedNodeIDs(nodes models.NodeList) []string {
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"aqua-speed-tools/internal/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ProbeDirect sends a HEAD request to each URL in parallel and returns the slowest
+// response time, or an error if any of them fails to answer within timeout. Any HTTP
+// response counts as reachable and redirects are not followed, since only
+// connectivity to the hosts matters.
+func ProbeDirect(ctx context.Context, urls []string, timeout time.Duration) (time.Duration, error) {
+	client := utils.NewHTTPClient(timeout, utils.ProbeTransportOptions)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var slowest time.Duration
+	g, ctx := errgroup.WithContext(ctx)
+	for _, target := range urls {
+		g.Go(func() error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+			if err != nil {
+				return fmt.Errorf("failed to create probe for %s: %w", target, err)
+			}
+			req.Header.Set("User-Agent", utils.GetUserAgent("Aqua-Speed-Tools"))
+
+			start := time.Now()
+			resp, err := client.Do(req)
+			if err != nil {
+				return fmt.Errorf("%s is unreachable: %w", target, err)
+			}
+			resp.Body.Close()
+
+			mu.Lock()
+			slowest = max(slowest, time.Since(start))
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return 0, err
+	}
+	return slowest, nil
+}
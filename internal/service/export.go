@@ -0,0 +1,168 @@
+package service
+
+import (
+	"aqua-speed-tools/internal/models"
+	"aqua-speed-tools/internal/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Supported node export formats
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+	ExportFormatHTML = "html"
+)
+
+// nodeCSVHeader lists the flattened node columns used by the CSV export
+var nodeCSVHeader = []string{
+	"id", "name_zh", "name_en", "size", "isp_zh", "isp_en", "url", "threads", "type",
+	"country_code", "region", "city", "geo_type", "timeout", "health_path", "headers", "auth_token",
+}
+
+// ExportNodes writes the loaded node list to path in the given format.
+// bom prefixes CSV output with a UTF-8 byte order mark for Excel.
+func (s *SpeedTest) ExportNodes(path, format string, bom bool) error {
+	if len(s.nodes) == 0 {
+		return fmt.Errorf("node list is empty")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	if err := s.WriteNodes(f, format, bom); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// WriteNodes writes the loaded node list to w in the given format.
+// The JSON form matches presets/config.json so it can be loaded again, credentials
+// included; the CSV and HTML forms are for reading and mask them.
+func (s *SpeedTest) WriteNodes(w io.Writer, format string, bom bool) error {
+	if bom && format != ExportFormatCSV {
+		return fmt.Errorf("byte order mark is only supported for CSV export")
+	}
+
+	switch format {
+	case ExportFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(s.nodes); err != nil {
+			return fmt.Errorf("failed to encode nodes: %w", err)
+		}
+		return nil
+	case ExportFormatCSV:
+		if bom {
+			if _, err := io.WriteString(w, utils.UTF8BOM); err != nil {
+				return fmt.Errorf("failed to write byte order mark: %w", err)
+			}
+		}
+		return s.writeNodesCSV(w)
+	case ExportFormatHTML:
+		return s.writeNodesHTML(w)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// writeNodesCSV writes one row per node with nested fields flattened into columns
+func (s *SpeedTest) writeNodesCSV(w io.Writer) error {
+	ids := sortedNodeIDs(s.nodes)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(nodeCSVHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := writer.Write(nodeFields(s.nodes[id])); err != nil {
+			return fmt.Errorf("failed to write CSV row for node %s: %w", id, err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeNodesHTML writes the nodes as an HTML table, with the same columns as the CSV export
+func (s *SpeedTest) writeNodesHTML(w io.Writer) error {
+	table := utils.NewTable(nodeCSVHeader)
+	for _, id := range sortedNodeIDs(s.nodes) {
+		table.AddRow(nodeFields(s.nodes[id]))
+	}
+
+	if _, err := io.WriteString(w, table.RenderHTML()); err != nil {
+		return fmt.Errorf("failed to write HTML: %w", err)
+	}
+	return nil
+}
+
+// nodeFields flattens a node into values matching nodeCSVHeader, masking the auth token
+// and the values of sensitive headers
+func nodeFields(node models.Node) []string {
+	return []string{
+		node.Id,
+		node.Name.Zh,
+		node.Name.En,
+		strconv.FormatInt(node.Size.Value, 10),
+		node.Isp.Zh,
+		node.Isp.En,
+		node.Url,
+		strconv.Itoa(int(node.Threads)),
+		string(node.Type),
+		node.GeoInfo.CountryCode,
+		stringOrEmpty(node.GeoInfo.Region),
+		stringOrEmpty(node.GeoInfo.City),
+		node.GeoInfo.Type,
+		intOrEmpty(node.Timeout),
+		node.HealthPath,
+		redactedNodeHeaders(node.Headers),
+		redactedSecret(node.AuthToken),
+	}
+}
+
+// redactedNodeHeaders formats a node's headers as "Name: Value" pairs sorted by name
+// and separated by "; ", with the values of sensitive headers masked
+func redactedNodeHeaders(headers map[string]string) string {
+	lines := make([]string, 0, len(headers))
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		lines = append(lines, name+": "+headers[name])
+	}
+	return strings.Join(utils.RedactHeaderLines(lines), "; ")
+}
+
+// redactedSecret masks a secret, keeping whether one is set visible
+func redactedSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "***"
+}
+
+// stringOrEmpty dereferences an optional string
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// intOrEmpty formats an optional integer
+func intOrEmpty(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"aqua-speed-tools/internal/models"
+)
+
+// privateNodes returns a node list with a node requiring credentials
+func privateNodes() models.NodeList {
+	var node models.Node
+	node.Id = "private"
+	node.Name.Zh = "私有节点"
+	node.Size = models.Size{Value: 100}
+	node.Url = "https://speed.example.com/file.bin"
+	node.Headers = map[string]string{"X-Api-Key": "secret-key", "X-Region": "sh"}
+	node.AuthToken = "secret-token"
+	return models.NodeList{node.Id: node}
+}
+
+func TestWriteNodesCSVCredentials(t *testing.T) {
+	s := &SpeedTest{nodes: privateNodes()}
+	var buf bytes.Buffer
+	if err := s.WriteNodes(&buf, ExportFormatCSV, false); err != nil {
+		t.Fatalf("WriteNodes() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("CSV export contains a credential:\n%s", buf.String())
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("CSV has %d records, want a header and one node", len(records))
+	}
+	row := make(map[string]string)
+	for i, column := range records[0] {
+		row[column] = records[1][i]
+	}
+	if want := "X-Api-Key: ***; X-Region: sh"; row["headers"] != want {
+		t.Errorf("headers column = %q, want %q", row["headers"], want)
+	}
+	if row["auth_token"] != "***" {
+		t.Errorf("auth_token column = %q, want it masked", row["auth_token"])
+	}
+}
+
+func TestWriteNodesJSONKeepsCredentials(t *testing.T) {
+	s := &SpeedTest{nodes: privateNodes()}
+	var buf bytes.Buffer
+	if err := s.WriteNodes(&buf, ExportFormatJSON, false); err != nil {
+		t.Fatalf("WriteNodes() error = %v", err)
+	}
+
+	// The JSON export is loaded again with --nodes, so it keeps what the node needs
+	var nodes models.NodeList
+	if err := json.Unmarshal(buf.Bytes(), &nodes); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	node := nodes["private"]
+	if node.AuthToken != "secret-token" || node.Headers["X-Api-Key"] != "secret-key" {
+		t.Errorf("JSON export = %+v, want the credentials kept", node)
+	}
+}
@@ -3,12 +3,15 @@ package service
 import (
 	"aqua-speed-tools/internal/models"
 	"aqua-speed-tools/internal/utils"
+	"cmp"
 	"fmt"
-	"sort"
+	"slices"
+	"strings"
 )
 
-// ListNodes lists all available nodes
-func (s *SpeedTest) ListNodes() error {
+// ListNodes lists all available nodes, followed by a summary of their count by type
+// and by country unless summary is false
+func (s *SpeedTest) ListNodes(summary bool) error {
 	if len(s.nodes) == 0 {
 		return fmt.Errorf("node list is empty")
 	}
@@ -17,7 +20,7 @@ func (s *SpeedTest) ListNodes() error {
 	table := utils.NewTable(headers)
 
 	table.EnableAutoMerge()
-	table.SortBy([]string{"节点类型", "运营商"})
+	table.SortBy([]string{"节点类型", "运营商", "节点ID"})
 
 	for id, node := range s.nodes {
 		table.AddRow([]string{
@@ -33,9 +36,50 @@ func (s *SpeedTest) ListNodes() error {
 	}
 
 	table.Print()
+	if summary {
+		s.printNodeSummary()
+	}
 	return nil
 }
 
+// printNodeSummary prints the total number of nodes and their breakdown by type and by country
+func (s *SpeedTest) printNodeSummary() {
+	byType := make(map[string]int)
+	byCountry := make(map[string]int)
+	for _, node := range s.nodes {
+		byType[node.GeoInfo.Type]++
+		byCountry[node.GeoInfo.CountryCode]++
+	}
+
+	fmt.Printf("共 %d 个节点\n", len(s.nodes))
+	fmt.Printf("按类型: %s\n", formatNodeCounts(byType))
+	fmt.Printf("按国家/地区: %s\n", formatNodeCounts(byCountry))
+}
+
+// formatNodeCounts formats counts by key, most frequent first and then by key
+func formatNodeCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		name := key
+		if name == "" {
+			name = "未知"
+		}
+		parts[i] = fmt.Sprintf("%s %d", name, counts[key])
+	}
+	return strings.Join(parts, ", ")
+}
+
 // getAvailableIDs gets all available node IDs
 func getAvailableIDs(nodes []models.Node) []string {
 	ids := make([]string, 0, len(nodes))
@@ -45,6 +89,27 @@ func getAvailableIDs(nodes []models.Node) []string {
 	return ids
 }
 
+// filterValues lists the ISP, country and type values nodes can be filtered by
+func filterValues(nodes []models.Node) string {
+	var isps, countries, types []string
+	for _, node := range nodes {
+		isps = append(isps, node.Isp.En, node.Isp.Zh)
+		countries = append(countries, node.GeoInfo.CountryCode)
+		types = append(types, string(node.Type))
+	}
+
+	lines := make([]string, 0, 3)
+	for _, field := range []struct {
+		flag   string
+		values []string
+	}{{"--isp", isps}, {"--country", countries}, {"--type", types}} {
+		values := slices.DeleteFunc(field.values, func(v string) bool { return v == "" })
+		slices.Sort(values)
+		lines = append(lines, fmt.Sprintf("available %s values: %s", field.flag, strings.Join(slices.Compact(values), ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
 // GetNodeIDByInput gets node ID by either numeric or string input
 func (s *SpeedTest) GetNodeIDByInput(input string) (string, error) {
 	// Try to parse as a number
@@ -52,7 +117,7 @@ func (s *SpeedTest) GetNodeIDByInput(input string) (string, error) {
 	if _, err := fmt.Sscanf(input, "%d", &numID); err == nil {
 		// If it's a number, iterate through sorted nodes to find the corresponding one
 		index := 1
-		// Sort nodes by type and ISP to match table display
+		// Sort nodes by type, ISP and ID to match table display
 		sortedNodes := s.getSortedNodes()
 		for _, node := range sortedNodes {
 			if index == numID {
@@ -72,20 +137,7 @@ func (s *SpeedTest) GetNodeIDByInput(input string) (string, error) {
 	return "", fmt.Errorf("无效的节点ID: %s", input)
 }
 
-// getSortedNodes returns nodes sorted by type and ISP to match table display
+// getSortedNodes returns nodes sorted by type, ISP and ID to match table display
 func (s *SpeedTest) getSortedNodes() []models.Node {
-	nodes := make([]models.Node, 0, len(s.nodes))
-	for _, node := range s.nodes {
-		nodes = append(nodes, node)
-	}
-
-	// Sort by type and ISP to match table display
-	sort.Slice(nodes, func(i, j int) bool {
-		if nodes[i].GeoInfo.Type != nodes[j].GeoInfo.Type {
-			return nodes[i].GeoInfo.Type < nodes[j].GeoInfo.Type
-		}
-		return nodes[i].Isp.Zh < nodes[j].Isp.Zh
-	})
-
-	return nodes
+	return getSortedNodes(s.GetNodes())
 }
@@ -3,37 +3,95 @@ package service
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"aqua-speed-tools/internal/config"
 	"aqua-speed-tools/internal/utils"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 type MirrorTester struct {
-	client  *http.Client
-	logger  *zap.Logger
-	timeout time.Duration
+	client      *http.Client
+	logger      *zap.Logger
+	timeout     time.Duration
+	rounds      int                 // Number of probes per mirror
+	concurrency int                 // Number of mirrors probed at the same time
+	failures    *MirrorFailureCache // Mirrors to skip while cooling down, nil to probe all
+	// releaseSample is a GitHub release download URL reachable mirrors must also serve,
+	// once rewritten, to be used for release downloads; empty to skip the check
+	releaseSample string
 }
 
+// MirrorResult is the outcome of probing a mirror over several rounds.
+// Mirrors are ranked by Score, which is (median latency + jitter) in milliseconds
+// divided by the success rate, so lower is better and flaky mirrors are penalised.
 type MirrorResult struct {
-	URL       string
-	Latency   time.Duration
-	Reachable bool
+	URL         string
+	Latency     time.Duration // Median latency of the successful rounds
+	Jitter      time.Duration // Standard deviation of the successful round latencies
+	SuccessRate float64       // Fraction of rounds that succeeded, from 0 to 1
+	Score       float64       // Ranking score, lower is better; 0 if unreachable
+	Reachable   bool
+	Error       string // Last failure reason, empty if every attempt succeeded
+	// ReleaseCapable reports whether the mirror served the release sample; only set when
+	// a release sample is checked, otherwise ReleaseError is empty and it is false
+	ReleaseCapable bool
+	ReleaseError   string // Why the mirror cannot serve release downloads, empty if it can or was not checked
+}
+
+// MirrorSelection records which mirror was chosen and how every candidate performed
+type MirrorSelection struct {
+	Selected   string
+	Latency    time.Duration
+	Candidates []MirrorResult
+	// ReleaseChecked reports whether the candidates were checked against a release sample,
+	// in which case ReleaseMirror is the best ranked one serving it, empty if none does
+	ReleaseChecked bool
+	ReleaseMirror  string
 }
 
 func NewMirrorTester(logger *zap.Logger, timeout time.Duration) *MirrorTester {
 	return &MirrorTester{
-		client: &http.Client{
-			Timeout: timeout,
-		},
-		logger:  logger,
-		timeout: timeout,
+		client:      utils.NewHTTPClient(timeout, utils.ProbeTransportOptions),
+		logger:      logger,
+		timeout:     timeout,
+		rounds:      config.DefaultMirrorTestRounds,
+		concurrency: DefaultMaxConcurrentDownloads,
 	}
 }
 
+// DefaultMaxConcurrentDownloads is the default number of connections fetching in parallel
+const DefaultMaxConcurrentDownloads = 4
+
+// SetConcurrency sets how many mirrors are probed at the same time, at least one
+func (m *MirrorTester) SetConcurrency(concurrency int) {
+	m.concurrency = max(concurrency, 1)
+}
+
+// SetRounds sets how many times each mirror is probed, at least once
+func (m *MirrorTester) SetRounds(rounds int) {
+	m.rounds = max(rounds, 1)
+}
+
+// SetReleaseSample makes the tester also fetch the headers of sample, a GitHub release
+// download URL, through every reachable mirror the way updates rewrite release URLs.
+// A mirror failing it still serves raw content but is not used for release downloads.
+func (m *MirrorTester) SetReleaseSample(sample string) {
+	m.releaseSample = sample
+}
+
+// SetFailureCache makes the tester skip mirrors that recently failed and record new failures
+func (m *MirrorTester) SetFailureCache(cache *MirrorFailureCache) {
+	m.failures = cache
+}
+
 func (m *MirrorTester) testSingleMirror(ctx context.Context, mirrorURL string) MirrorResult {
 	result := MirrorResult{
 		URL:       mirrorURL,
@@ -48,6 +106,7 @@ func (m *MirrorTester) testSingleMirror(ctx context.Context, mirrorURL string) M
 	req, err := http.NewRequestWithContext(ctx, "HEAD", testURL, nil)
 	if err != nil {
 		m.logger.Debug("创建请求失败", zap.String("url", mirrorURL), zap.Error(err))
+		result.Error = err.Error()
 		return result
 	}
 
@@ -56,6 +115,7 @@ func (m *MirrorTester) testSingleMirror(ctx context.Context, mirrorURL string) M
 	resp, err := m.client.Do(req)
 	if err != nil {
 		m.logger.Debug("请求失败", zap.String("url", mirrorURL), zap.Error(err))
+		result.Error = err.Error()
 		return result
 	}
 	defer resp.Body.Close()
@@ -66,48 +126,221 @@ func (m *MirrorTester) testSingleMirror(ctx context.Context, mirrorURL string) M
 	return result
 }
 
-func (m *MirrorTester) FindFastestMirror(mirrors []string) string {
-	if len(mirrors) == 0 {
-		return ""
-	}
+// TestAll tests every mirror and returns their results ranked by score, best first.
+// Unreachable mirrors are placed last, in their original order.
+func (m *MirrorTester) TestAll(mirrors []string) []MirrorResult {
+	return m.TestAllContext(context.Background(), mirrors)
+}
 
-	var bestMirror string
-	var bestLatency time.Duration = time.Hour
+// TestAllContext is like TestAll but stops testing when ctx is cancelled.
+// Up to the tester's concurrency, mirrors are probed in parallel.
+func (m *MirrorTester) TestAllContext(ctx context.Context, mirrors []string) []MirrorResult {
+	results := make([]MirrorResult, len(mirrors))
 
-	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
 	defer cancel()
 
-	for _, mirror := range mirrors {
-		var totalLatency time.Duration
-		reachableCount := 0
-
-		for i := 0; i < 2; i++ {
-			result := m.testSingleMirror(ctx, mirror)
-			if result.Reachable {
-				totalLatency += result.Latency
-				reachableCount++
+	skip := m.coolingDown(mirrors)
+	var g errgroup.Group
+	g.SetLimit(m.concurrency)
+	for i, mirror := range mirrors {
+		if until, ok := skip[mirror]; ok {
+			results[i] = MirrorResult{
+				URL:     mirror,
+				Latency: time.Hour,
+				Error:   fmt.Sprintf("最近探测失败，冷却至 %s", until.Local().Format("15:04:05")),
 			}
+			m.logger.Debug("跳过冷却中的镜像", zap.String("mirror", mirror), zap.Time("until", until))
+			continue
+		}
+		g.Go(func() error {
+			results[i] = m.testMirror(ctx, mirror)
+			m.recordOutcome(ctx, results[i])
+			return nil
+		})
+	}
+	g.Wait()
+
+	if m.failures != nil {
+		if err := m.failures.Save(); err != nil {
+			m.logger.Warn("保存镜像失败缓存失败", zap.Error(err))
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Reachable != results[j].Reachable {
+			return results[i].Reachable
 		}
+		return results[i].Reachable && results[i].Score < results[j].Score
+	})
 
-		if reachableCount > 0 {
-			avgLatency := totalLatency / time.Duration(reachableCount)
-			m.logger.Debug("镜像测试结果",
-				zap.String("mirror", mirror),
-				zap.Duration("avgLatency", avgLatency),
-				zap.Int("reachableCount", reachableCount))
+	return results
+}
 
-			if avgLatency < bestLatency {
-				bestLatency = avgLatency
-				bestMirror = mirror
+// testMirror probes a mirror for every round and summarises the results
+func (m *MirrorTester) testMirror(ctx context.Context, mirror string) MirrorResult {
+	candidate := MirrorResult{URL: mirror, Latency: time.Hour}
+	latencies := make([]time.Duration, 0, m.rounds)
+
+	for i := 0; i < m.rounds; i++ {
+		result := m.testSingleMirror(ctx, mirror)
+		if result.Reachable {
+			latencies = append(latencies, result.Latency)
+		} else {
+			candidate.Error = result.Error
+		}
+	}
+
+	if len(latencies) > 0 {
+		candidate.Latency, candidate.Jitter = latencyStats(latencies)
+		candidate.SuccessRate = float64(len(latencies)) / float64(m.rounds)
+		candidate.Score = (durationMs(candidate.Latency) + durationMs(candidate.Jitter)) / candidate.SuccessRate
+		candidate.Reachable = true
+		m.logger.Debug("镜像测试结果",
+			zap.String("mirror", mirror),
+			zap.Duration("medianLatency", candidate.Latency),
+			zap.Duration("jitter", candidate.Jitter),
+			zap.Float64("successRate", candidate.SuccessRate),
+			zap.Float64("score", candidate.Score))
+
+		if m.releaseSample != "" {
+			if err := m.checkRelease(ctx, mirror); err != nil {
+				candidate.ReleaseError = err.Error()
+				m.logger.Debug("镜像无法提供 Release 文件", zap.String("mirror", mirror), zap.Error(err))
+			} else {
+				candidate.ReleaseCapable = true
 			}
 		}
 	}
 
-	if bestMirror != "" {
+	return candidate
+}
+
+// checkRelease rewrites the release sample to mirror and checks with a HEAD request
+// that the mirror serves it
+func (m *MirrorTester) checkRelease(ctx context.Context, mirror string) error {
+	rewritten, err := utils.ConvertReleaseURLToMirror(m.releaseSample, strings.TrimSuffix(mirror, "/"))
+	if err != nil {
+		return err
+	}
+	if rewritten == m.releaseSample {
+		return fmt.Errorf("release downloads are not rewritten to this mirror")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rewritten, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", utils.GetUserAgent("Aqua-Speed-MirrorTester"))
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", rewritten, resp.Status)
+	}
+	return nil
+}
+
+// coolingDown returns the mirrors to skip and until when. If every mirror is
+// cooling down none are skipped, so that selection always has a candidate.
+func (m *MirrorTester) coolingDown(mirrors []string) map[string]time.Time {
+	if m.failures == nil {
+		return nil
+	}
+	skip := make(map[string]time.Time)
+	for _, mirror := range mirrors {
+		if ok, until := m.failures.CoolingDown(mirror); ok {
+			skip[mirror] = until
+		}
+	}
+	if len(skip) == len(mirrors) {
+		return nil
+	}
+	return skip
+}
+
+// recordOutcome updates the failure cache with a probed mirror's result
+func (m *MirrorTester) recordOutcome(ctx context.Context, result MirrorResult) {
+	// 被取消或超过整体测试时间的探测不代表镜像不可用
+	if m.failures == nil || ctx.Err() != nil {
+		return
+	}
+	if result.Reachable {
+		m.failures.RecordSuccess(result.URL)
+	} else {
+		m.failures.RecordFailure(result.URL)
+	}
+}
+
+// latencyStats returns the median and the standard deviation of the latencies
+func latencyStats(latencies []time.Duration) (median, jitter time.Duration) {
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		median = sorted[n/2]
+	} else {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+
+	var mean, variance float64
+	for _, latency := range sorted {
+		mean += float64(latency)
+	}
+	mean /= float64(n)
+	for _, latency := range sorted {
+		variance += (float64(latency) - mean) * (float64(latency) - mean)
+	}
+	variance /= float64(n)
+
+	return median, time.Duration(math.Sqrt(variance))
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// SelectMirror tests all mirrors and returns the fastest one together with every candidate's result
+func (m *MirrorTester) SelectMirror(mirrors []string) *MirrorSelection {
+	return m.SelectMirrorContext(context.Background(), mirrors)
+}
+
+// SelectMirrorContext is like SelectMirror but stops testing when ctx is cancelled
+func (m *MirrorTester) SelectMirrorContext(ctx context.Context, mirrors []string) *MirrorSelection {
+	selection := &MirrorSelection{
+		Latency:        time.Hour,
+		Candidates:     m.TestAllContext(ctx, mirrors),
+		ReleaseChecked: m.releaseSample != "",
+	}
+	if selection.ReleaseChecked {
+		for _, candidate := range selection.Candidates {
+			if candidate.ReleaseCapable {
+				selection.ReleaseMirror = candidate.URL
+				break
+			}
+		}
+	}
+
+	if len(selection.Candidates) > 0 && selection.Candidates[0].Reachable {
+		selection.Selected = selection.Candidates[0].URL
+		selection.Latency = selection.Candidates[0].Latency
 		m.logger.Info("找到最快的镜像",
-			zap.String("mirror", bestMirror),
-			zap.Duration("latency", bestLatency))
+			zap.String("mirror", selection.Selected),
+			zap.Duration("latency", selection.Latency),
+			zap.Duration("jitter", selection.Candidates[0].Jitter),
+			zap.Float64("successRate", selection.Candidates[0].SuccessRate))
+		if selection.ReleaseChecked && selection.ReleaseMirror != selection.Selected {
+			m.logger.Warn("最快的镜像无法提供 Release 文件，仅用于 Raw 内容",
+				zap.String("mirror", selection.Selected),
+				zap.String("error", selection.Candidates[0].ReleaseError),
+				zap.String("releaseMirror", selection.ReleaseMirror))
+		}
 	}
 
-	return bestMirror
+	return selection
 }
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"aqua-speed-tools/internal/utils"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kinds of sample URLs a mirror can be checked with
+const (
+	MirrorCheckRelease = "release"
+	MirrorCheckRaw     = "raw"
+)
+
+// mirrorProbeBytes is how much of the rewritten URL is fetched to confirm it serves content
+const mirrorProbeBytes = 1024
+
+// MirrorCheck is the result of rewriting a sample URL to a mirror and fetching it
+type MirrorCheck struct {
+	Kind      string // MirrorCheckRelease or MirrorCheckRaw
+	Sample    string
+	Rewritten string
+	Status    int           // HTTP status of the probe, 0 if no response was received
+	Latency   time.Duration // Time until the probe response headers arrived
+	Err       error         // Why the mirror cannot serve the sample, nil if it can
+}
+
+// CheckMirror rewrites sample, a GitHub release download or raw content URL, to
+// mirror the way updates and node lists are fetched, then probes the rewritten URL.
+// An error is returned only when sample is not a URL that can be rewritten; whether
+// the mirror works is reported in the result.
+func CheckMirror(ctx context.Context, mirror, sample string, timeout time.Duration) (*MirrorCheck, error) {
+	mirror = strings.TrimRight(mirror, "/")
+	if _, err := url.ParseRequestURI(mirror); err != nil {
+		return nil, fmt.Errorf("invalid mirror URL %q: %w", mirror, err)
+	}
+	parsed, err := url.Parse(sample)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sample URL %q: %w", sample, err)
+	}
+
+	check := &MirrorCheck{Sample: sample}
+	switch parsed.Host {
+	case "github.com":
+		check.Kind = MirrorCheckRelease
+		if check.Rewritten, err = utils.ConvertReleaseURLToMirror(sample, mirror); err != nil {
+			return nil, err
+		}
+		if check.Rewritten == sample {
+			check.Err = fmt.Errorf("release downloads are not rewritten to this mirror: only jsDelivr mirrors serve release files, " +
+				"and the sample must look like https://github.com/<owner>/<repo>/releases/download/<tag>/<file>")
+			return check, nil
+		}
+	case "raw.githubusercontent.com":
+		check.Kind = MirrorCheckRaw
+		parts := strings.SplitN(strings.Trim(parsed.Path, "/"), "/", 4)
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("invalid raw URL %q: expected https://raw.githubusercontent.com/<owner>/<repo>/<branch>/<path>", sample)
+		}
+		urls := utils.NewGitHubURLs(mirror, "", nil)
+		check.Rewritten = urls.BuildRawURL(parts[0], parts[1], parts[2], parts[3])
+	default:
+		return nil, fmt.Errorf("unsupported sample URL %q: must be a github.com release download or a raw.githubusercontent.com URL", sample)
+	}
+
+	check.Status, check.Latency, check.Err = probeFetch(ctx, check.Rewritten, timeout)
+	return check, nil
+}
+
+// probeFetch requests the first bytes of target, following redirects, and reports
+// whether it answered with content
+func probeFetch(ctx context.Context, target string, timeout time.Duration) (int, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", utils.GetUserAgent("Aqua-Speed-MirrorTester"))
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", mirrorProbeBytes-1))
+
+	start := time.Now()
+	resp, err := utils.NewHTTPClient(timeout, utils.ProbeTransportOptions).Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	latency := time.Since(start)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return resp.StatusCode, latency, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if _, err := io.CopyN(io.Discard, resp.Body, mirrorProbeBytes); err != nil && err != io.EOF {
+		return resp.StatusCode, latency, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, latency, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"aqua-speed-tools/internal/utils"
+)
+
+// MirrorFailureCache remembers mirrors that failed every probe, so that selection
+// can skip them until their cooldown expires instead of spending the timeout on
+// them again. Expired entries are dropped, so recovered mirrors get retried.
+type MirrorFailureCache struct {
+	mu       sync.Mutex
+	path     string
+	cooldown time.Duration
+	failures map[string]time.Time // Mirror URL -> time of its last failed selection
+}
+
+// NewMirrorFailureCache creates an in-memory failure cache with the given cooldown
+func NewMirrorFailureCache(cooldown time.Duration) *MirrorFailureCache {
+	return &MirrorFailureCache{cooldown: cooldown, failures: make(map[string]time.Time)}
+}
+
+// LoadMirrorFailureCache loads a failure cache from path, which is also where it is saved.
+// A missing file yields an empty cache; a malformed one is an error.
+func LoadMirrorFailureCache(path string, cooldown time.Duration) (*MirrorFailureCache, error) {
+	cache := NewMirrorFailureCache(cooldown)
+	cache.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mirror failure cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &cache.failures); err != nil {
+		return nil, fmt.Errorf("failed to parse mirror failure cache %s: %w", path, err)
+	}
+	if cache.failures == nil {
+		cache.failures = make(map[string]time.Time)
+	}
+
+	now := time.Now()
+	for mirror, failedAt := range cache.failures {
+		if !now.Before(failedAt.Add(cooldown)) {
+			delete(cache.failures, mirror)
+		}
+	}
+	return cache, nil
+}
+
+// CoolingDown reports whether mirror failed within the cooldown and until when it is skipped
+func (c *MirrorFailureCache) CoolingDown(mirror string) (bool, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	failedAt, ok := c.failures[mirror]
+	if !ok {
+		return false, time.Time{}
+	}
+	until := failedAt.Add(c.cooldown)
+	if !time.Now().Before(until) {
+		delete(c.failures, mirror)
+		return false, time.Time{}
+	}
+	return true, until
+}
+
+// RecordFailure starts the cooldown of mirror
+func (c *MirrorFailureCache) RecordFailure(mirror string) {
+	c.mu.Lock()
+	c.failures[mirror] = time.Now().UTC()
+	c.mu.Unlock()
+}
+
+// RecordSuccess clears any cooldown of mirror
+func (c *MirrorFailureCache) RecordSuccess(mirror string) {
+	c.mu.Lock()
+	delete(c.failures, mirror)
+	c.mu.Unlock()
+}
+
+// Save writes the cache to its file, if any
+func (c *MirrorFailureCache) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c.failures, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode mirror failure cache: %w", err)
+	}
+
+	if err := utils.WriteFileAtomic(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write mirror failure cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestMirrorFailureCacheSkipsFailedMirror(t *testing.T) {
+	var probes int
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(up.Close)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	downURL := down.URL
+	down.Close()
+	// Counts probes of the failed mirror once it has recovered
+	recovered := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { probes++ })
+
+	path := filepath.Join(t.TempDir(), "mirror_failures.json")
+	cache, err := LoadMirrorFailureCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadMirrorFailureCache() error = %v", err)
+	}
+	tester := NewMirrorTester(zap.NewNop(), 5*time.Second)
+	tester.SetRounds(1)
+	tester.SetFailureCache(cache)
+
+	tester.TestAll([]string{up.URL, downURL})
+	if ok, _ := cache.CoolingDown(downURL); !ok {
+		t.Fatal("CoolingDown() = false for a mirror that failed, want true")
+	}
+
+	// A new run loading the saved cache skips the failed mirror within the cooldown
+	cache, err = LoadMirrorFailureCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadMirrorFailureCache() error = %v", err)
+	}
+	tester.SetFailureCache(cache)
+	results := tester.TestAll([]string{up.URL, downURL})
+	if results[1].URL != downURL || results[1].Reachable || results[1].Error == "" {
+		t.Errorf("TestAll() = %+v, want %s skipped", results, downURL)
+	}
+
+	// Once the cooldown expired the mirror is probed again
+	cache, err = LoadMirrorFailureCache(path, 0)
+	if err != nil {
+		t.Fatalf("LoadMirrorFailureCache() error = %v", err)
+	}
+	if ok, _ := cache.CoolingDown(downURL); ok {
+		t.Error("CoolingDown() = true after the cooldown, want false")
+	}
+	srv := httptest.NewServer(recovered)
+	t.Cleanup(srv.Close)
+	cache.RecordFailure(srv.URL)
+	tester.SetFailureCache(cache)
+	tester.TestAll([]string{up.URL, srv.URL})
+	if probes != 1 {
+		t.Errorf("recovered mirror probed %d times, want 1", probes)
+	}
+}
+
+func TestMirrorFailureCacheIgnoresTimeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(fast.Close)
+
+	cache := NewMirrorFailureCache(time.Hour)
+	tester := NewMirrorTester(zap.NewNop(), 200*time.Millisecond)
+	tester.SetRounds(1)
+	tester.SetFailureCache(cache)
+
+	tester.TestAll([]string{fast.URL, slow.URL})
+	if ok, _ := cache.CoolingDown(slow.URL); ok {
+		t.Error("CoolingDown() = true for a mirror cut off by the overall timeout, want false")
+	}
+}
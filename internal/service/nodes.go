@@ -5,13 +5,20 @@ import (
 	"aqua-speed-tools/internal/models"
 	"aqua-speed-tools/internal/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"time"
+
+	"go.uber.org/zap"
 )
 
+// ErrNoNodes is returned when the node source was fetched successfully but lists no nodes
+var ErrNoNodes = errors.New("node source returned no nodes")
+
 // initNodes initializes the speed test node list
 func (s *SpeedTest) initNodes() error {
 	owner, repo := splitRepo(config.DefaultGithubToolsRepo)
@@ -37,7 +44,7 @@ func (s *SpeedTest) initNodes() error {
 
 	nodeData, err := s.fetchNodeData(url)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to fetch node list: %w", err)
 	}
 
 	if err := s.parseAndValidateNodes(nodeData); err != nil {
@@ -47,9 +54,39 @@ func (s *SpeedTest) initNodes() error {
 	// Log success
 	utils.Green.Printf("Successfully loaded %d nodes\n", len(s.nodes))
 
+	// 保存节点列表，节点源不可用时可以回退使用
+	if err := saveNodeCache(nodeData); err != nil {
+		s.logger.Warn("Failed to save node list cache", zap.Error(err))
+	}
+
+	return nil
+}
+
+// LoadCachedNodes loads the node list saved by the last successful fetch
+func (s *SpeedTest) LoadCachedNodes() error {
+	path := config.GetNodeCachePath()
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("no cached node list: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read cached node list: %w", err)
+	}
+
+	if err := s.parseAndValidateNodes(data); err != nil {
+		return fmt.Errorf("invalid cached node list %s: %w", path, err)
+	}
+
+	utils.Green.Printf("Loaded %d cached nodes (saved %s)\n", len(s.nodes), info.ModTime().Format("2006-01-02 15:04"))
 	return nil
 }
 
+// saveNodeCache writes the fetched node list data to the node cache file
+func saveNodeCache(data []byte) error {
+	return utils.WriteFileAtomic(config.GetNodeCachePath(), data, 0644)
+}
+
 // splitRepo splits a repository string into owner and repo parts
 func splitRepo(fullRepo string) (owner, repo string) {
 	parts := strings.Split(fullRepo, "/")
@@ -60,9 +97,7 @@ func splitRepo(fullRepo string) (owner, repo string) {
 }
 
 func (s *SpeedTest) fetchNodeData(url string) ([]byte, error) {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	client := utils.NewHTTPClient(30*time.Second, utils.APITransportOptions)
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
@@ -82,8 +117,14 @@ func (s *SpeedTest) fetchNodeData(url string) ([]byte, error) {
 		return nil, fmt.Errorf("unexpected HTTP status code %d from %s", resp.StatusCode, url)
 	}
 
+	body, err := utils.ResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
 	const maxSize = 10 << 20 // 10 MB
-	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
+	data, err := io.ReadAll(io.LimitReader(body, maxSize))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response data: %w", err)
 	}
@@ -96,6 +137,10 @@ func (s *SpeedTest) fetchNodeData(url string) ([]byte, error) {
 }
 
 func (s *SpeedTest) parseAndValidateNodes(data []byte) error {
+	if err := models.CheckDuplicateNodeIDs(data); err != nil {
+		return fmt.Errorf("node validation failed: %w", err)
+	}
+
 	var tmpNodes models.NodeList
 	if err := json.Unmarshal(data, &tmpNodes); err != nil {
 		truncatedData := string(data)
@@ -104,10 +149,21 @@ func (s *SpeedTest) parseAndValidateNodes(data []byte) error {
 		}
 		return fmt.Errorf("failed to parse node data: %w\nReceived data: %s", err, truncatedData)
 	}
+	if len(tmpNodes) == 0 {
+		return ErrNoNodes
+	}
 
+	tmpNodes.Normalize()
 	if err := tmpNodes.Validate(); err != nil {
 		return fmt.Errorf("node validation failed: %w", err)
 	}
+	for id, node := range tmpNodes {
+		if !models.IsKnownCountryCode(node.GeoInfo.CountryCode) {
+			s.logger.Warn("Unknown country code in node list",
+				zap.String("nodeId", id),
+				zap.String("countryCode", node.GeoInfo.CountryCode))
+		}
+	}
 
 	return s.processNodes(tmpNodes)
 }
@@ -124,7 +180,7 @@ func (s *SpeedTest) processNodes(tmpNodes models.NodeList) error {
 	}
 
 	if len(s.nodes) == 0 {
-		return fmt.Errorf("no valid nodes found in response")
+		return ErrNoNodes
 	}
 
 	return nil
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aqua-speed-tools/internal/config"
+
+	"go.uber.org/zap"
+)
+
+func TestFetchNodeDataGzip(t *testing.T) {
+	nodes := []byte(`{"hk-1": {"name": {"zh": "香港", "en": "Hong Kong"}}}`)
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(nodes)
+	gz.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Sent whether or not the client asked for it, as some mirrors do
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+
+	s := &SpeedTest{}
+	data, err := s.fetchNodeData(srv.URL)
+	if err != nil {
+		t.Fatalf("fetchNodeData() error = %v", err)
+	}
+	if !bytes.Equal(data, nodes) {
+		t.Errorf("fetchNodeData() = %q, want %q", data, nodes)
+	}
+}
+
+func TestInitNodesEmptySource(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantNoNodes bool // The fetch succeeded but listed no nodes
+	}{
+		{name: "empty object", status: http.StatusOK, body: `{}`, wantNoNodes: true},
+		{name: "null", status: http.StatusOK, body: `null`, wantNoNodes: true},
+		{name: "server error", status: http.StatusInternalServerError, body: `{}`},
+		{name: "invalid JSON", status: http.StatusOK, body: `{"nodes": [`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(srv.Close)
+
+			s := &SpeedTest{
+				config: config.Config{GithubRawJsdelivrSet: []string{srv.URL}},
+				logger: zap.NewNop(),
+			}
+			err := s.initNodes()
+			if err == nil {
+				t.Fatal("initNodes() = nil, want error")
+			}
+			if errors.Is(err, ErrNoNodes) != tt.wantNoNodes {
+				t.Errorf("initNodes() error = %v, want ErrNoNodes %v", err, tt.wantNoNodes)
+			}
+			if len(s.GetNodes()) != 0 {
+				t.Errorf("GetNodes() = %d nodes, want none", len(s.GetNodes()))
+			}
+		})
+	}
+}
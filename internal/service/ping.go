@@ -0,0 +1,90 @@
+package service
+
+import (
+	"aqua-speed-tools/internal/models"
+	"aqua-speed-tools/internal/utils"
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultPingConcurrency is the default number of nodes probed in parallel by ping
+	DefaultPingConcurrency = 8
+
+	// DefaultPingTimeout is the default time a node has to answer the ping probe
+	DefaultPingTimeout = nodeProbeTimeout
+)
+
+// PingResult is the latency of a single node, or why it could not be reached
+type PingResult struct {
+	Node    models.Node
+	Latency time.Duration
+	Err     error
+}
+
+// PingNodes probes every node with a HEAD request, up to concurrency at a time, and
+// returns the results sorted by latency with unreachable nodes last. The test binary
+// is not involved, which makes this a quick alternative to testing all nodes.
+func (s *TestService) PingNodes(concurrency int, timeout time.Duration) []PingResult {
+	client := utils.NewHTTPClient(timeout, utils.ProbeTransportOptions)
+	results := make([]PingResult, len(s.nodes))
+
+	var g errgroup.Group
+	g.SetLimit(max(concurrency, 1))
+	for i, node := range s.nodes {
+		g.Go(func() error {
+			latency, err := s.measureNode(client, node, timeout)
+			if err != nil {
+				s.logger.Debug("node ping failed", zap.String("node", node.Id), zap.Error(err))
+			}
+			results[i] = PingResult{Node: node, Latency: latency, Err: err}
+			return nil
+		})
+	}
+	g.Wait()
+
+	slices.SortStableFunc(results, func(a, b PingResult) int {
+		switch {
+		case a.Err != nil && b.Err != nil:
+			return 0
+		case a.Err != nil:
+			return 1
+		case b.Err != nil:
+			return -1
+		}
+		return cmp.Compare(a.Latency, b.Latency)
+	})
+	return results
+}
+
+// RunPing pings every node and prints their latencies, fastest first
+func (s *TestService) RunPing(concurrency int, timeout time.Duration) error {
+	if len(s.nodes) == 0 {
+		return fmt.Errorf("node list is empty")
+	}
+
+	results := s.PingNodes(concurrency, timeout)
+
+	table := utils.NewTable([]string{"节点ID", "名称", "运营商", "延迟"})
+	reachable := 0
+	for _, result := range results {
+		latency := utils.Red.Sprint("超时或不可达")
+		if result.Err == nil {
+			latency = fmt.Sprintf("%.1f ms", float64(result.Latency.Microseconds())/1000)
+			reachable++
+		}
+		table.AddRow([]string{result.Node.Id, result.Node.Name.Zh, result.Node.Isp.Zh, latency})
+	}
+	if len(results) > 25 {
+		table.SetPageSize(25)
+	}
+	table.Print()
+
+	fmt.Printf("可访问 %d/%d 个节点\n", reachable, len(results))
+	return nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"aqua-speed-tools/internal/models"
+	"aqua-speed-tools/internal/utils"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Report metrics, selecting which value of the results is aggregated
+const (
+	ReportMetricDownload = "download"
+	ReportMetricUpload   = "upload"
+	ReportMetricLatency  = "latency"
+)
+
+// maxHistoryLine bounds the length of a single line in a history file
+const maxHistoryLine = 1 << 20
+
+// NodeStats summarizes one metric of a node over the results in a history file.
+// Results that did not report the metric are not counted.
+type NodeStats struct {
+	NodeID   string
+	NodeName string
+	Count    int
+	Min      float64
+	Max      float64
+	Avg      float64
+	P50      float64
+	P95      float64
+	First    time.Time // When the earliest counted test ran, zero if unknown
+	Last     time.Time // When the latest counted test ran, zero if unknown
+}
+
+// HistoryReport is the aggregation of a history file
+type HistoryReport struct {
+	Metric  string
+	Nodes   []NodeStats // Sorted by node ID
+	Results int         // Results read from the file
+	Skipped int         // Lines that could not be parsed, such as one cut short by a crash
+}
+
+// nodeSamples collects the values of a metric for one node while a file is read
+type nodeSamples struct {
+	name        string
+	values      []float64
+	first, last time.Time
+}
+
+// LoadHistoryReport reads a history file line by line and aggregates metric per node
+func LoadHistoryReport(path, metric string) (*HistoryReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	return AggregateHistory(file, metric)
+}
+
+// AggregateHistory aggregates metric per node from JSONL test results read from r.
+// Only the values are kept in memory, so large files can be reported on.
+func AggregateHistory(r io.Reader, metric string) (*HistoryReport, error) {
+	value, err := metricValue(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &HistoryReport{Metric: metric}
+	samples := make(map[string]*nodeSamples)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxHistoryLine)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var result models.TestResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil || result.NodeID == "" {
+			report.Skipped++
+			continue
+		}
+		report.Results++
+
+		v := value(result)
+		if v <= 0 {
+			continue
+		}
+		node, ok := samples[result.NodeID]
+		if !ok {
+			node = &nodeSamples{}
+			samples[result.NodeID] = node
+		}
+		node.name = result.NodeName
+		node.values = append(node.values, v)
+		if !result.TestedAt.IsZero() {
+			if node.first.IsZero() || result.TestedAt.Before(node.first) {
+				node.first = result.TestedAt
+			}
+			if result.TestedAt.After(node.last) {
+				node.last = result.TestedAt
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(samples)) {
+		report.Nodes = append(report.Nodes, summarize(id, samples[id]))
+	}
+	return report, nil
+}
+
+// metricValue returns the function reading metric from a result
+func metricValue(metric string) (func(models.TestResult) float64, error) {
+	switch metric {
+	case ReportMetricDownload:
+		return func(r models.TestResult) float64 { return r.Download }, nil
+	case ReportMetricUpload:
+		return func(r models.TestResult) float64 { return r.Upload }, nil
+	case ReportMetricLatency:
+		return func(r models.TestResult) float64 { return r.Latency }, nil
+	default:
+		return nil, fmt.Errorf("unsupported metric: %s (expected download, upload or latency)", metric)
+	}
+}
+
+// summarize computes the statistics of a node's samples
+func summarize(id string, node *nodeSamples) NodeStats {
+	values := node.values
+	slices.Sort(values)
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return NodeStats{
+		NodeID:   id,
+		NodeName: node.name,
+		Count:    len(values),
+		Min:      values[0],
+		Max:      values[len(values)-1],
+		Avg:      sum / float64(len(values)),
+		P50:      percentile(values, 50),
+		P95:      percentile(values, 95),
+		First:    node.first,
+		Last:     node.last,
+	}
+}
+
+// percentile returns the p-th percentile of sorted values by the nearest-rank method
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// PrintHistoryReport renders a history report as a table
+func PrintHistoryReport(report *HistoryReport) {
+	format := formatSpeed
+	if report.Metric == ReportMetricLatency {
+		format = formatLatency
+	}
+
+	table := utils.NewTable([]string{"名称", "节点ID", "次数", "最小", "平均", "P50", "P95", "最大", "首次", "最近"})
+	for _, node := range report.Nodes {
+		table.AddRow([]string{
+			node.NodeName,
+			node.NodeID,
+			fmt.Sprintf("%d", node.Count),
+			format(node.Min),
+			format(node.Avg),
+			format(node.P50),
+			format(node.P95),
+			format(node.Max),
+			formatReportTime(node.First),
+			formatReportTime(node.Last),
+		})
+	}
+	table.Print()
+}
+
+// PrintTestHistory renders recorded test results as a table, grouping the runs of each node
+func PrintTestHistory(results []models.TestResult) {
+	table := utils.NewTable([]string{"名称", "节点ID", "时间", "下载", "上传", "延迟", "抖动"})
+	for _, result := range results {
+		table.AddRow([]string{
+			result.NodeName,
+			result.NodeID,
+			formatReportTime(result.TestedAt),
+			formatSpeed(result.Download),
+			formatSpeed(result.Upload),
+			formatLatency(result.Latency),
+			formatLatency(result.Jitter),
+		})
+	}
+	table.SortBy([]string{"节点ID", "时间"})
+	table.Print()
+}
+
+// formatReportTime renders when a test ran in local time
+func formatReportTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/models"
+	"aqua-speed-tools/internal/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ansiPattern    = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	speedPattern   = regexp.MustCompile(`(?i)(download|upload)[^0-9\n]*([0-9]+(?:\.[0-9]+)?)\s*([kmg]?)bps`)
+	latencyPattern = regexp.MustCompile(`(?i)(?:latency|ping)[^0-9\n]*([0-9]+(?:\.[0-9]+)?)\s*(ms|s)\b`)
+	jitterPattern  = regexp.MustCompile(`(?i)jitter[^0-9\n]*([0-9]+(?:\.[0-9]+)?)\s*(ms|s)\b`)
+)
+
+// parseTestOutput extracts the download and upload speeds (in Mbps), latency and jitter (in ms)
+// from the test binary output. The last reported value wins, since the binary prints intermediate
+// progress before the final result.
+func parseTestOutput(output string) (download, upload, latency, jitter float64) {
+	output = ansiPattern.ReplaceAllString(output, "")
+
+	latency = lastDuration(latencyPattern, output)
+	jitter = lastDuration(jitterPattern, output)
+
+	for _, match := range speedPattern.FindAllStringSubmatch(output, -1) {
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+
+		switch strings.ToLower(match[3]) {
+		case "":
+			value /= 1e6
+		case "k":
+			value /= 1e3
+		case "g":
+			value *= 1e3
+		}
+
+		if strings.EqualFold(match[1], "download") {
+			download = value
+		} else {
+			upload = value
+		}
+	}
+
+	return download, upload, latency, jitter
+}
+
+// lastDuration returns the last duration in milliseconds matched by pattern in output,
+// 0 if there is none. The pattern captures the value and its unit (ms or s).
+func lastDuration(pattern *regexp.Regexp, output string) float64 {
+	var ms float64
+	for _, match := range pattern.FindAllStringSubmatch(output, -1) {
+		value, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(match[2], "s") {
+			value *= 1e3
+		}
+		ms = value
+	}
+	return ms
+}
+
+// formatSpeed renders a speed in Mbps colored against the configured thresholds
+func formatSpeed(mbps float64) string {
+	if mbps <= 0 {
+		return "-"
+	}
+	thresholds := config.Get().SpeedThresholds
+	return utils.SpeedColor(mbps, thresholds.Good, thresholds.Fair).Sprintf("%.2f Mbps", mbps)
+}
+
+// formatLatency renders a latency in milliseconds
+func formatLatency(ms float64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f ms", ms)
+}
+
+// printSummary prints a table of all test results
+func printSummary(results []models.TestResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	table := utils.NewTable([]string{"名称", "节点ID", "下载", "上传", "延迟"})
+	for _, result := range results {
+		table.AddRow([]string{
+			result.NodeName,
+			result.NodeID,
+			formatSpeed(result.Download),
+			formatSpeed(result.Upload),
+			formatLatency(result.Latency),
+		})
+	}
+
+	fmt.Println()
+	table.Print()
+}
+
+// printJSON writes v to stdout as indented JSON, for --json output
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON output: %w", err)
+	}
+	return nil
+}
+
+// writeResultsFile saves test results to path as indented JSON
+func writeResultsFile(path string, results []models.TestResult) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write results file: %w", err)
+	}
+	return nil
+}
@@ -40,15 +40,31 @@ func NewSpeedTest(cfg config.Config) (*SpeedTest, error) {
 // Init initializes the speed test environment
 func (s *SpeedTest) Init() error {
 	// 检查更新
+	s.CheckForUpdates()
+
+	// Initialize nodes
+	return s.LoadNodes()
+}
+
+// CheckForUpdates checks for and installs updates of the speed test binary.
+// Failures are logged rather than returned, so they never block testing.
+func (s *SpeedTest) CheckForUpdates() {
 	if err := s.updater.CheckAndUpdate(); err != nil {
 		s.logger.Error("Failed to check for updates", zap.Error(err))
 		// 继续执行，不要因为更新检查失败而中断
 	}
+}
 
-	// Initialize nodes
+// LoadNodes fetches and validates the speed test node list
+func (s *SpeedTest) LoadNodes() error {
 	return s.initNodes()
 }
 
+// SetConfig replaces the configuration used for fetching nodes
+func (s *SpeedTest) SetConfig(cfg config.Config) {
+	s.config = cfg
+}
+
 func (s *SpeedTest) GetNodes() []models.Node {
 	nodes := make([]models.Node, 0, len(s.nodes))
 	for _, node := range s.nodes {
@@ -1,22 +1,73 @@
 package service
 
 import (
+	"aqua-speed-tools/internal/history"
 	"aqua-speed-tools/internal/models"
 	"aqua-speed-tools/internal/updater"
 	"aqua-speed-tools/internal/utils"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// retryDelay is the pause between attempts of a failed node test
+const retryDelay = 2 * time.Second
+
+// waitDelay bounds how long a killed test binary's output is still read
+const waitDelay = time.Second
+
+// kindArg is the test binary argument selecting which directions to test
+const kindArg = "--kind"
+
+// proxyArg is the test binary argument selecting a proxy, passed when the binary supports it
+const proxyArg = "--proxy"
+
+// headerArg and tokenArg are the test binary arguments passing a node's headers and auth token
+const (
+	headerArg = "--header"
+	tokenArg  = "--token"
 )
 
 type TestService struct {
 	nodes   []models.Node
 	logger  *zap.Logger
 	updater *updater.Updater
+	retries int           // Number of times a failed test is re-run before giving up
+	timeout time.Duration // Per-test timeout, 0 means no limit; nodes may override it
+	kind    models.TestKind
+	// summaryOnly hides the per-node decorations and test binary output, leaving the results table
+	summaryOnly bool
+	// jsonOutput replaces all human-readable test output with the results as JSON on stdout
+	jsonOutput bool
+	parallel   int  // Number of nodes RunAllTest tests at once, 1 or less to test them one at a time
+	concurrent bool // Whether tests are running in parallel, so their output must not be streamed
+
+	kindSupported bool     // Whether the test binary is known to accept kindArg
+	proxy         *url.URL // Proxy the test binary connects through, nil for a direct connection
+	helpOutput    *string  // Cached --help output of the test binary, nil until first read
+	helpMu        sync.Mutex
+
+	resultsPath string              // File collecting the results of this run, empty to disable
+	historyPath string              // JSONL file every result is appended to across runs, empty to disable
+	savedPath   string              // History file read by the history command, empty to disable
+	filter      models.NodeFilter   // Nodes RunAllTest tests, empty to test all of them
+	recorded    []models.TestResult // Results written to resultsPath so far
+	recordMu    sync.Mutex          // Serializes recordResult during concurrent tests
 }
 
 func NewTestService(nodes []models.Node, logger *zap.Logger, updater *updater.Updater) *TestService {
@@ -24,42 +75,319 @@ func NewTestService(nodes []models.Node, logger *zap.Logger, updater *updater.Up
 		nodes:   nodes,
 		logger:  logger,
 		updater: updater,
+		kind:    models.TestKindBoth,
+	}
+}
+
+// SetRetries sets how many times a failed node test is re-run before it is reported as failed
+func (s *TestService) SetRetries(retries int) {
+	if retries < 0 {
+		retries = 0
+	}
+	s.retries = retries
+}
+
+// SetTimeout sets how long a single node test may run before it is killed, 0 disables the limit.
+// Nodes with their own timeout field override it.
+func (s *TestService) SetTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	s.timeout = timeout
+}
+
+// SetProxy makes the test binary connect through proxy, nil for a direct connection.
+// The proxy is passed through the proxy environment variables, and through proxyArg
+// when the binary supports it.
+func (s *TestService) SetProxy(proxy *url.URL) {
+	s.proxy = proxy
+}
+
+// SetTestKind selects whether tests measure download, upload or both.
+// Nodes whose type cannot run the kind are skipped by RunAllTest.
+func (s *TestService) SetTestKind(kind models.TestKind) {
+	s.kind = kind
+}
+
+// SetSummaryOnly hides per-node headers, footers and test binary output,
+// so only the final results table is shown
+func (s *TestService) SetSummaryOnly(summaryOnly bool) {
+	s.summaryOnly = summaryOnly
+}
+
+// SetJSONOutput makes tests print their results to stdout as JSON instead of the test binary
+// output and results table: a single object for one node, an array when testing all nodes
+func (s *TestService) SetJSONOutput(jsonOutput bool) {
+	s.jsonOutput = jsonOutput
+}
+
+// quietOutput reports whether the per-node decorations and test binary output are hidden
+func (s *TestService) quietOutput() bool {
+	return s.summaryOnly || s.jsonOutput
+}
+
+// SetParallel sets how many nodes RunAllTest tests at once. Concurrent tests share the
+// bandwidth and lower each other's measured speeds, so 1 is the default.
+func (s *TestService) SetParallel(parallel int) {
+	s.parallel = parallel
+}
+
+// SetResultsFile makes every successful test result of this run be saved to path as JSON
+func (s *TestService) SetResultsFile(path string) {
+	s.resultsPath = path
+}
+
+// SetHistoryFile appends every test result to path as a line of JSON, keeping the
+// results of all runs for the report command
+func (s *TestService) SetHistoryFile(path string) {
+	s.historyPath = path
+}
+
+// SetSavedHistory sets the history file every completed test is kept in for the history
+// command, usually config.GetHistoryPath()
+func (s *TestService) SetSavedHistory(path string) {
+	s.savedPath = path
+}
+
+// recordResult saves a result to the results and history files, if they are set
+func (s *TestService) recordResult(result models.TestResult) {
+	s.recordMu.Lock()
+	defer s.recordMu.Unlock()
+
+	paths := []string{s.savedPath}
+	if s.historyPath != s.savedPath {
+		paths = append(paths, s.historyPath)
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if err := history.Append(path, result); err != nil {
+			s.logger.Warn("failed to append test result to history",
+				zap.String("path", path),
+				zap.Error(err))
+		}
+	}
+
+	if s.resultsPath == "" {
+		return
+	}
+	s.recorded = append(s.recorded, result)
+	if err := writeResultsFile(s.resultsPath, s.recorded); err != nil {
+		s.logger.Warn("failed to save test results",
+			zap.String("path", s.resultsPath),
+			zap.Error(err))
+	}
+}
+
+// SetNodeFilter restricts the nodes RunAllTest tests
+func (s *TestService) SetNodeFilter(filter models.NodeFilter) {
+	s.filter = filter
+}
+
+// RunFilteredTest tests the nodes matching filter, like RunAllTest with the filter set
+func (s *TestService) RunFilteredTest(ctx context.Context, filter models.NodeFilter) error {
+	s.SetNodeFilter(filter)
+	return s.RunAllTest(ctx)
+}
+
+// RunAllTest tests every node kept by the node filter, one at a time unless a
+// parallelism above 1 is set, stopping at the first failure. Cancelling ctx kills
+// the running test and prints the results of the nodes completed so far.
+func (s *TestService) RunAllTest(ctx context.Context) error {
+	if s.parallel > 1 {
+		return s.RunAllTestConcurrent(ctx, s.parallel)
+	}
+
+	nodes, err := s.nodesToTest()
+	if err != nil {
+		return err
+	}
+
+	results := make([]models.TestResult, 0, len(nodes))
+	for _, node := range nodes {
+		result, err := s.runSpeedTest(ctx, node)
+		if ctx.Err() != nil {
+			return s.cancelled(ctx, results, len(nodes))
+		}
+		if err != nil {
+			s.logger.Error("failed to test node",
+				zap.String("node", node.Name.Zh),
+				zap.Error(err))
+			// 仍输出已完成节点的结果，便于脚本处理部分结果
+			if printErr := s.printResults(results); printErr != nil {
+				s.logger.Warn("failed to print test results", zap.Error(printErr))
+			}
+			return fmt.Errorf("failed to test node %s: %w", node.Name.Zh, err)
+		}
+		results = append(results, result)
+	}
+
+	s.logger.Info("all node tests completed successfully")
+	if err := s.printResults(results); err != nil {
+		return err
+	}
+	if !s.jsonOutput {
+		utils.Green.Println(" ✨ All node tests completed")
+	}
+	return nil
+}
+
+// RunAllTestConcurrent tests every node kept by the node filter, up to maxParallel at
+// a time. A failed node does not stop the others; the failures are returned together
+// once every node has been tested, and the results are printed in node table order.
+// Cancelling ctx kills the running tests and skips the nodes not started yet.
+func (s *TestService) RunAllTestConcurrent(ctx context.Context, maxParallel int) error {
+	maxParallel = max(maxParallel, 1)
+	nodes, err := s.nodesToTest()
+	if err != nil {
+		return err
+	}
+	// 提前检查测速程序，使并发测试只读取缓存的检查结果
+	if err := s.checkTestKind(); err != nil {
+		return err
+	}
+	if maxParallel > 1 {
+		s.logger.Warn("testing nodes in parallel, measured speeds will be lower",
+			zap.Int("parallel", maxParallel))
+		utils.Yellow.Printf("Warning: testing up to %d nodes in parallel; concurrent tests share the bandwidth, so measured speeds will be lower\n", maxParallel)
+		s.concurrent = true
+		defer func() { s.concurrent = false }()
+	}
+
+	results := make([]models.TestResult, len(nodes))
+	errs := make([]error, len(nodes))
+	var g errgroup.Group
+	g.SetLimit(maxParallel)
+	for i, node := range nodes {
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return nil
+			}
+			results[i], errs[i] = s.runSpeedTest(ctx, node)
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return nil
+			}
+			if errs[i] != nil {
+				s.logger.Error("failed to test node",
+					zap.String("node", node.Name.Zh),
+					zap.Error(errs[i]))
+				errs[i] = fmt.Errorf("failed to test node %s: %w", node.Name.Zh, errs[i])
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	completed := make([]models.TestResult, 0, len(nodes))
+	failed := 0
+	for i, result := range results {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		completed = append(completed, result)
+	}
+	if ctx.Err() != nil {
+		return s.cancelled(ctx, completed, len(nodes))
+	}
+
+	if err := s.printResults(completed); err != nil {
+		return err
+	}
+	if failed > 0 {
+		utils.Red.Printf("%d of %d node tests failed\n", failed, len(nodes))
+		return errors.Join(errs...)
 	}
+	s.logger.Info("all node tests completed successfully")
+	if !s.jsonOutput {
+		utils.Green.Println(" ✨ All node tests completed")
+	}
+	return nil
 }
 
-func (s *TestService) RunAllTest() error {
+// nodesToTest returns the nodes RunAllTest tests, in node table order, leaving out
+// those excluded by the node filter or unable to run the test kind
+func (s *TestService) nodesToTest() ([]models.Node, error) {
 	if len(s.nodes) == 0 {
 		s.logger.Error("no available nodes")
-		return fmt.Errorf("no available nodes")
+		return nil, fmt.Errorf("no available nodes")
 	}
 
 	s.logger.Info("starting test for all nodes")
 	utils.Yellow.Println("Preparing to test all nodes...")
 
+	nodes := make([]models.Node, 0, len(s.nodes))
 	for _, node := range s.nodes {
-		if err := s.runSpeedTest(node); err != nil {
-			s.logger.Error("failed to test node",
+		if !s.filter.Keep(node) {
+			s.logger.Debug("skipping node excluded by filter", zap.String("node", node.Id))
+			utils.Explain(utils.ExplainNodes, "跳过节点 %s：被节点筛选条件排除", node.Id)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	if excluded := len(s.nodes) - len(nodes); excluded > 0 {
+		s.logger.Info("nodes excluded by filter", zap.Int("excluded", excluded), zap.Int("remaining", len(nodes)))
+		utils.Yellow.Printf("Excluded %d of %d nodes by the node filter\n", excluded, len(s.nodes))
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no node matches the filter, all %d nodes were excluded\n%s", len(s.nodes), filterValues(s.nodes))
+	}
+
+	// Test in the same order as the node table so runs are comparable
+	supported := make([]models.Node, 0, len(nodes))
+	for _, node := range getSortedNodes(nodes) {
+		if !node.Type.Supports(s.kind) {
+			s.logger.Info("skipping node that does not support the test kind",
 				zap.String("node", node.Name.Zh),
-				zap.Error(err))
-			return fmt.Errorf("failed to test node %s: %w", node.Name.Zh, err)
+				zap.String("type", string(node.Type)),
+				zap.String("kind", string(s.kind)))
+			utils.Yellow.Printf("Skipping %s: %s nodes do not support %s tests\n", node.Name.Zh, node.Type, s.kind)
+			utils.Explain(utils.ExplainNodes, "跳过节点 %s：%s 类型不支持 %s 测试", node.Id, node.Type, s.kind)
+			continue
 		}
+		supported = append(supported, node)
 	}
+	return supported, nil
+}
 
-	s.logger.Info("all node tests completed successfully")
-	utils.Green.Println(" ✨ All node tests completed")
+// cancelled reports a run of total nodes interrupted by ctx after the results were completed,
+// printing them with a summary of the skipped nodes, and returns the cancellation error
+func (s *TestService) cancelled(ctx context.Context, results []models.TestResult, total int) error {
+	skipped := total - len(results)
+	s.logger.Warn("speed tests cancelled",
+		zap.Int("completed", len(results)),
+		zap.Int("skipped", skipped),
+		zap.Error(ctx.Err()))
+	if err := s.printResults(results); err != nil {
+		s.logger.Warn("failed to print test results", zap.Error(err))
+	}
+	utils.Yellow.Printf("\nTests cancelled: %d of %d nodes completed, %d skipped\n", len(results), total, skipped)
+	return fmt.Errorf("speed tests cancelled: %w", ctx.Err())
+}
+
+// printResults prints the results of several nodes as JSON in JSON mode, or as the results table
+func (s *TestService) printResults(results []models.TestResult) error {
+	if s.jsonOutput {
+		return printJSON(results)
+	}
+	printSummary(results)
 	return nil
 }
 
-func (s *TestService) RunTest(input string) error {
+// RunTest tests the node with the given numeric or node ID. Cancelling ctx kills the test.
+func (s *TestService) RunTest(ctx context.Context, input string) error {
 	var numID int
 	if _, err := fmt.Sscanf(input, "%d", &numID); err == nil {
 		// Try to find the node by numeric ID
 		index := 1
-		// Sort nodes by type and ISP to match table display
+		// Sort nodes by type, ISP and ID to match table display
 		sortedNodes := getSortedNodes(s.nodes)
 		for _, node := range sortedNodes {
 			if index == numID {
-				return s.runSpeedTest(node)
+				return s.runSingleTest(ctx, node)
 			}
 			index++
 		}
@@ -84,55 +412,242 @@ func (s *TestService) RunTest(input string) error {
 		return fmt.Errorf("invalid node ID: %s", input)
 	}
 
-	return s.runSpeedTest(node)
+	return s.runSingleTest(ctx, node)
+}
+
+// runSingleTest tests one node, printing its result as JSON in JSON mode or
+// its result table in summary-only mode
+func (s *TestService) runSingleTest(ctx context.Context, node models.Node) error {
+	result, err := s.runSpeedTest(ctx, node)
+	if ctx.Err() != nil {
+		utils.Yellow.Printf("\nTest of %s cancelled\n", node.Name.Zh)
+		return fmt.Errorf("speed test cancelled: %w", ctx.Err())
+	}
+	if err != nil {
+		return err
+	}
+	if s.jsonOutput {
+		return printJSON(result)
+	}
+	if s.summaryOnly {
+		printSummary([]models.TestResult{result})
+	}
+	return nil
 }
 
-func (s *TestService) runSpeedTest(node models.Node) error {
+func (s *TestService) runSpeedTest(ctx context.Context, node models.Node) (models.TestResult, error) {
+	if !node.Type.Supports(s.kind) {
+		return models.TestResult{}, fmt.Errorf("%s nodes do not support %s tests", node.Type, s.kind)
+	}
+	if err := s.checkTestKind(); err != nil {
+		return models.TestResult{}, err
+	}
+
 	s.logger.Info("starting speed test for node",
-		zap.String("node", node.Name.Zh))
+		zap.String("node", node.Name.Zh),
+		zap.String("kind", string(s.kind)))
 
-	printTestHeader(node)
+	if !s.quietOutput() {
+		printTestHeader(node)
+	}
 
-	if err := s.executeTest(node); err != nil {
+	output, err := s.executeTest(ctx, node)
+	for attempt := 1; err != nil && ctx.Err() == nil && attempt <= s.retries; attempt++ {
+		s.logger.Warn("speed test failed, retrying",
+			zap.String("node", node.Name.Zh),
+			zap.Int("attempt", attempt),
+			zap.Int("retries", s.retries),
+			zap.Error(err))
+		utils.Yellow.Printf("Test failed, retrying (%d/%d)...\n", attempt, s.retries)
+		select {
+		case <-time.After(retryDelay):
+		case <-ctx.Done():
+			return models.TestResult{}, ctx.Err()
+		}
+		output, err = s.executeTest(ctx, node)
+	}
+	if err != nil {
 		s.logger.Error("speed test execution failed",
 			zap.String("node", node.Name.Zh),
 			zap.Error(err))
-		return err
+		return models.TestResult{}, err
 	}
 
-	// s.logger.Info("speed test completed successfully",
-	// 	zap.String("node", node.Name.Zh))
-	printTestFooter(node)
-	return nil
+	result := models.TestResult{
+		NodeID:   node.Id,
+		NodeName: node.Name.Zh,
+		TestedAt: time.Now().UTC(),
+	}
+	result.Download, result.Upload, result.Latency, result.Jitter = parseTestOutput(output)
+
+	s.logger.Info("speed test completed successfully",
+		zap.String("nodeId", result.NodeID),
+		zap.String("node", result.NodeName),
+		zap.Float64("downloadMbps", result.Download),
+		zap.Float64("uploadMbps", result.Upload),
+		zap.Float64("latencyMs", result.Latency),
+		zap.Float64("jitterMs", result.Jitter))
+	if !s.quietOutput() {
+		printTestFooter(node, result)
+	}
+	s.recordResult(result)
+	return result, nil
 }
 
-func (s *TestService) executeTest(node models.Node) error {
+// executeTest runs the test binary for a node and returns a copy of its output.
+// The binary is killed when ctx is cancelled or the node's timeout expires.
+func (s *TestService) executeTest(ctx context.Context, node models.Node) (string, error) {
 	cmdArgs := []string{
 		"--thread", fmt.Sprintf("%d", node.Threads),
 		"--server", node.Url,
 		"--sn", node.Name.Zh,
 		"--type", string(node.Type),
 	}
+	if s.kind != models.TestKindBoth {
+		cmdArgs = append(cmdArgs, kindArg, string(s.kind))
+	}
+	if s.proxy != nil && s.binarySupports(proxyArg) {
+		cmdArgs = append(cmdArgs, proxyArg, s.proxy.String())
+	}
+	authArgs, err := s.nodeAuthArgs(node)
+	if err != nil {
+		return "", err
+	}
+	cmdArgs = append(cmdArgs, authArgs...)
 
-	binaryPath := filepath.Join(s.updater.InstallDir, "bin", s.updater.BinaryName)
-	cmd := exec.Command(binaryPath, cmdArgs...)
+	timeout := node.TestTimeout(s.timeout)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	binaryPath := s.binaryPath()
+	cmd := exec.CommandContext(ctx, binaryPath, cmdArgs...)
+	// 子进程被终止后不再等待其残留的子进程关闭输出管道
+	cmd.WaitDelay = waitDelay
+	if s.proxy != nil {
+		cmd.Env = append(os.Environ(), utils.ProxyEnv(s.proxy)...)
+	}
 
 	s.logger.Info("executing speed test command",
 		zap.String("binary", binaryPath),
 		zap.String("node", node.Name.Zh),
-		zap.Strings("args", cmdArgs))
+		zap.Strings("args", redactArgs(cmdArgs)),
+		zap.Duration("timeout", timeout))
 
-	cmd.Stdout = os.Stdout
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	if !s.quietOutput() && !s.concurrent {
+		cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+	}
 	cmd.Stderr = os.Stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("speed test timed out after %s: %w", timeout, ctx.Err())
+	}
 	if err != nil {
 		s.logger.Error("command execution failed",
 			zap.String("binary", binaryPath),
 			zap.String("node", node.Name.Zh),
 			zap.Error(err))
 	}
-	return err
+	return output.String(), err
+}
+
+// nodeAuthArgs returns the test binary arguments passing the node's headers, in name
+// order, and auth token. Unlike the proxy they cannot be left out, so a binary not
+// supporting them is an error.
+func (s *TestService) nodeAuthArgs(node models.Node) ([]string, error) {
+	var args []string
+	if len(node.Headers) > 0 {
+		if !s.binarySupports(headerArg) {
+			return nil, fmt.Errorf("node %s requires headers but speed test binary %s does not support %s", node.Id, s.binaryPath(), headerArg)
+		}
+		for _, name := range slices.Sorted(maps.Keys(node.Headers)) {
+			args = append(args, headerArg, fmt.Sprintf("%s: %s", name, node.Headers[name]))
+		}
+	}
+	if node.AuthToken != "" {
+		if !s.binarySupports(tokenArg) {
+			return nil, fmt.Errorf("node %s requires an auth token but speed test binary %s does not support %s", node.Id, s.binaryPath(), tokenArg)
+		}
+		args = append(args, tokenArg, node.AuthToken)
+	}
+	return args, nil
+}
+
+// redactArgs returns test binary arguments for logging, with the auth token and the
+// values of sensitive headers masked
+func redactArgs(args []string) []string {
+	redacted := slices.Clone(args)
+	for i := 1; i < len(redacted); i++ {
+		switch redacted[i-1] {
+		case tokenArg:
+			redacted[i] = "***"
+		case headerArg:
+			if name, _, ok := strings.Cut(redacted[i], ":"); ok && utils.IsSensitiveHeader(name) {
+				redacted[i] = name + ": ***"
+			}
+		}
+	}
+	return redacted
+}
+
+// binaryPath returns the path of the installed test binary
+func (s *TestService) binaryPath() string {
+	return filepath.Join(s.updater.InstallDir, "bin", s.updater.BinaryName)
+}
+
+// checkTestKind verifies that the test binary accepts kindArg when a single direction is requested
+func (s *TestService) checkTestKind() error {
+	if s.kind == models.TestKindBoth || s.kindSupported {
+		return nil
+	}
+
+	binaryPath := s.binaryPath()
+	output, err := s.binaryHelp()
+	if !strings.Contains(output, kindArg) {
+		if err != nil {
+			return fmt.Errorf("failed to check %s test support of %s: %w", s.kind, binaryPath, err)
+		}
+		return fmt.Errorf("speed test binary %s does not support %s tests (missing %s option)", binaryPath, s.kind, kindArg)
+	}
+
+	s.kindSupported = true
+	return nil
+}
+
+// binarySupports reports whether the test binary's --help output mentions arg
+func (s *TestService) binarySupports(arg string) bool {
+	output, err := s.binaryHelp()
+	if err != nil && output == "" {
+		s.logger.Debug("failed to read test binary help", zap.String("arg", arg), zap.Error(err))
+	}
+	return strings.Contains(output, arg)
+}
+
+// binaryHelp returns the --help output of the test binary, read once and then cached.
+// A failed read is not cached, so an update installing the binary is picked up.
+func (s *TestService) binaryHelp() (string, error) {
+	s.helpMu.Lock()
+	defer s.helpMu.Unlock()
+
+	if s.helpOutput != nil {
+		return *s.helpOutput, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, s.binaryPath(), "--help").CombinedOutput()
+	if err != nil {
+		return string(output), err
+	}
+	help := string(output)
+	s.helpOutput = &help
+	return help, nil
 }
 
 func (s *TestService) getNodeByID(id string) (models.Node, bool) {
@@ -153,26 +668,35 @@ func printTestHeader(node models.Node) {
 	utils.Green.Printf("└─────────────────────────────────────────┘\n\n")
 }
 
-func printTestFooter(node models.Node) {
+func printTestFooter(node models.Node, result models.TestResult) {
 	utils.Green.Printf("\n┌─────────────────────────────────────────┐\n")
 	fmt.Printf("%s 🎉 Test completed: %s%s\n",
 		utils.Green.Sprintf("│"),
 		utils.Cyan.Sprint(node.Name.Zh),
 		utils.Green.Sprintf(" "))
+	if result.Download > 0 || result.Upload > 0 {
+		fmt.Printf("%s    Download: %s  Upload: %s\n",
+			utils.Green.Sprintf("│"),
+			formatSpeed(result.Download),
+			formatSpeed(result.Upload))
+	}
 	utils.Green.Printf("└─────────────────────────────────────────┘\n\n")
 }
 
-// getSortedNodes returns nodes sorted by type and ISP to match table display
+// getSortedNodes returns nodes sorted by type, ISP and ID to match table display
 func getSortedNodes(nodes []models.Node) []models.Node {
 	sortedNodes := make([]models.Node, len(nodes))
 	copy(sortedNodes, nodes)
 
-	// Sort by type and ISP to match table display
+	// Sort by type, ISP and ID to match table display
 	sort.Slice(sortedNodes, func(i, j int) bool {
 		if sortedNodes[i].GeoInfo.Type != sortedNodes[j].GeoInfo.Type {
 			return sortedNodes[i].GeoInfo.Type < sortedNodes[j].GeoInfo.Type
 		}
-		return sortedNodes[i].Isp.Zh < sortedNodes[j].Isp.Zh
+		if sortedNodes[i].Isp.Zh != sortedNodes[j].Isp.Zh {
+			return sortedNodes[i].Isp.Zh < sortedNodes[j].Isp.Zh
+		}
+		return sortedNodes[i].Id < sortedNodes[j].Id
 	})
 
 	return sortedNodes
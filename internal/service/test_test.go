@@ -0,0 +1,84 @@
+package service
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+
+	"aqua-speed-tools/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// testNodes returns nodes sharing types and ISPs, so that only their ID tells some apart
+func testNodes() models.NodeList {
+	nodes := make(models.NodeList)
+	for i, spec := range []struct{ geoType, isp string }{
+		{"IDC", "电信"}, {"IDC", "电信"}, {"IDC", "联通"}, {"CDN", "电信"},
+		{"CDN", "移动"}, {"IDC", "移动"}, {"CDN", "移动"}, {"IDC", "电信"},
+	} {
+		var node models.Node
+		node.Id = "node-" + strconv.Itoa(i)
+		node.Isp.Zh = spec.isp
+		node.GeoInfo = models.GeoInfo{CountryCode: "CN", Type: spec.geoType}
+		nodes[node.Id] = node
+	}
+	return nodes
+}
+
+func nodeIDs(nodes []models.Node) []string {
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.Id
+	}
+	return ids
+}
+
+func TestGetSortedNodesStableOrder(t *testing.T) {
+	s := &SpeedTest{nodes: testNodes()}
+	want := nodeIDs(getSortedNodes(s.GetNodes()))
+
+	for run := range 20 {
+		nodes := s.GetNodes()
+		rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+		if got := nodeIDs(getSortedNodes(nodes)); !slices.Equal(got, want) {
+			t.Fatalf("run %d order = %v, want %v", run, got, want)
+		}
+	}
+
+	// The numbers shown in the node table select the nodes in the same order
+	for i, id := range want {
+		got, err := s.GetNodeIDByInput(strconv.Itoa(i + 1))
+		if err != nil {
+			t.Fatalf("GetNodeIDByInput(%d) error = %v", i+1, err)
+		}
+		if got != id {
+			t.Errorf("GetNodeIDByInput(%d) = %s, want %s", i+1, got, id)
+		}
+	}
+}
+
+func TestNodesToTestStableOrder(t *testing.T) {
+	nodes := (&SpeedTest{nodes: testNodes()}).GetNodes()
+
+	var first []string
+	for run := range 2 {
+		// Each run receives the nodes in another order, as iterating the node map does
+		shuffled := slices.Clone(nodes)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		s := &TestService{nodes: shuffled, logger: zap.NewNop(), kind: models.TestKindDownload}
+
+		tested, err := s.nodesToTest()
+		if err != nil {
+			t.Fatalf("nodesToTest() error = %v", err)
+		}
+		if run == 0 {
+			first = nodeIDs(tested)
+			continue
+		}
+		if got := nodeIDs(tested); !slices.Equal(got, first) {
+			t.Errorf("second run order = %v, want %v as in the first run", got, first)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bufio"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
@@ -47,12 +48,95 @@ func (r *ReaderWithProgress) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// FileProgressFunc receives extraction progress of a single archive entry.
+type FileProgressFunc func(name string, current, total int64)
+
 // NewArchiveReader creates a new ArchiveReader based on the archive type.
 func NewArchiveReader(path string, logger *zap.Logger) (ArchiveReader, error) {
+	return NewArchiveReaderWithProgress(path, logger, nil)
+}
+
+// NewArchiveReaderWithProgress creates a new ArchiveReader that reports extraction
+// progress to fn instead of showing a progress bar per file. A nil fn keeps the bars.
+func NewArchiveReaderWithProgress(path string, logger *zap.Logger, fn FileProgressFunc) (ArchiveReader, error) {
+	if strings.HasSuffix(path, ".zip") {
+		reader, err := NewZipArchiveReader(path, logger)
+		if err != nil {
+			return nil, err
+		}
+		reader.progressFn = fn
+		return reader, nil
+	}
+
+	if isTarGz(path) {
+		reader, err := NewTarGzArchiveReader(path, logger)
+		if err != nil {
+			return nil, err
+		}
+		reader.progressFn = fn
+		return reader, nil
+	}
+
+	reader, err := NewTarXzArchiveReader(path, logger)
+	if err != nil {
+		return nil, err
+	}
+	reader.progressFn = fn
+	return reader, nil
+}
+
+// archiveEntrySizes returns the uncompressed size of every file in the archive at path,
+// in archive order. Tar archives have to be decompressed to reach every entry header.
+func archiveEntrySizes(path string) ([]int64, error) {
 	if strings.HasSuffix(path, ".zip") {
-		return NewZipArchiveReader(path, logger)
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open ZIP file: %w", err)
+		}
+		defer reader.Close()
+
+		var sizes []int64
+		for _, file := range reader.File {
+			if !file.FileInfo().IsDir() {
+				sizes = append(sizes, int64(file.UncompressedSize64))
+			}
+		}
+		return sizes, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	decoder, err := newTarDecoder(f)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := decoder.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	var sizes []int64
+	tarReader := tar.NewReader(decoder)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return sizes, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar header: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			sizes = append(sizes, header.Size)
+		}
 	}
-	return NewTarXzArchiveReader(path, logger)
+}
+
+// isTarGz reports whether path names a gzip compressed tar archive
+func isTarGz(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
 }
 
 type ZipArchiveReader struct {
@@ -61,6 +145,7 @@ type ZipArchiveReader struct {
 	index      int
 	bufferPool sync.Pool
 	logger     *zap.Logger
+	progressFn FileProgressFunc
 }
 
 func NewZipArchiveReader(path string, logger *zap.Logger) (*ZipArchiveReader, error) {
@@ -104,14 +189,20 @@ func (z *ZipArchiveReader) Next() (string, io.Reader, error) {
 		pool:   &z.bufferPool,
 	}
 
-	progressBar := progressbar.DefaultBytes(
-		int64(file.UncompressedSize64),
-		fmt.Sprintf("Extracting %s", file.Name),
-	)
+	report := z.progressFn
+	if report == nil {
+		progressBar := progressbar.DefaultBytes(
+			int64(file.UncompressedSize64),
+			fmt.Sprintf("Extracting %s", file.Name),
+		)
+		report = func(_ string, current, _ int64) {
+			progressBar.Set64(current)
+		}
+	}
 
 	return file.Name, NewReaderWithProgress(reader, int64(file.UncompressedSize64),
 		func(current, total int64) {
-			progressBar.Set64(current)
+			report(file.Name, current, total)
 			z.logger.Debug("Extraction progress",
 				zap.String("file", file.Name),
 				zap.Int64("current", current),
@@ -124,17 +215,21 @@ func (z *ZipArchiveReader) Close() error {
 	return z.reader.Close()
 }
 
-type TarXzArchiveReader struct {
-	file      *os.File
-	xzReader  io.Reader
-	tarReader *tar.Reader
-	logger    *zap.Logger
+// tarArchiveReader reads the entries of a compressed tar archive
+type tarArchiveReader struct {
+	file       *os.File
+	decoder    io.Reader // Decompressed stream of file
+	tarReader  *tar.Reader
+	logger     *zap.Logger
+	progressFn FileProgressFunc
 }
 
-func NewTarXzArchiveReader(path string, logger *zap.Logger) (*TarXzArchiveReader, error) {
+// openTarArchive opens the tar archive at path, decompressed with newDecoder.
+// kind names the archive format in errors.
+func openTarArchive(path, kind string, logger *zap.Logger, newDecoder func(*os.File) (io.Reader, error)) (tarArchiveReader, error) {
 	f, err := os.OpenFile(path, os.O_RDONLY, 0)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open TAR.XZ file: %w", err)
+		return tarArchiveReader{}, fmt.Errorf("failed to open %s file: %w", kind, err)
 	}
 
 	// Enable read-ahead for better performance
@@ -142,27 +237,19 @@ func NewTarXzArchiveReader(path string, logger *zap.Logger) (*TarXzArchiveReader
 	// syscall.Fadvise(int(f.Fd()), 0, 0, syscall.FADV_SEQUENTIAL)
 	// TODO: use fadvise on linux
 
-	// Optimize buffer size for small files
-	bufferedReader := bufio.NewReaderSize(f, 256*1024) // 256KB buffer
-
-	// Configure XZ reader for optimal small file performance
-	xzConfig := xz.ReaderConfig{
-		DictCap: 1024 * 1024, // 1MB dictionary
-	}
-
-	xzReader, err := xzConfig.NewReader(bufferedReader)
+	decoder, err := newDecoder(f)
 	if err != nil {
 		f.Close()
-		return nil, fmt.Errorf("failed to create XZ reader: %w", err)
+		return tarArchiveReader{}, err
 	}
 
 	fi, err := f.Stat()
 	if err != nil {
 		f.Close()
-		return nil, err
+		return tarArchiveReader{}, err
 	}
 
-	progressReader := NewReaderWithProgress(xzReader, fi.Size(),
+	progressReader := NewReaderWithProgress(decoder, fi.Size(),
 		func(current, total int64) {
 			logger.Debug("Decompression progress",
 				zap.Int64("current", current),
@@ -170,31 +257,94 @@ func NewTarXzArchiveReader(path string, logger *zap.Logger) (*TarXzArchiveReader
 				zap.Float64("percentage", float64(current)/float64(total)*100))
 		})
 
-	tarReader := tar.NewReader(progressReader)
-
-	return &TarXzArchiveReader{
+	return tarArchiveReader{
 		file:      f,
-		xzReader:  xzReader,
-		tarReader: tarReader,
+		decoder:   decoder,
+		tarReader: tar.NewReader(progressReader),
 		logger:    logger,
 	}, nil
 }
 
-func (t *TarXzArchiveReader) Next() (string, io.Reader, error) {
+type TarXzArchiveReader struct {
+	tarArchiveReader
+}
+
+func NewTarXzArchiveReader(path string, logger *zap.Logger) (*TarXzArchiveReader, error) {
+	reader, err := openTarArchive(path, "TAR.XZ", logger, newXzReader)
+	if err != nil {
+		return nil, err
+	}
+	return &TarXzArchiveReader{reader}, nil
+}
+
+// TarGzArchiveReader reads the entries of a gzip compressed tar archive (.tar.gz or .tgz)
+type TarGzArchiveReader struct {
+	tarArchiveReader
+}
+
+func NewTarGzArchiveReader(path string, logger *zap.Logger) (*TarGzArchiveReader, error) {
+	reader, err := openTarArchive(path, "TAR.GZ", logger, newGzReader)
+	if err != nil {
+		return nil, err
+	}
+	return &TarGzArchiveReader{reader}, nil
+}
+
+// newTarDecoder returns the decompressed stream of the tar archive f, chosen by the name of f
+func newTarDecoder(f *os.File) (io.Reader, error) {
+	if isTarGz(f.Name()) {
+		return newGzReader(f)
+	}
+	return newXzReader(f)
+}
+
+// newXzReader returns the decompressed stream of the XZ file f
+func newXzReader(f *os.File) (io.Reader, error) {
+	// Optimize buffer size for small files
+	bufferedReader := bufio.NewReaderSize(f, 256*1024) // 256KB buffer
+
+	// Configure XZ reader for optimal small file performance
+	xzConfig := xz.ReaderConfig{
+		DictCap: 1024 * 1024, // 1MB dictionary
+	}
+
+	xzReader, err := xzConfig.NewReader(bufferedReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create XZ reader: %w", err)
+	}
+	return xzReader, nil
+}
+
+// newGzReader returns the decompressed stream of the gzip file f
+func newGzReader(f *os.File) (io.Reader, error) {
+	gzReader, err := gzip.NewReader(bufio.NewReaderSize(f, 256*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	return gzReader, nil
+}
+
+func (t *tarArchiveReader) Next() (string, io.Reader, error) {
 	header, err := t.tarReader.Next()
 	if err != nil {
 		return "", nil, err
 	}
 
 	if header.Size > 0 {
-		progressBar := progressbar.DefaultBytes(
-			header.Size,
-			fmt.Sprintf("Extracting %s", header.Name),
-		)
+		report := t.progressFn
+		if report == nil {
+			progressBar := progressbar.DefaultBytes(
+				header.Size,
+				fmt.Sprintf("Extracting %s", header.Name),
+			)
+			report = func(_ string, current, _ int64) {
+				progressBar.Set64(current)
+			}
+		}
 
 		return header.Name, NewReaderWithProgress(t.tarReader, header.Size,
 			func(current, total int64) {
-				progressBar.Set64(current)
+				report(header.Name, current, total)
 				t.logger.Debug("File extraction progress",
 					zap.String("file", header.Name),
 					zap.Int64("current", current),
@@ -206,8 +356,8 @@ func (t *TarXzArchiveReader) Next() (string, io.Reader, error) {
 	return header.Name, t.tarReader, nil
 }
 
-func (t *TarXzArchiveReader) Close() error {
-	if closer, ok := t.xzReader.(io.Closer); ok {
+func (t *tarArchiveReader) Close() error {
+	if closer, ok := t.decoder.(io.Closer); ok {
 		closer.Close()
 	}
 	return t.file.Close()
@@ -0,0 +1,128 @@
+package updater
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ArchiveBenchmark is the timing of fully reading a release archive
+type ArchiveBenchmark struct {
+	ArchiveSize int64         // Size of the archive file
+	StreamSize  int64         // Size of the decompressed tar stream, zero for ZIP archives
+	EntrySize   int64         // Total size of the archive entries
+	Entries     int           // Number of archive entries
+	Decompress  time.Duration // Time to decompress the XZ stream alone, zero for ZIP archives
+	Unpack      time.Duration // Time to read the entries out of the decompressed tar stream, zero for ZIP archives
+	Total       time.Duration // Time to read every entry through the archive reader
+}
+
+// IsZip reports whether the benchmark has no separate decompress phase, since
+// ZIP archives compress each entry on its own
+func (b *ArchiveBenchmark) IsZip() bool {
+	return b.StreamSize == 0
+}
+
+// BenchmarkArchive times fully reading the archive at path through the archive reader.
+// For tar archives the XZ or gzip stream is also decompressed into memory on its own, and
+// the tar phase is timed over that stream, so both phases are measured separately.
+func (u *Updater) BenchmarkArchive(path string) (*ArchiveBenchmark, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, WrapError("stat archive", err)
+	}
+	bench := &ArchiveBenchmark{ArchiveSize: info.Size()}
+
+	if !strings.HasSuffix(path, ".zip") {
+		stream, elapsed, err := benchmarkDecompress(path)
+		if err != nil {
+			return nil, WrapError("decompress archive", err)
+		}
+		bench.StreamSize, bench.Decompress = int64(len(stream)), elapsed
+
+		if bench.Unpack, err = benchmarkUnpack(stream); err != nil {
+			return nil, WrapError("read tar stream", err)
+		}
+	}
+
+	// 读取时不显示进度条，避免终端输出影响计时
+	start := time.Now()
+	reader, err := NewArchiveReaderWithProgress(path, u.logger, func(string, int64, int64) {})
+	if err != nil {
+		return nil, WrapError("open archive", err)
+	}
+	defer reader.Close()
+
+	for {
+		name, entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, WrapError("read archive", err)
+		}
+		n, err := io.Copy(io.Discard, entry)
+		if closer, ok := entry.(io.Closer); ok {
+			closer.Close()
+		}
+		if err != nil {
+			return nil, WrapError("read archive", fmt.Errorf("%s: %w", name, err))
+		}
+		bench.Entries++
+		bench.EntrySize += n
+	}
+	bench.Total = time.Since(start)
+
+	u.logger.Debug("Benchmarked archive",
+		zap.String("path", path),
+		zap.Int("entries", bench.Entries),
+		zap.Duration("decompress", bench.Decompress),
+		zap.Duration("unpack", bench.Unpack),
+		zap.Duration("total", bench.Total))
+	return bench, nil
+}
+
+// benchmarkDecompress times decompressing the XZ or gzip stream of path into memory without parsing it
+func benchmarkDecompress(path string) ([]byte, time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	decoder, err := newTarDecoder(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	var stream bytes.Buffer
+	if _, err := io.Copy(&stream, decoder); err != nil {
+		return nil, 0, err
+	}
+	return stream.Bytes(), time.Since(start), nil
+}
+
+// benchmarkUnpack times reading every entry out of a decompressed tar stream
+func benchmarkUnpack(stream []byte) (time.Duration, error) {
+	start := time.Now()
+	tarReader := tar.NewReader(bytes.NewReader(stream))
+	for {
+		_, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if _, err := io.Copy(io.Discard, tarReader); err != nil {
+			return 0, err
+		}
+	}
+	return time.Since(start), nil
+}
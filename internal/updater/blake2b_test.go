@@ -0,0 +1,53 @@
+package updater
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestBlake2b512(t *testing.T) {
+	sequence := func(n int) []byte {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{
+			// RFC 7693 Appendix A
+			name:  "abc",
+			input: []byte("abc"),
+			want:  "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		},
+		{
+			name:  "empty",
+			input: nil,
+			want:  "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce",
+		},
+		{
+			name:  "exactly one block",
+			input: sequence(128),
+			want:  "2319e3789c47e2daa5fe807f61bec2a1a6537fa03f19ff32e87eecbfd64b7e0e8ccff439ac333b040f19b0c4ddd11a61e24ac1fe0f10a039806c5dcc0da3d115",
+		},
+		{
+			name:  "one block and a byte",
+			input: sequence(129),
+			want:  "f59711d44a031d5f97a9413c065d1e614c417ede998590325f49bad2fd444d3e4418be19aec4e11449ac1a57207898bc57d76a1bcf3566292c20c683a5c4648f",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest := blake2b512(tt.input)
+			if got := hex.EncodeToString(digest[:]); got != tt.want {
+				t.Errorf("blake2b512() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
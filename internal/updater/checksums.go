@@ -0,0 +1,180 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// releaseChecksumsAsset is the release-level checksum file listing several assets
+const releaseChecksumsAsset = "checksums.txt"
+
+// releaseChecksumsSigAssets are the accepted names of the minisign signature of releaseChecksumsAsset
+var releaseChecksumsSigAssets = []string{releaseChecksumsAsset + ".minisig", releaseChecksumsAsset + ".sig"}
+
+// signedChecksumsRequired reports whether binaries must be verified against the
+// signed release checksum file, which is the case once a public key is configured
+func signedChecksumsRequired() bool {
+	return config.Get().Binary.ChecksumsPublicKey != ""
+}
+
+// fetchReleaseChecksums downloads and parses the release-level checksum file of the
+// latest release, verifying its minisign signature when a public key is configured.
+// The verified checksums are kept until the next release lookup.
+func (u *Updater) fetchReleaseChecksums() (map[string]string, error) {
+	if u.releaseChecksums != nil {
+		return u.releaseChecksums, nil
+	}
+	if u.checksumsURL == "" {
+		if signedChecksumsRequired() {
+			return nil, fmt.Errorf("checksums_public_key is set but the release has no %s", releaseChecksumsAsset)
+		}
+		return nil, fmt.Errorf("checksum file not found in archive and the release has no %s", releaseChecksumsAsset)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	content, err := u.fetchReleaseAsset(ctx, u.checksumsURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", releaseChecksumsAsset, err)
+	}
+	if err := u.verifyChecksumsSignature(ctx, content); err != nil {
+		return nil, WrapError("verify checksum signature", err)
+	}
+
+	u.logger.Debug("Fetched release checksum file", zap.String("url", u.checksumsURL))
+	u.releaseChecksums = parseChecksums(string(content))
+	return u.releaseChecksums, nil
+}
+
+// verifyChecksumsSignature verifies the release checksum file against its minisign
+// signature. Without a configured public key it only warns; with one, a release
+// without a signature asset fails.
+func (u *Updater) verifyChecksumsSignature(ctx context.Context, content []byte) error {
+	publicKey := config.Get().Binary.ChecksumsPublicKey
+	if publicKey == "" {
+		u.logger.Warn("Release checksum file is not signature verified",
+			zap.Bool("signatureAvailable", u.checksumsSigURL != ""))
+		return nil
+	}
+	if u.checksumsSigURL == "" {
+		return fmt.Errorf("checksums_public_key is set but the release has no signature for %s", releaseChecksumsAsset)
+	}
+
+	key, err := ParseMinisignPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	signature, err := u.fetchReleaseAsset(ctx, u.checksumsSigURL)
+	if err != nil {
+		return fmt.Errorf("failed to download signature: %w", err)
+	}
+	if err := key.VerifyMinisign(content, string(signature)); err != nil {
+		return err
+	}
+
+	u.logger.Debug("Verified release checksum file signature", zap.String("url", u.checksumsSigURL))
+	return nil
+}
+
+// fetchReleaseAsset downloads a small release asset such as a checksum or signature file.
+func (u *Updater) fetchReleaseAsset(ctx context.Context, assetURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := checkDownloadHost(req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Aqua-Speed-Updater/"+u.Version.String())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %s", resp.Status)
+	}
+
+	body, err := utils.ResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(io.LimitReader(body, 1<<20))
+}
+
+// trustedChecksums returns the checksums the binaries read from archivePath must match.
+// Those bundled in the archive are trusted unless a checksums public key is configured:
+// then they come from the signed release checksum file, which must list the binaries
+// or the archive, so that a tampered archive carrying a matching checksum.txt is rejected.
+func (u *Updater) trustedChecksums(archivePath string, binaries []archiveBinary, bundled map[string]string) (map[string]string, error) {
+	if !signedChecksumsRequired() {
+		return bundled, nil
+	}
+	checksums, err := u.releaseBinaryChecksums(archivePath, binaries)
+	if err != nil {
+		return nil, WrapError("verify signed checksums", err)
+	}
+	return checksums, nil
+}
+
+// releaseBinaryChecksums returns the checksums of binaries extracted from archivePath,
+// keyed by their archive name, using the release-level checksum file. If that file
+// lists the binaries, their entries are used; if it only lists the archive, the
+// archive is verified instead and the binaries' own checksums are computed.
+func (u *Updater) releaseBinaryChecksums(archivePath string, binaries []archiveBinary) (map[string]string, error) {
+	checksums, err := u.fetchReleaseChecksums()
+	if err != nil {
+		return nil, err
+	}
+
+	listed := true
+	for _, binary := range binaries {
+		if _, ok := checksums[binary.archiveName]; !ok {
+			listed = false
+			break
+		}
+	}
+	if listed {
+		return checksums, nil
+	}
+
+	archiveName := filepath.Base(archivePath)
+	archiveChecksum, ok := checksums[archiveName]
+	if !ok {
+		return nil, fmt.Errorf("%s has no entry for %s or its binaries", releaseChecksumsAsset, archiveName)
+	}
+
+	archiveData, err := os.ReadFile(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if err := u.verifyChecksum(archiveData, archiveChecksum); err != nil {
+		return nil, err
+	}
+	u.logger.Debug("Verified archive against release checksum file", zap.String("archive", archiveName))
+
+	binaryChecksums := make(map[string]string, len(binaries))
+	for _, binary := range binaries {
+		checksum, err := binary.hasher.Sum(u.computedChecksumAlgorithm())
+		if err != nil {
+			return nil, WrapError("calculate checksum", err)
+		}
+		binaryChecksums[binary.archiveName] = checksum
+	}
+	return binaryChecksums, nil
+}
@@ -0,0 +1,324 @@
+package updater
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aqua-speed-tools/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// minisignSigner signs files the way minisign -S does with a prehashed (ED) signature
+type minisignSigner struct {
+	keyID   [8]byte
+	private ed25519.PrivateKey
+	public  string // Base64 public key line, as in minisign.pub
+}
+
+func newMinisignSigner(t *testing.T) *minisignSigner {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &minisignSigner{private: private}
+	copy(signer.keyID[:], "testkey1")
+	raw := append([]byte(minisignAlgLegacy), signer.keyID[:]...)
+	signer.public = base64.StdEncoding.EncodeToString(append(raw, public...))
+	return signer
+}
+
+func (s *minisignSigner) sign(content []byte) string {
+	digest := blake2b512(content)
+	sig := ed25519.Sign(s.private, digest[:])
+	raw := append(append([]byte(minisignAlgPrehashed), s.keyID[:]...), sig...)
+	trustedComment := "timestamp:1700000000\tfile:checksums.txt"
+	globalSig := ed25519.Sign(s.private, append(bytes.Clone(sig), trustedComment...))
+	return fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trustedComment, base64.StdEncoding.EncodeToString(globalSig))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// setChecksumsPublicKey configures key for the duration of the test
+func setChecksumsPublicKey(t *testing.T, key string) {
+	t.Helper()
+	previous := config.Get().Binary.ChecksumsPublicKey
+	config.Update(func(cfg *config.Config) { cfg.Binary.ChecksumsPublicKey = key })
+	t.Cleanup(func() {
+		config.Update(func(cfg *config.Config) { cfg.Binary.ChecksumsPublicKey = previous })
+	})
+}
+
+// newChecksumsUpdater returns an updater whose latest release serves assets, keyed by name
+func newChecksumsUpdater(t *testing.T, assets map[string]string) *Updater {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := assets[filepath.Base(r.URL.Path)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	t.Cleanup(srv.Close)
+
+	u := &Updater{logger: zap.NewNop(), client: srv.Client()}
+	if _, ok := assets[releaseChecksumsAsset]; ok {
+		u.checksumsURL = srv.URL + "/" + releaseChecksumsAsset
+	}
+	if _, ok := assets[releaseChecksumsAsset+".minisig"]; ok {
+		u.checksumsSigURL = srv.URL + "/" + releaseChecksumsAsset + ".minisig"
+	}
+	return u
+}
+
+// hashedBinary returns data as a binary read from an archive
+func hashedBinary(t *testing.T, name string, data []byte) archiveBinary {
+	t.Helper()
+	read, hasher, err := readHashed(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return archiveBinary{archiveName: name, destName: name, data: read, hasher: hasher}
+}
+
+func TestTrustedChecksumsSigned(t *testing.T) {
+	signer := newMinisignSigner(t)
+	setChecksumsPublicKey(t, signer.public)
+
+	original := []byte("original aqua-speed binary")
+	tampered := []byte("tampered aqua-speed binary")
+	checksums := fmt.Sprintf("%s  aqua-speed-linux-x64\n", sha256Hex(original))
+	archivePath := filepath.Join(t.TempDir(), "aqua-speed-linux-x64.tar.gz")
+
+	tests := []struct {
+		name      string
+		assets    map[string]string
+		binary    []byte
+		wantErr   bool // trustedChecksums fails
+		wantMatch bool // the binary matches the trusted checksum
+	}{
+		{
+			name: "valid signature",
+			assets: map[string]string{
+				releaseChecksumsAsset:              checksums,
+				releaseChecksumsAsset + ".minisig": signer.sign([]byte(checksums)),
+			},
+			binary:    original,
+			wantMatch: true,
+		},
+		{
+			// A mirror serving a modified binary with a checksum.txt matching it
+			name: "tampered binary with matching bundled checksum",
+			assets: map[string]string{
+				releaseChecksumsAsset:              checksums,
+				releaseChecksumsAsset + ".minisig": signer.sign([]byte(checksums)),
+			},
+			binary: tampered,
+		},
+		{
+			name: "tampered checksum file",
+			assets: map[string]string{
+				releaseChecksumsAsset:              fmt.Sprintf("%s  aqua-speed-linux-x64\n", sha256Hex(tampered)),
+				releaseChecksumsAsset + ".minisig": signer.sign([]byte(checksums)),
+			},
+			binary:  tampered,
+			wantErr: true,
+		},
+		{
+			name: "signature from another key",
+			assets: map[string]string{
+				releaseChecksumsAsset:              checksums,
+				releaseChecksumsAsset + ".minisig": newMinisignSigner(t).sign([]byte(checksums)),
+			},
+			binary:  original,
+			wantErr: true,
+		},
+		{
+			name:    "missing signature",
+			assets:  map[string]string{releaseChecksumsAsset: checksums},
+			binary:  original,
+			wantErr: true,
+		},
+		{
+			name:    "missing checksum file",
+			assets:  map[string]string{},
+			binary:  original,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newChecksumsUpdater(t, tt.assets)
+			binary := hashedBinary(t, "aqua-speed-linux-x64", tt.binary)
+			bundled := map[string]string{binary.archiveName: sha256Hex(tt.binary)}
+
+			trusted, err := u.trustedChecksums(archivePath, []archiveBinary{binary}, bundled)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("trustedChecksums() = %v, want error", trusted)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("trustedChecksums() error = %v", err)
+			}
+
+			err = u.verifyHashed(binary.hasher, trusted[binary.archiveName])
+			if tt.wantMatch && err != nil {
+				t.Errorf("verifyHashed() error = %v, want match", err)
+			}
+			if !tt.wantMatch && err == nil {
+				t.Error("verifyHashed() = nil, want mismatch")
+			}
+		})
+	}
+}
+
+func TestTrustedChecksumsSignedArchive(t *testing.T) {
+	signer := newMinisignSigner(t)
+	setChecksumsPublicKey(t, signer.public)
+
+	archive := []byte("release archive")
+	archivePath := filepath.Join(t.TempDir(), "aqua-speed-linux-x64.tar.gz")
+	if err := os.WriteFile(archivePath, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+	checksums := fmt.Sprintf("%s  aqua-speed-linux-x64.tar.gz\n", sha256Hex(archive))
+	u := newChecksumsUpdater(t, map[string]string{
+		releaseChecksumsAsset:              checksums,
+		releaseChecksumsAsset + ".minisig": signer.sign([]byte(checksums)),
+	})
+
+	binary := hashedBinary(t, "aqua-speed-linux-x64", []byte("binary"))
+	if _, err := u.trustedChecksums(archivePath, []archiveBinary{binary}, nil); err != nil {
+		t.Fatalf("trustedChecksums() error = %v", err)
+	}
+
+	if err := os.WriteFile(archivePath, []byte("tampered archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.trustedChecksums(archivePath, []archiveBinary{binary}, nil); err == nil {
+		t.Error("trustedChecksums() = nil for a tampered archive, want error")
+	}
+}
+
+func TestTrustedChecksumsWithoutKey(t *testing.T) {
+	setChecksumsPublicKey(t, "")
+
+	u := newChecksumsUpdater(t, map[string]string{})
+	bundled := map[string]string{"aqua-speed-linux-x64": "abc"}
+	trusted, err := u.trustedChecksums("archive.tar.gz", nil, bundled)
+	if err != nil {
+		t.Fatalf("trustedChecksums() error = %v", err)
+	}
+	if trusted["aqua-speed-linux-x64"] != "abc" {
+		t.Errorf("trustedChecksums() = %v, want the bundled checksums", trusted)
+	}
+}
+
+func TestReadArchiveContentsChecksumLocation(t *testing.T) {
+	setChecksumsPublicKey(t, "")
+
+	binary := "#!/bin/sh\necho aqua-speed v1.2.3\n"
+	withBundled := [][2]string{
+		{"aqua-speed-linux-x64", binary},
+		{"checksum.txt", sha256Hex([]byte(binary)) + "\n"},
+	}
+	withoutBundled := [][2]string{{"aqua-speed-linux-x64", binary}}
+
+	tests := []struct {
+		name    string
+		files   [][2]string
+		release func(archive []byte) map[string]string // Release assets, given the archive
+		wantErr bool
+	}{
+		{
+			name:    "checksum in the archive",
+			files:   withBundled,
+			release: func([]byte) map[string]string { return map[string]string{} },
+		},
+		{
+			name:  "release checksum file listing the binary",
+			files: withoutBundled,
+			release: func([]byte) map[string]string {
+				return map[string]string{releaseChecksumsAsset: fmt.Sprintf("%s  aqua-speed-linux-x64\n", sha256Hex([]byte(binary)))}
+			},
+		},
+		{
+			name:  "release checksum file listing the archive",
+			files: withoutBundled,
+			release: func(archive []byte) map[string]string {
+				return map[string]string{releaseChecksumsAsset: fmt.Sprintf("%s  aqua-speed-linux-x64.tar.gz\n", sha256Hex(archive))}
+			},
+		},
+		{
+			name:  "release checksum file not matching the binary",
+			files: withoutBundled,
+			release: func([]byte) map[string]string {
+				return map[string]string{releaseChecksumsAsset: fmt.Sprintf("%s  aqua-speed-linux-x64\n", sha256Hex([]byte("other")))}
+			},
+			wantErr: true,
+		},
+		{
+			name:  "release checksum file listing neither",
+			files: withoutBundled,
+			release: func([]byte) map[string]string {
+				return map[string]string{releaseChecksumsAsset: fmt.Sprintf("%s  aqua-speed-darwin-x64\n", sha256Hex([]byte(binary)))}
+			},
+			wantErr: true,
+		},
+		{
+			name:    "no checksum anywhere",
+			files:   withoutBundled,
+			release: func([]byte) map[string]string { return map[string]string{} },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "aqua-speed-linux-x64.tar.gz")
+			writeTarGz(t, archivePath, tt.files)
+			archive, err := os.ReadFile(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			u := newChecksumsUpdater(t, tt.release(archive))
+			u.BinaryName = "aqua-speed-linux-x64"
+			checksum, read, err := u.readArchiveContents(archivePath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readArchiveContents() checksum = %s, want error", checksum)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readArchiveContents() error = %v", err)
+			}
+			if string(read.data) != binary {
+				t.Errorf("readArchiveContents() binary = %q, want %q", read.data, binary)
+			}
+			if err := u.verifyHashed(read.hasher, checksum); err != nil {
+				t.Errorf("readArchiveContents() checksum = %s, want the binary's: %v", checksum, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"go.uber.org/zap"
+)
+
+// versionCheckTimeout bounds how long the installed binary may take to report its version
+const versionCheckTimeout = 10 * time.Second
+
+// validateTimeout bounds the trial run of a newly saved binary before it is kept
+const validateTimeout = 5 * time.Second
+
+// reportedVersionPattern finds a version number in the output of the binary's --version
+var reportedVersionPattern = regexp.MustCompile(`v?\d+\.\d+\.\d+(?:[-+][0-9A-Za-z.+-]*)?`)
+
+// ErrVersionMismatch is returned when the installed binary is not the version recorded in version.txt
+var ErrVersionMismatch = errors.New("installed binary version does not match version.txt")
+
+// ErrUnexpectedVersion is returned when a newly saved binary reports another version than the release it came from
+var ErrUnexpectedVersion = errors.New("installed binary does not report the release version")
+
+// CheckInstalledVersion compares the version the installed binary reports with
+// --version against the version recorded in version.txt, which catches a binary
+// swapped by hand or left behind by a partial update. It returns nil when there
+// is nothing to compare, i.e. the binary or version.txt is missing.
+func (u *Updater) CheckInstalledVersion() error {
+	binaryPath := filepath.Join(u.InstallDir, "bin", u.BinaryName)
+	if !FileExists(binaryPath) {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(u.InstallDir, "version.txt"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return WrapError("read version file", err)
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return WrapError("read version file", ErrInvalidVersion)
+	}
+	recorded, err := ParseVersion(fields[0])
+	if err != nil {
+		return WrapError("read version file", err)
+	}
+
+	reported, err := reportedVersion(binaryPath)
+	if err != nil {
+		return WrapError("check installed version", err)
+	}
+
+	u.logger.Debug("Checked installed binary version",
+		zap.String("binary", binaryPath),
+		zap.String("reported", reported.String()),
+		zap.String("recorded", recorded.String()))
+	if !reported.Equals(recorded) {
+		return fmt.Errorf("%w: %s reports %s, version.txt records %s", ErrVersionMismatch, binaryPath, reported, recorded)
+	}
+	return nil
+}
+
+// reportedVersion runs the binary with --version and parses the version it prints
+func reportedVersion(binaryPath string) (semver.Version, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionCheckTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, binaryPath, "--version").CombinedOutput()
+	match := reportedVersionPattern.FindString(string(output))
+	if match == "" {
+		if err != nil {
+			return semver.Version{}, fmt.Errorf("failed to run %s --version: %w", binaryPath, err)
+		}
+		return semver.Version{}, fmt.Errorf("no version found in the output of %s --version", binaryPath)
+	}
+	return ParseVersion(match)
+}
+
+// validateBinary runs a newly saved binary with --version and requires it to exit
+// successfully and print a version, catching a corrupt or wrong-architecture build
+// whose checksum still matched
+func validateBinary(binaryPath string) (semver.Version, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), validateTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, binaryPath, "--version").CombinedOutput()
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("%w: %s --version failed: %v", ErrBinaryNotRunnable, binaryPath, err)
+	}
+	match := reportedVersionPattern.FindString(string(output))
+	if match == "" {
+		return semver.Version{}, fmt.Errorf("%w: no version found in the output of %s --version", ErrBinaryNotRunnable, binaryPath)
+	}
+	version, err := ParseVersion(match)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("%w: %v", ErrBinaryNotRunnable, err)
+	}
+	return version, nil
+}
@@ -0,0 +1,164 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// versionScript returns a shell script printing output for --version and exiting with code
+func versionScript(output string, code int) string {
+	return fmt.Sprintf("#!/bin/sh\necho '%s'\nexit %d\n", output, code)
+}
+
+// skipWithoutShell skips tests running shell scripts as the installed binary
+func skipWithoutShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the installed binary is a shell script")
+	}
+}
+
+func TestValidateBinary(t *testing.T) {
+	skipWithoutShell(t)
+
+	tests := []struct {
+		name    string
+		script  string
+		want    string
+		wantErr error
+	}{
+		{name: "reports its version", script: versionScript("aqua-speed v2.0.0 (linux/amd64)", 0), want: "2.0.0"},
+		{name: "fails to run", script: versionScript("aqua-speed v2.0.0", 1), wantErr: ErrBinaryNotRunnable},
+		{name: "reports no version", script: versionScript("usage: aqua-speed [options]", 0), wantErr: ErrBinaryNotRunnable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "aqua-speed")
+			if err := os.WriteFile(path, []byte(tt.script), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			reported, err := validateBinary(path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validateBinary() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && reported.String() != tt.want {
+				t.Errorf("validateBinary() = %s, want %s", reported, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAndSaveBinaryRollsBack(t *testing.T) {
+	skipWithoutShell(t)
+
+	previous := versionScript("aqua-speed v1.0.0", 0)
+	tests := []struct {
+		name       string
+		installed  bool // Whether a previous binary is installed
+		newBinary  string
+		wantErr    error
+		wantBinary string // Binary left in place after the update, empty for none
+	}{
+		{name: "reports the release version", installed: true, newBinary: versionScript("aqua-speed v2.0.0", 0), wantBinary: versionScript("aqua-speed v2.0.0", 0)},
+		{name: "reports another version", installed: true, newBinary: versionScript("aqua-speed v1.9.0", 0), wantErr: ErrUnexpectedVersion, wantBinary: previous},
+		{name: "fails to run", installed: true, newBinary: versionScript("aqua-speed v2.0.0", 1), wantErr: ErrBinaryNotRunnable, wantBinary: previous},
+		{name: "reports another version on a first install", newBinary: versionScript("aqua-speed v1.9.0", 0), wantErr: ErrUnexpectedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			destPath := filepath.Join(dir, "aqua-speed")
+			versionFile := filepath.Join(dir, "version.txt")
+			if tt.installed {
+				if err := os.WriteFile(destPath, []byte(previous), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(versionFile, []byte("1.0.0 old\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			u := &Updater{InstallDir: dir, logger: zap.NewNop()}
+			binary := hashedBinary(t, "aqua-speed", []byte(tt.newBinary))
+			latest, err := ParseVersion("2.0.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checksum := sha256Hex([]byte(tt.newBinary))
+
+			err = u.verifyAndSaveBinary(destPath, &binary, latest, checksum)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verifyAndSaveBinary() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got, readErr := os.ReadFile(destPath)
+			switch {
+			case tt.wantBinary == "" && !os.IsNotExist(readErr):
+				t.Errorf("binary left at %s, want it removed", destPath)
+			case tt.wantBinary != "" && string(got) != tt.wantBinary:
+				t.Errorf("binary = %q, want %q", got, tt.wantBinary)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			if FileExists(destPath + backupSuffix) {
+				t.Errorf("backup %s left behind after restoring it", destPath+backupSuffix)
+			}
+			if tt.installed {
+				if content, _ := os.ReadFile(versionFile); string(content) != "1.0.0 old\n" {
+					t.Errorf("version.txt = %q, want it unchanged", content)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckInstalledVersion(t *testing.T) {
+	skipWithoutShell(t)
+
+	tests := []struct {
+		name     string
+		binary   string // Empty for no installed binary
+		recorded string // Empty for no version.txt
+		wantErr  error
+	}{
+		{name: "matching", binary: versionScript("aqua-speed v2.0.0", 0), recorded: "2.0.0 abc\n"},
+		{name: "swapped binary", binary: versionScript("aqua-speed v1.9.0", 0), recorded: "2.0.0 abc\n", wantErr: ErrVersionMismatch},
+		{name: "no version.txt", binary: versionScript("aqua-speed v1.9.0", 0)},
+		{name: "no binary", recorded: "2.0.0 abc\n"},
+		{name: "empty version.txt", binary: versionScript("aqua-speed v2.0.0", 0), recorded: "\n", wantErr: ErrInvalidVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, "bin"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.binary != "" {
+				if err := os.WriteFile(filepath.Join(dir, "bin", "aqua-speed"), []byte(tt.binary), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.recorded != "" {
+				if err := os.WriteFile(filepath.Join(dir, "version.txt"), []byte(tt.recorded), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			u := &Updater{InstallDir: dir, BinaryName: "aqua-speed", logger: zap.NewNop()}
+			if err := u.CheckInstalledVersion(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckInstalledVersion() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// diskSpaceMargin is the free space required on top of the estimated need
+	diskSpaceMargin = 16 << 20
+	// extractionFactor estimates the size of the extracted binaries from the archive
+	// size, as xz-compressed executables typically expand three to four times
+	extractionFactor = 4
+)
+
+// diskFreeSpace returns the bytes available to the current user on the filesystem
+// holding dir. It is a variable so the check can be exercised without a full disk.
+var diskFreeSpace = freeDiskSpace
+
+// DiskSpaceError is returned when a directory lacks the free space an update needs
+type DiskSpaceError struct {
+	Dir       string
+	Required  uint64
+	Available uint64
+	Hint      string // How to use another directory instead
+}
+
+func (e *DiskSpaceError) Error() string {
+	return fmt.Sprintf("not enough free space in %s: %.1f MiB required, %.1f MiB available; free up space or %s",
+		e.Dir, float64(e.Required)/(1<<20), float64(e.Available)/(1<<20), e.Hint)
+}
+
+// checkDiskSpace verifies that the temporary directory can hold the downloaded archive
+// and the installation directory the extracted binaries, before anything is downloaded.
+// An unknown archive size or an unsupported free space query skips the check.
+func (u *Updater) checkDiskSpace(tempDir string, archiveSize int64) error {
+	if archiveSize <= 0 {
+		u.logger.Debug("Archive size unknown, skipping disk space check")
+		return nil
+	}
+
+	size := uint64(archiveSize)
+	for _, need := range []struct {
+		dir      string
+		required uint64
+		hint     string
+	}{
+		{tempDir, size + diskSpaceMargin, "point TMPDIR (TEMP on Windows) at another directory"},
+		{u.InstallDir, size*extractionFactor + diskSpaceMargin, "choose another directory with --install-dir"},
+	} {
+		available, err := diskFreeSpace(need.dir)
+		if err != nil {
+			u.logger.Debug("Failed to query free disk space, skipping check", zap.String("dir", need.dir), zap.Error(err))
+			continue
+		}
+		u.logger.Debug("Checked free disk space",
+			zap.String("dir", need.dir),
+			zap.Uint64("required", need.required),
+			zap.Uint64("available", available))
+		if available < need.required {
+			return &DiskSpaceError{Dir: need.dir, Required: need.required, Available: available, Hint: need.hint}
+		}
+	}
+	return nil
+}
+
+// archiveSize returns the size of the release archive at downloadURL, from the
+// release metadata when known or else from the Content-Length of a HEAD request.
+// It returns 0 when the size cannot be determined.
+func (u *Updater) archiveSize(downloadURL string) int64 {
+	if u.assetSize > 0 {
+		return u.assetSize
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, downloadURL, nil)
+	if err != nil || checkDownloadHost(req.URL.Hostname()) != nil {
+		return 0
+	}
+	req.Header.Set("User-Agent", "Aqua-Speed-Updater/"+u.Version.String())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		u.logger.Debug("Failed to query archive size", zap.String("url", downloadURL), zap.Error(err))
+		return 0
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0
+	}
+	return max(resp.ContentLength, 0)
+}
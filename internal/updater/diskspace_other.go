@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package updater
+
+import "errors"
+
+// freeDiskSpace is not supported on this platform, so the disk space check is skipped
+func freeDiskSpace(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
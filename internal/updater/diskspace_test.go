@@ -0,0 +1,98 @@
+package updater
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/blang/semver/v4"
+
+	"go.uber.org/zap"
+)
+
+// setDiskFreeSpace makes the disk space check see free bytes in each directory, or
+// fail the query for directories missing from free
+func setDiskFreeSpace(t *testing.T, free map[string]uint64) {
+	t.Helper()
+	previous := diskFreeSpace
+	diskFreeSpace = func(dir string) (uint64, error) {
+		available, ok := free[dir]
+		if !ok {
+			return 0, errors.New("statfs not supported")
+		}
+		return available, nil
+	}
+	t.Cleanup(func() { diskFreeSpace = previous })
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	const archiveSize = 10 << 20
+	const tempNeed = archiveSize + diskSpaceMargin
+	const installNeed = archiveSize*extractionFactor + diskSpaceMargin
+
+	tests := []struct {
+		name        string
+		archiveSize int64
+		free        map[string]uint64
+		wantDir     string // Directory reported as lacking space, empty for no error
+		wantNeed    uint64
+	}{
+		{name: "enough space", archiveSize: archiveSize, free: map[string]uint64{"tmp": tempNeed, "install": installNeed}},
+		{name: "temp dir full", archiveSize: archiveSize, free: map[string]uint64{"tmp": tempNeed - 1, "install": installNeed}, wantDir: "tmp", wantNeed: tempNeed},
+		{name: "install dir full", archiveSize: archiveSize, free: map[string]uint64{"tmp": tempNeed, "install": installNeed - 1}, wantDir: "install", wantNeed: installNeed},
+		{name: "unknown archive size", archiveSize: 0, free: map[string]uint64{"tmp": 0, "install": 0}},
+		{name: "free space unknown", archiveSize: archiveSize, free: map[string]uint64{"install": installNeed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setDiskFreeSpace(t, tt.free)
+			u := &Updater{logger: zap.NewNop(), InstallDir: "install"}
+
+			err := u.checkDiskSpace("tmp", tt.archiveSize)
+			if tt.wantDir == "" {
+				if err != nil {
+					t.Fatalf("checkDiskSpace() error = %v", err)
+				}
+				return
+			}
+
+			var spaceErr *DiskSpaceError
+			if !errors.As(err, &spaceErr) {
+				t.Fatalf("checkDiskSpace() error = %v, want a DiskSpaceError", err)
+			}
+			if spaceErr.Dir != tt.wantDir || spaceErr.Required != tt.wantNeed || spaceErr.Available != tt.wantNeed-1 {
+				t.Errorf("checkDiskSpace() error = %+v, want %s lacking %d bytes", spaceErr, tt.wantDir, tt.wantNeed)
+			}
+			if spaceErr.Hint == "" {
+				t.Error("DiskSpaceError.Hint is empty, want how to use another directory")
+			}
+		})
+	}
+}
+
+func TestPerformUpdateChecksDiskSpaceBeforeDownloading(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("archive"))
+	}))
+	t.Cleanup(srv.Close)
+
+	tempDir, installDir := t.TempDir(), t.TempDir()
+	setDiskFreeSpace(t, map[string]uint64{tempDir: 1 << 30, installDir: 1 << 20})
+	u := newDownloadUpdater(srv.Client())
+	u.InstallDir = installDir
+	u.assetSize = 10 << 20
+
+	err := u.performUpdate(tempDir, srv.URL+"/aqua-speed.tar.gz", semver.MustParse("2.0.0"), "aqua-speed.tar.gz")
+	var spaceErr *DiskSpaceError
+	if !errors.As(err, &spaceErr) || spaceErr.Dir != installDir {
+		t.Fatalf("performUpdate() error = %v, want a DiskSpaceError for %s", err, installDir)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("performUpdate() sent %d requests, want none before the disk space check", n)
+	}
+}
@@ -0,0 +1,123 @@
+package updater
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aqua-speed-tools/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// setRetryPolicy configures policy for the duration of the test
+func setRetryPolicy(t *testing.T, policy utils.RetryPolicy) {
+	t.Helper()
+	previous := utils.GetRetryPolicy()
+	utils.SetRetryPolicy(policy)
+	t.Cleanup(func() { utils.SetRetryPolicy(previous) })
+}
+
+// newDownloadUpdater returns an updater downloading with client
+func newDownloadUpdater(client *http.Client) *Updater {
+	return &Updater{logger: zap.NewNop(), client: client}
+}
+
+func TestDownloadAssetFallsBackToGitHub(t *testing.T) {
+	setRetryPolicy(t, utils.RetryPolicy{Attempts: 2, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	var mirrorRequests atomic.Int32
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorRequests.Add(1)
+		http.Error(w, "mirror down", http.StatusBadGateway)
+	}))
+	t.Cleanup(mirror.Close)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("archive"))
+	}))
+	t.Cleanup(origin.Close)
+
+	tests := []struct {
+		name      string
+		direct    string
+		wantErr   bool
+		wantTries int32 // Requests to the mirror
+	}{
+		{name: "working origin", direct: origin.URL + "/aqua-speed.tar.gz", wantTries: 2},
+		{name: "no origin known", direct: "", wantErr: true, wantTries: 2},
+		{name: "origin down too", direct: mirror.URL + "/direct/aqua-speed.tar.gz", wantErr: true, wantTries: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mirrorRequests.Store(0)
+			u := newDownloadUpdater(http.DefaultClient)
+			u.directDownloadURL = tt.direct
+
+			data, err := u.downloadAsset(mirror.URL+"/aqua-speed.tar.gz", "")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("downloadAsset() = %q, want error", data)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("downloadAsset() error = %v", err)
+				}
+				if string(data) != "archive" {
+					t.Errorf("downloadAsset() = %q, want the archive from the origin", data)
+				}
+			}
+			if n := mirrorRequests.Load(); n != tt.wantTries {
+				t.Errorf("mirror requested %d times, want %d", n, tt.wantTries)
+			}
+		})
+	}
+}
+
+// truncatingHandler advertises size bytes but, for the first truncate requests,
+// closes the connection after half of them
+func truncatingHandler(size int, truncate int32) (http.Handler, *atomic.Int32) {
+	var requests atomic.Int32
+	content := make([]byte, size)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		if requests.Add(1) <= truncate {
+			w.Write(content[:size/2])
+			return
+		}
+		w.Write(content)
+	}), &requests
+}
+
+func TestDownloadWithProgressTruncated(t *testing.T) {
+	handler, _ := truncatingHandler(4096, 1)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	_, err := newDownloadUpdater(srv.Client()).downloadWithProgress(srv.URL, "")
+	if !errors.Is(err, ErrTruncatedDownload) {
+		t.Errorf("downloadWithProgress() error = %v, want %v", err, ErrTruncatedDownload)
+	}
+}
+
+func TestDownloadWithRetryRedownloadsTruncated(t *testing.T) {
+	setRetryPolicy(t, utils.RetryPolicy{Attempts: 2, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	handler, requests := truncatingHandler(4096, 1)
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	data, err := newDownloadUpdater(srv.Client()).downloadWithRetry(srv.URL, "")
+	if err != nil {
+		t.Fatalf("downloadWithRetry() error = %v", err)
+	}
+	if len(data) != 4096 {
+		t.Errorf("downloaded %d bytes, want 4096", len(data))
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+}
@@ -15,6 +15,11 @@ func (e *UpdateError) Error() string {
 	return e.Err.Error()
 }
 
+// Unwrap returns the wrapped error, so that errors.Is matches the predefined errors.
+func (e *UpdateError) Unwrap() error {
+	return e.Err
+}
+
 // WrapError wraps an error with an operation context.
 func WrapError(op string, err error) error {
 	if err == nil {
@@ -29,4 +34,7 @@ var (
 	ErrDownloadFailed    = WrapError("download", fmt.Errorf("update download failed"))
 	ErrChecksumMismatch  = WrapError("checksum", fmt.Errorf("file checksum mismatch"))
 	ErrInvalidVersion    = WrapError("version", fmt.Errorf("invalid version file format"))
+	ErrTruncatedDownload = WrapError("download", fmt.Errorf("truncated download"))
+	ErrHostNotAllowed    = WrapError("download", fmt.Errorf("host not allowed"))
+	ErrBinaryNotRunnable = WrapError("validate", fmt.Errorf("installed binary does not run"))
 )
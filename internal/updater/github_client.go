@@ -4,19 +4,29 @@ import (
 	"aqua-speed-tools/internal/utils"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// ErrNotModified is returned by GetLatestReleaseIfChanged when the release still has the given ETag
+var ErrNotModified = errors.New("release not modified")
+
 // GitHubRelease represents the GitHub release API response.
 type GitHubRelease struct {
-	TagName string `json:"tag_name"`
-	Assets  []struct {
+	TagName     string    `json:"tag_name"`
+	Body        string    `json:"body"`         // Release notes in Markdown
+	HTMLURL     string    `json:"html_url"`     // Release page
+	PublishedAt time.Time `json:"published_at"` // Zero for unpublished drafts
+	Prerelease  bool      `json:"prerelease"`
+	Assets      []struct {
 		Name               string `json:"name"`
 		BrowserDownloadURL string `json:"browser_download_url"`
+		Size               int64  `json:"size"`
 	} `json:"assets"`
 }
 
@@ -32,6 +42,9 @@ type DefaultGitHubClient struct {
 	logger  *zap.Logger
 	version string
 	urls    *utils.GitHubURLs
+	// retry controls how a failed latest release lookup is retried; at most one attempt
+	// is made by the client itself when Attempts is below 2
+	retry utils.RetryPolicy
 }
 
 // NewDefaultGitHubClient creates a new DefaultGitHubClient instance.
@@ -44,8 +57,72 @@ func NewDefaultGitHubClient(client *http.Client, logger *zap.Logger, version str
 	}
 }
 
+// SetRetryPolicy sets how latest release lookups failing with a connection error, a 5xx
+// status or 429 are retried. Waits are randomized and stop early at the context deadline.
+// A rate limited lookup is retried once the limit resets, if that is within MaxBackoff.
+func (c *DefaultGitHubClient) SetRetryPolicy(policy utils.RetryPolicy) {
+	c.retry = policy
+}
+
 // GetLatestRelease fetches the latest release from the GitHub API.
 func (c *DefaultGitHubClient) GetLatestRelease(ctx context.Context, apiURL string) (*GitHubRelease, error) {
+	release, _, err := c.GetLatestReleaseIfChanged(ctx, apiURL, "")
+	return release, err
+}
+
+// GetLatestReleaseIfChanged fetches the latest release from the GitHub API along with its
+// ETag. When etag is not empty it is sent as If-None-Match, and ErrNotModified is returned
+// if the release still has it. Transient failures are retried as set by SetRetryPolicy.
+func (c *DefaultGitHubClient) GetLatestReleaseIfChanged(ctx context.Context, apiURL, etag string) (*GitHubRelease, string, error) {
+	if c.retry.Attempts <= 1 {
+		release, newETag, _, err := c.getLatestRelease(ctx, apiURL, etag)
+		return release, newETag, err
+	}
+
+	// 由本方法按 API 重试策略重试，避免与传输层的重试叠加
+	ctx = utils.WithoutRetry(ctx)
+	for attempt := 1; ; attempt++ {
+		release, newETag, retryable, err := c.getLatestRelease(ctx, apiURL, etag)
+		if err == nil || !retryable || attempt >= c.retry.Attempts || ctx.Err() != nil {
+			return release, newETag, err
+		}
+
+		wait := c.retry.JitteredBackoff(attempt)
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) && rateLimitErr.Wait > 0 {
+			// 限流时等到重置后再重试，重置时间超过等待上限时直接返回
+			if rateLimitErr.Wait > c.retry.MaxBackoff {
+				return nil, "", err
+			}
+			wait = rateLimitErr.Wait
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			c.logger.Debug("Not retrying release lookup past the context deadline",
+				zap.String("url", apiURL),
+				zap.Duration("backoff", wait))
+			return nil, "", err
+		}
+		c.logger.Warn("Release lookup failed, retrying",
+			zap.String("url", apiURL),
+			zap.Int("attempt", attempt),
+			zap.Int("attempts", c.retry.Attempts),
+			zap.Duration("backoff", wait),
+			zap.Error(err))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, "", err
+		case <-timer.C:
+		}
+	}
+}
+
+// getLatestRelease makes a single latest release request, reporting whether a failure
+// is transient: a connection error, a 5xx status, 429 Too Many Requests or a rate limit
+// that resets later
+func (c *DefaultGitHubClient) getLatestRelease(ctx context.Context, apiURL, etag string) (*GitHubRelease, string, bool, error) {
 	c.logger.Debug("Making API request",
 		zap.String("url", apiURL),
 		zap.String("version", c.version),
@@ -53,39 +130,55 @@ func (c *DefaultGitHubClient) GetLatestRelease(ctx context.Context, apiURL strin
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "", false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	userAgent := "Aqua-Speed-Updater/" + c.version
 	req.Header.Set("User-Agent", userAgent)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	authenticated := utils.AuthorizeGitHubRequest(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch latest version: %w", err)
+		return nil, "", ctx.Err() == nil, fmt.Errorf("failed to fetch latest version: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusForbidden {
-		resetTime := resp.Header.Get("X-RateLimit-Reset")
-		return nil, fmt.Errorf("rate limit exceeded, reset at: %s", resetTime)
+	c.logRateLimit(resp, authenticated)
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return nil, etag, false, ErrNotModified
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		rateLimitErr := newRateLimitError(resp, c.logger)
+		rateLimitErr.Authenticated = authenticated
+		// 带有重置时间的 403 同样是限流，可以在重置后重试
+		return nil, "", resp.StatusCode == http.StatusTooManyRequests || rateLimitErr.Wait > 0, rateLimitErr
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, "", resp.StatusCode >= http.StatusInternalServerError, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	body, err := utils.ResponseBody(resp)
+	if err != nil {
+		return nil, "", false, err
 	}
+	defer body.Close()
 
 	var release GitHubRelease
-	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to decode GitHub response: %w", err)
+	if err := json.NewDecoder(io.LimitReader(body, 10<<20)).Decode(&release); err != nil {
+		return nil, "", false, fmt.Errorf("failed to decode GitHub response: %w", err)
 	}
 
 	c.logger.Debug("Received release info",
 		zap.String("tag", release.TagName),
 		zap.Int("assets", len(release.Assets)))
 
-	return &release, nil
+	return &release, resp.Header.Get("ETag"), false, nil
 }
 
 // GetRawContent fetches raw content from GitHub.
@@ -97,19 +190,27 @@ func (c *DefaultGitHubClient) GetRawContent(ctx context.Context, rawURL string)
 
 	userAgent := "Aqua-Speed-Updater/" + c.version
 	req.Header.Set("User-Agent", userAgent)
+	authenticated := utils.AuthorizeGitHubRequest(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch raw content: %w", err)
 	}
 	defer resp.Body.Close()
+	c.logRateLimit(resp, authenticated)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
 		return nil, fmt.Errorf("GitHub returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20)) // 限制为 10MB
+	body, err := utils.ResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, 10<<20)) // 限制为 10MB
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
@@ -124,3 +225,16 @@ func (c *DefaultGitHubClient) GetDefaultConfig(ctx context.Context, owner, repo
 
 	return c.GetRawContent(ctx, rawURL)
 }
+
+// logRateLimit logs the GitHub rate limit left after resp, if the response reports it
+func (c *DefaultGitHubClient) logRateLimit(resp *http.Response, authenticated bool) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return
+	}
+	c.logger.Debug("GitHub rate limit",
+		zap.String("url", resp.Request.URL.Redacted()),
+		zap.String("remaining", remaining),
+		zap.String("limit", resp.Header.Get("X-RateLimit-Limit")),
+		zap.Bool("authenticated", authenticated))
+}
@@ -0,0 +1,44 @@
+package updater
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"aqua-speed-tools/internal/config"
+)
+
+// checkDownloadHost returns ErrHostNotAllowed if downloads may not connect to host:
+// it is denied, or an allow list is configured and neither it nor a configured
+// mirror's host matches it.
+func checkDownloadHost(host string) error {
+	cfg := config.Get()
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, denied := range cfg.Download.DeniedHosts {
+		if hostMatches(host, denied) {
+			return fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, host)
+		}
+	}
+
+	if len(cfg.Download.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, allowed := range cfg.Download.AllowedHosts {
+		if hostMatches(host, allowed) {
+			return nil
+		}
+	}
+	for _, mirror := range cfg.GithubRawJsdelivrSet {
+		if parsed, err := url.Parse(mirror); err == nil && hostMatches(host, parsed.Hostname()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in the allowed hosts", ErrHostNotAllowed, host)
+}
+
+// hostMatches reports whether host is pattern or one of its subdomains
+func hostMatches(host, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	return pattern != "" && (host == pattern || strings.HasSuffix(host, "."+pattern))
+}
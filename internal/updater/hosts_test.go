@@ -0,0 +1,106 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aqua-speed-tools/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// setDownloadConfig configures download for the duration of the test
+func setDownloadConfig(t *testing.T, download config.DownloadConfig) {
+	t.Helper()
+	previous := config.Get().Download
+	config.Update(func(cfg *config.Config) { cfg.Download = download })
+	t.Cleanup(func() {
+		config.Update(func(cfg *config.Config) { cfg.Download = previous })
+	})
+}
+
+func TestDownloadRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/evil":
+			http.Redirect(w, r, "http://evil.example.com/aqua-speed.tar.gz", http.StatusFound)
+		case "/evil-subdomain":
+			http.Redirect(w, r, "http://cdn.evil.example.com/aqua-speed.tar.gz", http.StatusFound)
+		case "/local":
+			http.Redirect(w, r, "/aqua-speed.tar.gz", http.StatusFound)
+		default:
+			fmt.Fprint(w, "archive")
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name     string
+		download config.DownloadConfig
+		path     string
+		wantErr  bool
+		denied   bool // The error is ErrHostNotAllowed
+	}{
+		{
+			name:     "redirect to denied host",
+			download: config.DownloadConfig{MaxRedirects: 10, DeniedHosts: []string{"evil.example.com"}},
+			path:     "/evil",
+			wantErr:  true,
+			denied:   true,
+		},
+		{
+			name:     "redirect to subdomain of denied host",
+			download: config.DownloadConfig{MaxRedirects: 10, DeniedHosts: []string{"evil.example.com"}},
+			path:     "/evil-subdomain",
+			wantErr:  true,
+			denied:   true,
+		},
+		{
+			name:     "redirect to host missing from the allow list",
+			download: config.DownloadConfig{MaxRedirects: 10, AllowedHosts: []string{"127.0.0.1"}},
+			path:     "/evil",
+			wantErr:  true,
+			denied:   true,
+		},
+		{
+			name:     "redirect to allowed host",
+			download: config.DownloadConfig{MaxRedirects: 10, AllowedHosts: []string{"127.0.0.1"}},
+			path:     "/local",
+		},
+		{
+			name:     "redirects disabled",
+			download: config.DownloadConfig{MaxRedirects: 0},
+			path:     "/local",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setDownloadConfig(t, tt.download)
+			client := srv.Client()
+			client.CheckRedirect = checkDownloadRedirect(zap.NewNop())
+			u := &Updater{logger: zap.NewNop(), client: client}
+
+			data, err := u.downloadWithProgress(srv.URL+tt.path, "")
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("downloadWithProgress() error = %v", err)
+				}
+				if string(data) != "archive" {
+					t.Errorf("downloadWithProgress() = %q, want archive", data)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("downloadWithProgress() = %q, want error", data)
+			}
+			if tt.denied && !errors.Is(err, ErrHostNotAllowed) {
+				t.Errorf("downloadWithProgress() error = %v, want %v", err, ErrHostNotAllowed)
+			}
+		})
+	}
+}
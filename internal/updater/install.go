@@ -0,0 +1,61 @@
+package updater
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"aqua-speed-tools/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// InstallFromArchive installs aqua-speed from a local release archive instead of
+// downloading it, verifying the binaries the same way as a regular update and
+// recording version in version.txt. If version is empty it is parsed from the
+// archive file name. Archives built for another platform are rejected.
+func (u *Updater) InstallFromArchive(archivePath, version string) error {
+	if !FileExists(archivePath) {
+		return WrapError("open archive", fmt.Errorf("file not found: %s", archivePath))
+	}
+
+	osName, arch, parsedVersion, ok := ParseAssetName(config.Get().Binary.AssetTemplate, filepath.Base(archivePath))
+	if ok {
+		hostArch := NormalizeArch(runtime.GOARCH)
+		if osName != runtime.GOOS || NormalizeArch(arch) != hostArch {
+			return WrapError("check platform", fmt.Errorf("archive is built for %s-%s, but this host is %s-%s", osName, arch, runtime.GOOS, hostArch))
+		}
+	}
+
+	if version == "" {
+		if parsedVersion == "" {
+			return WrapError("parse version", fmt.Errorf("cannot determine version from archive name %q", filepath.Base(archivePath)))
+		}
+		version = parsedVersion
+	}
+	installVersion, err := ParseVersion(version)
+	if err != nil {
+		return WrapError("parse version", err)
+	}
+
+	u.logger.Info("Installing from local archive",
+		zap.String("archive", archivePath),
+		zap.String("version", installVersion.String()))
+
+	if err := u.checkInstallDirWritable(); err != nil {
+		u.logger.Error("Installation directory is not usable", zap.Error(err))
+		return err
+	}
+
+	defer u.beginProgress("Installing aqua-speed")()
+	u.overall.complete(PhaseDownload)
+
+	if err := u.installArchive(archivePath, installVersion); err != nil {
+		u.logger.Error("Install failed", zap.Error(err))
+		return err
+	}
+
+	u.Version = installVersion
+	u.logger.Info("Install completed successfully", zap.String("version", installVersion.String()))
+	return nil
+}
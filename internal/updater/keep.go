@@ -0,0 +1,107 @@
+package updater
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"aqua-speed-tools/internal/config"
+
+	"github.com/blang/semver/v4"
+	"go.uber.org/zap"
+)
+
+// SetKeepDownloadDir keeps verified release archives in dir and reuses them for a
+// later update to the same version. Empty discards archives after installing.
+func (u *Updater) SetKeepDownloadDir(dir string) {
+	u.keepDownloadDir = dir
+}
+
+// installKeptArchive installs the archive kept by an earlier update, if there is one.
+// The archive goes through the same checksum verification as a fresh download; if
+// that fails it is removed so the caller downloads it again.
+func (u *Updater) installKeptArchive(assetName string, latestVersion semver.Version) bool {
+	if u.keepDownloadDir == "" {
+		return false
+	}
+	keptPath := filepath.Join(u.keepDownloadDir, assetName)
+	if !FileExists(keptPath) {
+		return false
+	}
+
+	u.logger.Info("Reusing kept download", zap.String("path", keptPath))
+	u.overall.complete(PhaseDownload)
+	if err := u.installArchive(keptPath, latestVersion); err != nil {
+		u.logger.Warn("Kept download failed verification, downloading again",
+			zap.String("path", keptPath),
+			zap.Error(err))
+		os.Remove(keptPath)
+		return false
+	}
+	return true
+}
+
+// keepArchive copies a verified archive into the keep-download directory and removes
+// archives kept for other versions of this platform, which can no longer be reused.
+func (u *Updater) keepArchive(archivePath, assetName string) error {
+	if err := os.MkdirAll(u.keepDownloadDir, 0755); err != nil {
+		return fmt.Errorf("failed to create keep-download directory: %w", err)
+	}
+
+	keptPath := filepath.Join(u.keepDownloadDir, assetName)
+	if err := copyFile(archivePath, keptPath); err != nil {
+		return fmt.Errorf("failed to keep download: %w", err)
+	}
+	u.logger.Info("Kept downloaded archive", zap.String("path", keptPath))
+
+	entries, err := os.ReadDir(u.keepDownloadDir)
+	if err != nil {
+		return fmt.Errorf("failed to read keep-download directory: %w", err)
+	}
+	hostArch := NormalizeArch(runtime.GOARCH)
+	template := config.Get().Binary.AssetTemplate
+	for _, entry := range entries {
+		name := entry.Name()
+		osName, arch, _, ok := ParseAssetName(template, name)
+		if !ok || name == assetName || osName != runtime.GOOS || NormalizeArch(arch) != hostArch {
+			continue
+		}
+		if err := os.Remove(filepath.Join(u.keepDownloadDir, name)); err != nil {
+			u.logger.Warn("Failed to remove outdated kept download", zap.String("file", name), zap.Error(err))
+			continue
+		}
+		u.logger.Debug("Removed outdated kept download", zap.String("file", name))
+	}
+	return nil
+}
+
+// copyFile copies src to dst through a temporary file, so dst is never left half written
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmpPath := dst + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
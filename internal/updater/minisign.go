@@ -0,0 +1,91 @@
+package updater
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// minisign signature algorithms
+const (
+	minisignAlgLegacy    = "Ed" // Ed25519 over the file itself
+	minisignAlgPrehashed = "ED" // Ed25519 over the BLAKE2b-512 digest of the file
+)
+
+// MinisignPublicKey is a minisign Ed25519 public key
+type MinisignPublicKey struct {
+	KeyID [8]byte
+	Key   ed25519.PublicKey
+}
+
+// ParseMinisignPublicKey parses a minisign public key, either the base64 key line
+// alone or the whole content of a minisign.pub file.
+func ParseMinisignPublicKey(text string) (*MinisignPublicKey, error) {
+	line := lastNonCommentLine(text)
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minisign public key encoding: %w", err)
+	}
+	if len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != minisignAlgLegacy {
+		return nil, fmt.Errorf("invalid minisign public key")
+	}
+
+	key := &MinisignPublicKey{Key: ed25519.PublicKey(raw[10:])}
+	copy(key.KeyID[:], raw[2:10])
+	return key, nil
+}
+
+// VerifyMinisign verifies a minisign signature file over message, including its trusted comment.
+func (k *MinisignPublicKey) VerifyMinisign(message []byte, signature string) error {
+	lines := strings.Split(strings.ReplaceAll(signature, "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("invalid minisign signature format")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	algorithm, keyID, sig := string(raw[:2]), raw[2:10], raw[10:]
+	if !bytes.Equal(keyID, k.KeyID[:]) {
+		return fmt.Errorf("signature key ID %X does not match public key ID %X", keyID, k.KeyID)
+	}
+
+	switch algorithm {
+	case minisignAlgLegacy:
+	case minisignAlgPrehashed:
+		digest := blake2b512(message)
+		message = digest[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", algorithm)
+	}
+	if !ed25519.Verify(k.Key, message, sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+
+	// The global signature covers the signature and the trusted comment
+	trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign global signature")
+	}
+	if !ed25519.Verify(k.Key, append(bytes.Clone(sig), trustedComment...), globalSig) {
+		return fmt.Errorf("trusted comment verification failed")
+	}
+
+	return nil
+}
+
+// lastNonCommentLine returns the last non-empty line that is not an untrusted comment
+func lastNonCommentLine(text string) string {
+	var last string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			last = line
+		}
+	}
+	return last
+}
@@ -0,0 +1,74 @@
+package updater
+
+import (
+	"fmt"
+	"strings"
+
+	"aqua-speed-tools/internal/utils"
+
+	"github.com/blang/semver/v4"
+)
+
+// Release notes longer than these limits are truncated when shown after an update
+const (
+	maxNotesLines = 15
+	maxNotesRunes = 1200
+)
+
+// SetQuiet suppresses the version change summary printed after an update.
+func (u *Updater) SetQuiet(quiet bool) {
+	u.quiet = quiet
+}
+
+// SetShowNotes makes the summary printed after an update include the release notes.
+func (u *Updater) SetShowNotes(show bool) {
+	u.showNotes = show
+}
+
+// printUpdateSummary prints the old and new version of an applied update and,
+// if requested, the truncated release notes
+func (u *Updater) printUpdateSummary(from, to semver.Version) {
+	if u.quiet {
+		return
+	}
+
+	_, name := splitRepo(u.Repo)
+	fmt.Printf("%s 已更新: %s → %s\n", name, utils.Red.Sprint(from.String()), utils.Green.Sprint(to.String()))
+	if !u.showNotes {
+		return
+	}
+
+	notes, truncated := truncateNotes(u.releaseNotes)
+	if notes == "" {
+		utils.Yellow.Println("该版本没有更新说明")
+		return
+	}
+	utils.Cyan.Println("更新说明:")
+	for _, line := range strings.Split(notes, "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	if truncated {
+		if u.releaseURL != "" {
+			fmt.Printf("  ... 完整说明见 %s\n", u.releaseURL)
+		} else {
+			fmt.Println("  ...")
+		}
+	}
+}
+
+// truncateNotes trims release notes to maxNotesLines lines and maxNotesRunes
+// characters, reporting whether anything was cut
+func truncateNotes(notes string) (string, bool) {
+	notes = strings.TrimSpace(strings.ReplaceAll(notes, "\r\n", "\n"))
+	truncated := false
+
+	if lines := strings.Split(notes, "\n"); len(lines) > maxNotesLines {
+		notes = strings.Join(lines[:maxNotesLines], "\n")
+		truncated = true
+	}
+	if runes := []rune(notes); len(runes) > maxNotesRunes {
+		notes = string(runes[:maxNotesRunes])
+		truncated = true
+	}
+	return strings.TrimRight(notes, " \n"), truncated
+}
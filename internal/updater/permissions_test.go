@@ -0,0 +1,87 @@
+package updater
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"go.uber.org/zap"
+)
+
+// newInstallDirUpdater returns an updater at version 1.0.0 installing into installDir,
+// whose latest release 2.0.0 is served by a server counting the requests it gets
+func newInstallDirUpdater(t *testing.T, installDir string) (*Updater, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("archive"))
+	}))
+	t.Cleanup(srv.Close)
+
+	const template = "aqua-speed-{os}-{arch}_v{version}"
+	name := FormatCompressedName(template, runtime.GOOS, runtime.GOARCH, "2.0.0")
+	release := GitHubRelease{TagName: "v2.0.0"}
+	release.Assets = append(release.Assets, struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+		Size               int64  `json:"size"`
+	}{Name: name, BrowserDownloadURL: srv.URL + "/" + name})
+
+	return &Updater{
+		Version:       semver.MustParse("1.0.0"),
+		InstallDir:    installDir,
+		Repo:          "alice39s/aqua-speed",
+		AssetTemplate: template,
+		logger:        zap.NewNop(),
+		client:        srv.Client(),
+		githubClient:  &releaseClient{release: release},
+	}, &requests
+}
+
+func TestCheckAndUpdateReadOnlyInstallDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permission bits are not enforced on Windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+
+	installDir := t.TempDir()
+	if err := os.Chmod(installDir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(installDir, 0755) })
+
+	u, requests := newInstallDirUpdater(t, installDir)
+	err := u.CheckAndUpdate()
+	var dirErr *InstallDirError
+	if !errors.As(err, &dirErr) || !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("CheckAndUpdate() error = %v, want an InstallDirError for a permission error", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("CheckAndUpdate() sent %d requests, want none before the install dir check", n)
+	}
+}
+
+func TestCheckAndUpdateUnusableInstallDir(t *testing.T) {
+	// A file in place of the installation directory fails even when running as root
+	installDir := filepath.Join(t.TempDir(), "aqua-speed")
+	if err := os.WriteFile(installDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	u, requests := newInstallDirUpdater(t, installDir)
+	if err := u.CheckAndUpdate(); err == nil {
+		t.Fatal("CheckAndUpdate() error = nil, want the install dir to be rejected")
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("CheckAndUpdate() sent %d requests, want none before the install dir check", n)
+	}
+}
@@ -0,0 +1,114 @@
+package updater
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// UpdatePhase identifies a stage of an update.
+type UpdatePhase string
+
+const (
+	PhaseDownload UpdatePhase = "download"
+	PhaseExtract  UpdatePhase = "extract"
+	PhaseVerify   UpdatePhase = "verify"
+)
+
+// ProgressFunc receives the overall update progress as a percentage from 0 to 100.
+type ProgressFunc func(phase UpdatePhase, percent float64)
+
+// phaseRanges maps each phase onto its share of the overall progress.
+// Downloading dominates the time spent, so it gets the largest share.
+var phaseRanges = map[UpdatePhase][2]float64{
+	PhaseDownload: {0, 80},
+	PhaseExtract:  {80, 95},
+	PhaseVerify:   {95, 100},
+}
+
+// overallProgress turns per-phase progress into a single monotonic percentage.
+type overallProgress struct {
+	mu   sync.Mutex
+	fn   ProgressFunc
+	last float64
+}
+
+func newOverallProgress(fn ProgressFunc) *overallProgress {
+	return &overallProgress{fn: fn}
+}
+
+// update reports current out of total units done within phase. A non-positive
+// total reports the start of the phase.
+func (p *overallProgress) update(phase UpdatePhase, current, total int64) {
+	fraction := 0.0
+	if total > 0 {
+		fraction = min(float64(current)/float64(total), 1)
+	}
+	r := phaseRanges[phase]
+	p.report(phase, r[0]+(r[1]-r[0])*fraction)
+}
+
+// complete reports that phase has finished.
+func (p *overallProgress) complete(phase UpdatePhase) {
+	p.report(phase, phaseRanges[phase][1])
+}
+
+func (p *overallProgress) report(phase UpdatePhase, percent float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Retries and fallbacks restart a phase, but the overall progress never goes back
+	if percent <= p.last && percent != 0 {
+		return
+	}
+	p.last = max(p.last, percent)
+	if p.fn != nil {
+		p.fn(phase, p.last)
+	}
+}
+
+// minSizeableEntry is the size from which an archive entry gets noticeable extraction progress
+const minSizeableEntry = 64 * 1024
+
+// extractBar renders the extraction of every entry of an archive as a single progress
+// bar over their total size, labelled with the entry being extracted.
+type extractBar struct {
+	bar     *progressbar.ProgressBar
+	current string // Entry being extracted
+	done    int64  // Bytes of the entries extracted before current
+	size    int64  // Size of current
+}
+
+func newExtractBar(total int64) *extractBar {
+	return &extractBar{bar: progressbar.DefaultBytes(total, "Extracting")}
+}
+
+// update reports current out of total bytes of entry name extracted. Entries skipped
+// without being read are never reported, finish fills the bar up for them.
+func (b *extractBar) update(name string, current, total int64) {
+	if name != b.current {
+		b.done += b.size
+		b.current, b.size = name, 0
+		b.bar.Describe(fmt.Sprintf("Extracting %s", name))
+	}
+	b.size = total
+	b.bar.Set64(b.done + current)
+}
+
+func (b *extractBar) finish() {
+	b.bar.Finish()
+}
+
+// newProgressBarFunc returns a ProgressFunc rendering a single terminal progress bar.
+func newProgressBarFunc(description string) ProgressFunc {
+	bar := progressbar.NewOptions(100,
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionShowCount(),
+		progressbar.OptionClearOnFinish(),
+	)
+	return func(phase UpdatePhase, percent float64) {
+		bar.Describe(description + " (" + string(phase) + ")")
+		bar.Set(int(percent))
+	}
+}
@@ -0,0 +1,60 @@
+package updater
+
+import (
+	"aqua-speed-tools/internal/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// clockSkewThreshold is the difference between the local and server clocks worth logging
+const clockSkewThreshold = 30 * time.Second
+
+// RateLimitError is returned when the GitHub API rate limit is exceeded.
+type RateLimitError struct {
+	Reset time.Time     // When the rate limit resets, in server time
+	Wait  time.Duration // How long to wait until the reset, independent of the local clock
+
+	Authenticated bool // Whether the request carried a GitHub token
+}
+
+func (e *RateLimitError) Error() string {
+	msg := "rate limit exceeded"
+	if !e.Reset.IsZero() {
+		msg = fmt.Sprintf("rate limit exceeded, resets in %s (at %s)", e.Wait.Round(time.Second), e.Reset.Format(time.RFC3339))
+	}
+	if !e.Authenticated {
+		msg += "; set --github-token or " + utils.GitHubTokenEnv + " to raise the limit from 60 to 5000 requests per hour"
+	}
+	return msg
+}
+
+// newRateLimitError builds a RateLimitError from the rate limit headers of resp.
+// The wait is measured against the server's Date header rather than the local clock,
+// so a skewed local clock does not shorten or lengthen it.
+func newRateLimitError(resp *http.Response, logger *zap.Logger) *RateLimitError {
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return &RateLimitError{}
+	}
+	resetTime := time.Unix(reset, 0)
+
+	now := time.Now()
+	reference := now
+	if serverDate, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		reference = serverDate
+		if skew := now.Sub(serverDate); skew > clockSkewThreshold || skew < -clockSkewThreshold {
+			logger.Warn("Local clock differs from the server clock",
+				zap.Duration("skew", skew.Round(time.Second)),
+				zap.Time("serverTime", serverDate))
+		}
+	}
+
+	return &RateLimitError{
+		Reset: resetTime,
+		Wait:  max(resetTime.Sub(reference), 0),
+	}
+}
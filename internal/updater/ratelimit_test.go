@@ -0,0 +1,116 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aqua-speed-tools/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// rateLimitResponse returns a rate limited response whose server clock reads serverNow
+func rateLimitResponse(serverNow time.Time, resetIn time.Duration) *http.Response {
+	header := make(http.Header)
+	header.Set("Date", serverNow.UTC().Format(http.TimeFormat))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(serverNow.Add(resetIn).Unix(), 10))
+	return &http.Response{StatusCode: http.StatusForbidden, Header: header}
+}
+
+func TestNewRateLimitErrorClockSkew(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name string
+		skew time.Duration // Local clock minus server clock
+	}{
+		{name: "in sync", skew: 0},
+		{name: "local clock ahead", skew: time.Hour},
+		{name: "local clock behind", skew: -time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := rateLimitResponse(now.Add(-tt.skew), 90*time.Second)
+			err := newRateLimitError(resp, zap.NewNop())
+
+			// The wait must not include the skew, within the second resolution of the Date header
+			if diff := err.Wait - 90*time.Second; diff < -time.Second || diff > time.Second {
+				t.Errorf("Wait = %s, want 90s", err.Wait)
+			}
+		})
+	}
+}
+
+func TestNewRateLimitErrorWithoutDate(t *testing.T) {
+	resp := rateLimitResponse(time.Now(), time.Minute)
+	resp.Header.Del("Date")
+
+	err := newRateLimitError(resp, zap.NewNop())
+	if err.Wait < 58*time.Second || err.Wait > time.Minute {
+		t.Errorf("Wait = %s, want about 1m measured against the local clock", err.Wait)
+	}
+}
+
+func TestGetLatestReleaseWaitsForRateLimitReset(t *testing.T) {
+	var requests atomic.Int32
+	var retriedAt atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// The server clock is an hour behind the local one and resets the limit in 1s
+			serverNow := time.Now().Add(-time.Hour)
+			w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(serverNow.Add(time.Second).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		retriedAt.Store(time.Now().UnixNano())
+		fmt.Fprint(w, `{"tag_name": "v1.2.3"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewDefaultGitHubClient(srv.Client(), zap.NewNop(), "1.0.0", nil)
+	client.SetRetryPolicy(utils.RetryPolicy{Attempts: 2, BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Second})
+
+	start := time.Now()
+	release, err := client.GetLatestRelease(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("GetLatestRelease() error = %v", err)
+	}
+	if release.TagName != "v1.2.3" {
+		t.Errorf("TagName = %s, want v1.2.3", release.TagName)
+	}
+	// Waited for the reset, neither the base backoff nor an hour more for the skew
+	if waited := time.Unix(0, retriedAt.Load()).Sub(start); waited < 100*time.Millisecond || waited > 3*time.Second {
+		t.Errorf("retried after %s, want about 1s", waited)
+	}
+}
+
+func TestGetLatestReleaseRateLimitResetTooFar(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewDefaultGitHubClient(srv.Client(), zap.NewNop(), "1.0.0", nil)
+	client.SetRetryPolicy(utils.RetryPolicy{Attempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Second})
+
+	_, err := client.GetLatestRelease(context.Background(), srv.URL)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("GetLatestRelease() error = %v, want RateLimitError", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("made %d requests, want 1 without retrying", n)
+	}
+}
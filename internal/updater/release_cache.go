@@ -0,0 +1,109 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"aqua-speed-tools/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// releaseCacheFile is the file in the install directory caching the latest releases
+const releaseCacheFile = "release-cache.json"
+
+// DefaultReleaseCacheTTL is how long a cached latest release is used without asking GitHub again
+const DefaultReleaseCacheTTL = time.Hour
+
+// releaseCacheEntry is a latest release fetched from an API URL
+type releaseCacheEntry struct {
+	FetchedAt time.Time     `json:"fetchedAt"`
+	ETag      string        `json:"etag,omitempty"` // Sent as If-None-Match when the entry expires
+	Release   GitHubRelease `json:"release"`
+}
+
+// SetReleaseCacheTTL sets how long the latest release is reused from the cache in the
+// install directory before GitHub is asked again. 0 disables the cache.
+func (u *Updater) SetReleaseCacheTTL(ttl time.Duration) {
+	u.releaseCacheTTL = max(ttl, 0)
+}
+
+// fetchLatestRelease returns the latest release at apiURL, from the release cache while
+// it is younger than the cache TTL. An expired entry is revalidated with its ETag, so an
+// unchanged release is not downloaded again.
+func (u *Updater) fetchLatestRelease(ctx context.Context, apiURL string) (*GitHubRelease, error) {
+	if u.releaseCacheTTL <= 0 {
+		return u.githubClient.GetLatestRelease(ctx, apiURL)
+	}
+
+	cachePath := filepath.Join(u.InstallDir, releaseCacheFile)
+	cache, err := loadReleaseCache(cachePath)
+	if err != nil {
+		u.logger.Debug("Ignoring unreadable release cache", zap.String("path", cachePath), zap.Error(err))
+		cache = make(map[string]releaseCacheEntry)
+	}
+
+	entry, cached := cache[apiURL]
+	if age := time.Since(entry.FetchedAt); cached && age >= 0 && age < u.releaseCacheTTL {
+		u.logger.Debug("Using cached latest release",
+			zap.String("apiURL", apiURL),
+			zap.String("tag", entry.Release.TagName),
+			zap.Duration("age", age.Round(time.Second)))
+		utils.Explain(utils.ExplainUpdate, "使用 %s 前缓存的最新版本 %s (缓存有效期 %s)", age.Round(time.Second), entry.Release.TagName, u.releaseCacheTTL)
+		return &entry.Release, nil
+	}
+
+	var release *GitHubRelease
+	var etag string
+	if client, ok := u.githubClient.(*DefaultGitHubClient); ok {
+		release, etag, err = client.GetLatestReleaseIfChanged(ctx, apiURL, entry.ETag)
+	} else {
+		release, err = u.githubClient.GetLatestRelease(ctx, apiURL)
+	}
+	if errors.Is(err, ErrNotModified) {
+		u.logger.Debug("Cached latest release is unchanged", zap.String("apiURL", apiURL), zap.String("tag", entry.Release.TagName))
+		release, etag, err = &entry.Release, entry.ETag, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cache[apiURL] = releaseCacheEntry{FetchedAt: time.Now(), ETag: etag, Release: *release}
+	if err := saveReleaseCache(cachePath, cache); err != nil {
+		u.logger.Debug("Failed to save release cache", zap.String("path", cachePath), zap.Error(err))
+	}
+	return release, nil
+}
+
+// loadReleaseCache reads the release cache at path, keyed by API URL. A missing file is an empty cache.
+func loadReleaseCache(path string) (map[string]releaseCacheEntry, error) {
+	cache := make(map[string]releaseCacheEntry)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("invalid release cache: %w", err)
+	}
+	return cache, nil
+}
+
+// saveReleaseCache writes the release cache to path
+func saveReleaseCache(path string, cache map[string]releaseCacheEntry) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,107 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ErrNoBackup is returned by Rollback when no previous binary was kept by an update
+var ErrNoBackup = errors.New("no previous binary to roll back to")
+
+// backupVersionInfo copies version.txt next to it with backupSuffix, recording the version
+// and checksum of the binary backed up before an update. A stale copy is removed when
+// there is no version.txt, so the backup never describes another binary.
+func (u *Updater) backupVersionInfo() error {
+	versionFile := filepath.Join(u.InstallDir, "version.txt")
+	if !FileExists(versionFile) {
+		if err := os.Remove(versionFile + backupSuffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return copyFile(versionFile, versionFile+backupSuffix)
+}
+
+// BackupVersion returns the version recorded for the binary kept by the last update,
+// empty if it is unknown. It returns ErrNoBackup when there is no such binary.
+func (u *Updater) BackupVersion() (string, error) {
+	binaryPath := filepath.Join(u.InstallDir, "bin", u.BinaryName)
+	if !FileExists(binaryPath + backupSuffix) {
+		return "", ErrNoBackup
+	}
+	content, err := os.ReadFile(filepath.Join(u.InstallDir, "version.txt") + backupSuffix)
+	if err != nil {
+		return "", nil
+	}
+	if fields := strings.Fields(string(content)); len(fields) > 0 {
+		return fields[0], nil
+	}
+	return "", nil
+}
+
+// Rollback swaps the binary kept by the last update back into place, together with its
+// version.txt. The replaced binary becomes the backup, so rolling back again undoes it.
+// Only the main binary is kept; extra binaries are left as they are.
+func (u *Updater) Rollback() error {
+	binaryPath := filepath.Join(u.InstallDir, "bin", u.BinaryName)
+	versionFile := filepath.Join(u.InstallDir, "version.txt")
+	if !FileExists(binaryPath + backupSuffix) {
+		return ErrNoBackup
+	}
+
+	if err := swapWithBackup(binaryPath); err != nil {
+		return WrapError("restore binary file", err)
+	}
+	if FileExists(versionFile+backupSuffix) || FileExists(versionFile) {
+		if err := swapWithBackup(versionFile); err != nil {
+			// 版本信息与程序需保持一致，恢复失败时换回原程序
+			if undoErr := swapWithBackup(binaryPath); undoErr != nil {
+				u.logger.Error("Failed to undo binary rollback", zap.String("binary", binaryPath), zap.Error(undoErr))
+			}
+			return WrapError("restore version information", err)
+		}
+	}
+
+	if reported, err := validateBinary(binaryPath); err != nil {
+		u.logger.Warn("Restored binary failed to run", zap.String("binary", binaryPath), zap.Error(err))
+	} else {
+		u.logger.Debug("Validated restored binary",
+			zap.String("binary", binaryPath),
+			zap.String("reported", reported.String()))
+	}
+	u.logger.Debug("Rolled back to previous binary", zap.String("binary", binaryPath))
+	return nil
+}
+
+// swapWithBackup exchanges path and its backup. Either of them may be missing, in
+// which case the other one is simply moved.
+func swapWithBackup(path string) error {
+	backupPath := path + backupSuffix
+	tmpPath := path + ".rollback"
+
+	hasCurrent := FileExists(path)
+	if hasCurrent {
+		if err := os.Rename(path, tmpPath); err != nil {
+			return err
+		}
+	}
+	if FileExists(backupPath) {
+		if err := os.Rename(backupPath, path); err != nil {
+			if hasCurrent {
+				os.Rename(tmpPath, path)
+			}
+			return err
+		}
+	}
+	if hasCurrent {
+		if err := os.Rename(tmpPath, backupPath); err != nil {
+			return fmt.Errorf("failed to keep replaced file as backup: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"aqua-speed-tools/internal/config"
+	"aqua-speed-tools/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// ToolsAssetTemplate matches the release assets of aqua-speed-tools, which are bare
+// executables named after GOOS and GOARCH rather than archives
+const ToolsAssetTemplate = "aqua-speed-tools-{os}-{goarch}"
+
+// appendedChecksumMarker precedes the SHA256 checksum the release workflow appends to
+// every aqua-speed-tools executable. The checksum covers the executable and the marker.
+const appendedChecksumMarker = "\n=== SHA256 ===\n"
+
+// smokeTestTimeout bounds the trial run of a downloaded executable before it replaces the running one
+const smokeTestTimeout = 30 * time.Second
+
+// NewSelfUpdater creates an Updater for the running aqua-speed-tools executable,
+// fetching releases from the tools repository instead of the aqua-speed one.
+func NewSelfUpdater(currentVersion string, urls *utils.GitHubURLs) (*Updater, error) {
+	u, err := New(currentVersion, urls)
+	if err != nil {
+		return nil, err
+	}
+	u.Repo = config.DefaultGithubToolsRepo
+	u.AssetTemplate = ToolsAssetTemplate
+	u.BinaryName = FormatBinaryName("aqua-speed-tools", runtime.GOOS, runtime.GOARCH)
+	u.CompressedName = ""
+	return u, nil
+}
+
+// SelfUpdate replaces the running executable with the latest release if it is newer.
+// It returns the path of the executable and whether it was replaced.
+func (u *Updater) SelfUpdate() (string, bool, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", false, WrapError("locate executable", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	// 下载前确认可以替换可执行文件
+	if err := probeWritable(filepath.Dir(exe)); err != nil {
+		return exe, false, fmt.Errorf("cannot replace %s: directory is not writable (%v); %s", exe, err, elevateHint())
+	}
+
+	latestVersion, downloadURL, assetName, err := u.GetLatestVersion()
+	if err != nil {
+		return exe, false, err
+	}
+	if latestVersion.LTE(u.Version) {
+		utils.Explain(utils.ExplainUpdate, "未更新 aqua-speed-tools：当前版本 %s 不低于最新版本 %s", u.Version, latestVersion)
+		return exe, false, nil
+	}
+	u.logger.Info("Self update available",
+		zap.String("current", u.Version.String()),
+		zap.String("latest", latestVersion.String()))
+
+	data, err := u.downloadAsset(downloadURL, "")
+	if err != nil {
+		return exe, false, WrapError("download file", err)
+	}
+	binary, err := u.verifyToolsBinary(data, assetName)
+	if err != nil {
+		return exe, false, err
+	}
+
+	if err := replaceExecutable(exe, binary); err != nil {
+		utils.Explain(utils.ExplainUpdate, "aqua-speed-tools 从 %s 更新到 %s 失败: %v", u.Version, latestVersion, err)
+		return exe, false, WrapError("replace executable", err)
+	}
+
+	u.logger.Info("Self update completed", zap.String("executable", exe), zap.String("version", latestVersion.String()))
+	utils.Explain(utils.ExplainUpdate, "aqua-speed-tools 已从 %s 更新到 %s", u.Version, latestVersion)
+	u.printUpdateSummary(u.Version, latestVersion)
+	return exe, true, nil
+}
+
+// verifyToolsBinary verifies a downloaded aqua-speed-tools executable against the
+// checksum appended to it or, when there is none, the release checksum file, and
+// returns the executable without the appended checksums.
+func (u *Updater) verifyToolsBinary(data []byte, assetName string) ([]byte, error) {
+	if i := bytes.LastIndex(data, []byte(appendedChecksumMarker)); i >= 0 {
+		covered := data[:i+len(appendedChecksumMarker)]
+		checksum, _, _ := strings.Cut(string(data[len(covered):]), "\n")
+		if err := u.verifyChecksum(covered, strings.TrimSpace(checksum)); err != nil {
+			return nil, err
+		}
+		return data[:i], nil
+	}
+
+	checksums, err := u.fetchReleaseChecksums()
+	if err != nil {
+		return nil, WrapError("verify executable", err)
+	}
+	checksum, ok := checksums[assetName]
+	if !ok {
+		return nil, WrapError("verify executable", fmt.Errorf("%s has no entry for %s", releaseChecksumsAsset, assetName))
+	}
+	if err := u.verifyChecksum(data, checksum); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// replaceExecutable writes binary next to exe, checks that it runs and then swaps it
+// in place of exe, so that a failure at any point leaves exe untouched
+func replaceExecutable(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	// 临时文件与可执行文件位于同一目录，保证重命名是原子操作
+	ext := filepath.Ext(exe)
+	pattern := "." + strings.TrimSuffix(filepath.Base(exe), ext) + "-*" + ext
+	tmp, err := os.CreateTemp(filepath.Dir(exe), pattern)
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	if err := smokeTest(tmpPath); err != nil {
+		return fmt.Errorf("downloaded executable does not run: %w", err)
+	}
+	return swapExecutable(tmpPath, exe)
+}
+
+// smokeTest runs the version command of the executable at path
+func smokeTest(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), smokeTestTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, path, "version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build !unix && !windows
+
+package updater
+
+import (
+	"os"
+	"os/exec"
+)
+
+// swapExecutable renames newPath over exe
+func swapExecutable(newPath, exe string) error {
+	return os.Rename(newPath, exe)
+}
+
+// ExecReplaced runs exe with args and waits for it, as the current process cannot be
+// replaced on this platform. The exit status of exe is returned as an error.
+func ExecReplaced(exe string, args []string) error {
+	cmd := exec.Command(exe, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// CleanupReplacedExecutable removes what a previous self update left behind; nothing on this platform
+func CleanupReplacedExecutable() {}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package updater
+
+import (
+	"os"
+	"syscall"
+)
+
+// swapExecutable atomically renames newPath over exe, which a running program may do
+func swapExecutable(newPath, exe string) error {
+	return os.Rename(newPath, exe)
+}
+
+// ExecReplaced runs exe with args in place of the current process
+func ExecReplaced(exe string, args []string) error {
+	return syscall.Exec(exe, append([]string{exe}, args...), os.Environ())
+}
+
+// CleanupReplacedExecutable removes what a previous self update left behind; nothing on this platform
+func CleanupReplacedExecutable() {}
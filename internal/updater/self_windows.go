@@ -0,0 +1,50 @@
+//go:build windows
+
+package updater
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// swapExecutable replaces exe with newPath. A running executable cannot be overwritten
+// or deleted on Windows but it can be renamed, so it is moved aside first and removed
+// by CleanupReplacedExecutable on a later start.
+func swapExecutable(newPath, exe string) error {
+	old := replacedExecutablePath(exe)
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(newPath, exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	return nil
+}
+
+// ExecReplaced runs exe with args and waits for it, as Windows cannot replace the
+// current process. The exit status of exe is returned as an error.
+func ExecReplaced(exe string, args []string) error {
+	cmd := exec.Command(exe, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// CleanupReplacedExecutable removes the executable a previous self update moved aside
+func CleanupReplacedExecutable() {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	os.Remove(replacedExecutablePath(exe))
+}
+
+// replacedExecutablePath is where swapExecutable moves the running executable
+func replacedExecutablePath(exe string) string {
+	return exe + ".old"
+}
@@ -5,6 +5,7 @@ import (
 	"aqua-speed-tools/internal/utils"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,15 +23,65 @@ import (
 	"go.uber.org/zap"
 )
 
+// partialSuffix is appended to the temporary path of an asset while it is being downloaded
+const partialSuffix = ".part"
+
+// backupSuffix is appended to the paths of the previous binary and version.txt, kept for rollback
+const backupSuffix = ".bak"
+
 // Updater handles program update related operations.
 type Updater struct {
 	Version        semver.Version
 	InstallDir     string
 	BinaryName     string
 	CompressedName string
-	logger         *zap.Logger
-	client         *http.Client
-	githubClient   GitHubClient
+	// Repo is the GitHub repository ("owner/name") releases are fetched from, and
+	// AssetTemplate the name template of its release assets
+	Repo          string
+	AssetTemplate string
+	logger        *zap.Logger
+	client        *http.Client
+	githubClient  GitHubClient
+
+	// ExtraBinaries lists helper binaries bundled in the release archive that are
+	// installed next to the main binary. When empty, only the main binary is installed.
+	ExtraBinaries []string
+
+	// progressFn receives the overall progress of an update. When nil, a single
+	// progress bar is shown for the whole update.
+	progressFn ProgressFunc
+	// overall tracks the progress of the update currently being performed
+	overall *overallProgress
+
+	// directDownloadURL is the original GitHub URL of the latest release asset
+	// when the download URL was rewritten to a mirror, used as a fallback.
+	directDownloadURL string
+	// assetSize is the size of the latest release asset reported by the release, 0 if unknown
+	assetSize int64
+
+	// checksumsURL is the release-level checksum file of the latest release, used
+	// when an archive does not contain its own checksum file
+	checksumsURL string
+	// checksumsSigURL is the minisign signature of checksumsURL, if the release has one
+	checksumsSigURL string
+	// releaseChecksums caches the verified content of checksumsURL
+	releaseChecksums map[string]string
+
+	// keepDownloadDir keeps verified archives for reuse by later updates, empty to discard them
+	keepDownloadDir string
+
+	// releaseCacheTTL is how long the latest release is reused from the release cache, 0 to always fetch it
+	releaseCacheTTL time.Duration
+
+	// checksumAlgorithm forces the checksum algorithm; auto or empty detects it from the checksum length
+	checksumAlgorithm ChecksumAlgorithm
+
+	// releaseNotes and releaseURL describe the latest release, shown after updating to it
+	releaseNotes string
+	releaseURL   string
+	// quiet suppresses the summary printed after an update, showNotes adds the release notes to it
+	quiet     bool
+	showNotes bool
 }
 
 // New creates a new Updater instance.
@@ -40,34 +93,44 @@ func New(currentVersion string, urls *utils.GitHubURLs) (*Updater, error) {
 		return nil, WrapError("parse current version", err)
 	}
 
+	cfg := config.Get()
 	arch := NormalizeArch(runtime.GOARCH)
 	binaryName := FormatBinaryName("aqua-speed", runtime.GOOS, arch)
-	compressedName := FormatCompressedName("aqua-speed", runtime.GOOS, arch, currentVersion)
+	compressedName := FormatCompressedName(cfg.Binary.AssetTemplate, runtime.GOOS, arch, currentVersion)
 
 	// 如果没有提供 URLs，使用默认值
 	if urls == nil {
 		urls = utils.NewGitHubURLs(
-			config.ConfigReader.GithubRawBaseURL,
-			config.ConfigReader.GithubAPIBaseURL,
-			config.ConfigReader.GithubRawJsdelivrSet,
+			cfg.GithubRawBaseURL,
+			cfg.GithubAPIBaseURL,
+			cfg.GithubRawJsdelivrSet,
 		)
 	}
 
+	timeout := time.Duration(cfg.DownloadTimeout) * time.Second
+	client := utils.NewHTTPClient(timeout, utils.DownloadTransportOptions)
+	client.CheckRedirect = checkDownloadRedirect(logger)
+	githubClient := NewDefaultGitHubClient(utils.NewHTTPClient(timeout, utils.APITransportOptions), logger, currentVersion, urls)
+	githubClient.SetRetryPolicy(cfg.APIRetryPolicy())
+
 	return &Updater{
-		Version:        parsedVersion,
-		InstallDir:     GetInstallDir(),
-		BinaryName:     binaryName,
-		CompressedName: compressedName,
-		logger:         logger,
-		client:         &http.Client{Timeout: time.Duration(config.ConfigReader.DownloadTimeout) * time.Second},
-		githubClient:   NewDefaultGitHubClient(&http.Client{Timeout: time.Duration(config.ConfigReader.DownloadTimeout) * time.Second}, logger, currentVersion, urls),
+		Version:         parsedVersion,
+		InstallDir:      GetInstallDir(),
+		BinaryName:      binaryName,
+		CompressedName:  compressedName,
+		Repo:            config.DefaultGithubRepo,
+		AssetTemplate:   cfg.Binary.AssetTemplate,
+		ExtraBinaries:   cfg.Binary.ExtraBinaries,
+		logger:          logger,
+		client:          client,
+		githubClient:    githubClient,
+		releaseCacheTTL: DefaultReleaseCacheTTL,
 	}, nil
 }
 
 // NewWithLocalVersionAndURLs creates a new Updater instance with the local version and custom GitHub URLs.
 func NewWithLocalVersionAndURLs(defaultVersion string, urls *utils.GitHubURLs) (*Updater, error) {
-	versionFile := filepath.Join(GetInstallDir(), "version.txt")
-	content, err := ReadFileContent(versionFile)
+	content, err := ReadFileContent(GetVersionFilePath())
 	if err != nil {
 		// If read failed, use default version
 		return New(defaultVersion, urls)
@@ -94,15 +157,16 @@ func (u *Updater) GetLatestVersion() (semver.Version, string, string, error) {
 	}
 
 	// 确保 GithubRepo 不为空并且格式正确
-	repo := strings.Trim(config.DefaultGithubRepo, "/")
+	repo := strings.Trim(u.Repo, "/")
 	if !strings.Contains(repo, "/") {
 		return semver.Version{}, "", "", fmt.Errorf("invalid repository format: %s", repo)
 	}
 
 	owner, repoName := splitRepo(repo)
-	baseURL := strings.TrimSuffix(config.ConfigReader.GithubAPIMagicURL, "/")
+	cfg := config.Get()
+	baseURL := strings.TrimSuffix(cfg.GithubAPIMagicURL, "/")
 	if baseURL == "" {
-		baseURL = strings.TrimSuffix(config.ConfigReader.GithubAPIBaseURL, "/")
+		baseURL = strings.TrimSuffix(cfg.GithubAPIBaseURL, "/")
 	}
 	apiURL := fmt.Sprintf("%s/repos/%s/%s/releases/latest", baseURL, owner, repoName)
 
@@ -111,14 +175,14 @@ func (u *Updater) GetLatestVersion() (semver.Version, string, string, error) {
 		zap.String("repo", repo),
 		zap.String("currentVersion", u.Version.String()),
 		zap.String("baseURL", baseURL),
-		zap.String("magicURL", config.ConfigReader.GithubAPIMagicURL),
-		zap.String("baseAPIURL", config.ConfigReader.GithubAPIBaseURL))
+		zap.String("magicURL", cfg.GithubAPIMagicURL),
+		zap.String("baseAPIURL", cfg.GithubAPIBaseURL))
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	release, err := u.githubClient.GetLatestRelease(ctx, apiURL)
+	release, err := u.fetchLatestRelease(ctx, apiURL)
 	if err != nil {
 		u.logger.Error("Failed to fetch latest release",
 			zap.String("apiURL", apiURL),
@@ -136,35 +200,61 @@ func (u *Updater) GetLatestVersion() (semver.Version, string, string, error) {
 	}
 
 	// Determine the appropriate asset name
-	arch := NormalizeArch(runtime.GOARCH)
-	expectedPrefix := fmt.Sprintf("aqua-speed-%s-%s", runtime.GOOS, arch)
+	expectedPrefix := FormatAssetName(u.AssetTemplate, runtime.GOOS, runtime.GOARCH, latestVersion.String())
 	u.logger.Debug("Looking for asset",
 		zap.String("expectedPrefix", expectedPrefix),
 		zap.String("version", latestVersion.String()),
+		zap.Time("publishedAt", release.PublishedAt),
+		zap.Bool("prerelease", release.Prerelease),
 		zap.Int("totalAssets", len(release.Assets)),
 		zap.Any("assets", release.Assets))
 
 	var downloadURL string
 	var matchedAssetName string
+	u.assetSize = 0
+	u.releaseNotes, u.releaseURL = release.Body, release.HTMLURL
+	var skipped []string
+	u.checksumsURL, u.checksumsSigURL, u.releaseChecksums = "", "", nil
 	for _, asset := range release.Assets {
-		if asset.Name == "checksums.txt" {
-			continue
-		}
-		if strings.HasPrefix(asset.Name, expectedPrefix) {
+		var reason string
+		switch {
+		case asset.Name == releaseChecksumsAsset:
+			u.checksumsURL = asset.BrowserDownloadURL
+			reason = "checksum file"
+		case slices.Contains(releaseChecksumsSigAssets, asset.Name):
+			u.checksumsSigURL = asset.BrowserDownloadURL
+			reason = "checksum signature"
+		case strings.HasPrefix(asset.Name, expectedPrefix):
+			if downloadURL != "" {
+				reason = "duplicate match, using " + matchedAssetName
+				break
+			}
 			downloadURL = asset.BrowserDownloadURL
 			matchedAssetName = asset.Name
-			break
+			u.assetSize = asset.Size
+			u.logger.Debug("Asset matched", zap.String("asset", asset.Name))
+			continue
+		default:
+			reason = assetSkipReason(u.AssetTemplate, asset.Name, latestVersion)
 		}
+
+		u.logger.Debug("Asset skipped", zap.String("asset", asset.Name), zap.String("reason", reason))
+		skipped = append(skipped, fmt.Sprintf("%s: %s", asset.Name, reason))
 	}
 
 	if downloadURL == "" {
 		u.logger.Error("No matching asset found",
 			zap.String("expectedPrefix", expectedPrefix),
 			zap.Int("totalAssets", len(release.Assets)),
-			zap.Any("availableAssets", release.Assets))
-		return semver.Version{}, "", "", fmt.Errorf("no matching asset found for %s (available assets: %d)", expectedPrefix, len(release.Assets))
+			zap.Strings("skipped", skipped))
+		utils.Explain(utils.ExplainAsset, "没有与 %s 匹配的发布文件，跳过 %d 个", expectedPrefix, len(skipped))
+		if len(skipped) == 0 {
+			return semver.Version{}, "", "", fmt.Errorf("no matching asset found for %s: release has no assets", expectedPrefix)
+		}
+		return semver.Version{}, "", "", fmt.Errorf("no matching asset found for %s; skipped %s", expectedPrefix, summarizeSkipped(skipped))
 	}
 
+	utils.Explain(utils.ExplainAsset, "选择发布文件 %s (匹配 %s，跳过 %d 个)", matchedAssetName, expectedPrefix, len(skipped))
 	u.logger.Debug("Found matching asset",
 		zap.String("assetName", matchedAssetName),
 		zap.String("downloadURL", downloadURL),
@@ -172,10 +262,12 @@ func (u *Updater) GetLatestVersion() (semver.Version, string, string, error) {
 
 	// Try to convert GitHub release URL to mirror if available
 	originalDownloadURL := downloadURL
+	u.directDownloadURL = ""
 	if u.githubClient != nil {
 		if defaultClient, ok := u.githubClient.(*DefaultGitHubClient); ok && defaultClient.urls != nil && defaultClient.urls.FastestMirror != "" {
 			if mirrorURL, err := utils.ConvertReleaseURLToMirror(downloadURL, defaultClient.urls.FastestMirror); err == nil && mirrorURL != downloadURL {
 				downloadURL = mirrorURL
+				u.directDownloadURL = originalDownloadURL
 				u.logger.Info("Using mirror for download",
 					zap.String("original", originalDownloadURL),
 					zap.String("mirror", downloadURL),
@@ -202,33 +294,43 @@ func (u *Updater) GetLatestVersion() (semver.Version, string, string, error) {
 
 // NeedsUpdate determines if an update is needed by comparing the current version with the latest version.
 func (u *Updater) NeedsUpdate() (bool, semver.Version, string, string) {
+	needsUpdate, latestVersion, downloadURL, assetName, _ := u.checkForUpdate()
+	return needsUpdate, latestVersion, downloadURL, assetName
+}
+
+// checkForUpdate is NeedsUpdate, also returning why the latest version could not be fetched
+func (u *Updater) checkForUpdate() (bool, semver.Version, string, string, error) {
 	latestVersion, downloadURL, assetName, err := u.GetLatestVersion()
 	if err != nil {
 		u.logger.Error("Failed to get latest version", zap.Error(err))
-		return false, semver.Version{}, "", ""
+		utils.Explain(utils.ExplainUpdate, "未更新：获取最新版本失败 (%v)", err)
+		return false, semver.Version{}, "", "", err
 	}
 
 	// Compare versions using semantic versioning
 	if latestVersion.LTE(u.Version) {
-		return false, semver.Version{}, "", ""
+		utils.Explain(utils.ExplainUpdate, "未更新：当前版本 %s 不低于最新版本 %s", u.Version, latestVersion)
+		return false, semver.Version{}, "", "", nil
 	}
 
-	return true, latestVersion, downloadURL, assetName
+	return true, latestVersion, downloadURL, assetName, nil
 }
 
 // CheckAndUpdate checks for updates and performs the update if needed.
 func (u *Updater) CheckAndUpdate() error {
 	u.logger.Info("Starting update check", zap.String("current version", u.Version.String()))
 
-	// Create installation directory
-	binDir := filepath.Join(u.InstallDir, "bin")
-	if err := os.MkdirAll(binDir, 0755); err != nil {
-		u.logger.Error("Failed to create installation directory", zap.Error(err))
-		return WrapError("create installation directory", err)
+	// Create installation directory and make sure it can be written before downloading
+	if err := u.checkInstallDirWritable(); err != nil {
+		u.logger.Error("Installation directory is not usable", zap.Error(err))
+		return err
 	}
 
 	// Check if update is needed
-	needsUpdate, latestVersion, downloadURL, assetName := u.NeedsUpdate()
+	needsUpdate, latestVersion, downloadURL, assetName, err := u.checkForUpdate()
+	if err != nil {
+		return err
+	}
 	if !needsUpdate {
 		u.logger.Info("Current version is already the latest")
 		return nil
@@ -247,119 +349,517 @@ func (u *Updater) CheckAndUpdate() error {
 	// Perform the update
 	if err := u.performUpdate(tempDir, downloadURL, latestVersion, assetName); err != nil {
 		u.logger.Error("Update failed", zap.Error(err))
+		utils.Explain(utils.ExplainUpdate, "从 %s 更新到 %s 失败: %v", u.Version, latestVersion, err)
 		return err
 	}
 
 	u.logger.Info("Update completed successfully", zap.String("new version", latestVersion.String()))
+	utils.Explain(utils.ExplainUpdate, "已从 %s 更新到 %s", u.Version, latestVersion)
+	u.printUpdateSummary(u.Version, latestVersion)
 	return nil
 }
 
+// SetChecksumAlgorithm forces the algorithm used to compute and verify checksums,
+// overriding detection from the checksum length. ChecksumAuto restores detection.
+func (u *Updater) SetChecksumAlgorithm(algorithm ChecksumAlgorithm) {
+	u.checksumAlgorithm = algorithm
+}
+
+// SetReleaseMirror sets the mirror release downloads are rewritten to, instead of the
+// raw mirror, e.g. when mirror selection found the raw mirror cannot serve releases.
+// Empty downloads releases from GitHub directly.
+func (u *Updater) SetReleaseMirror(mirror string) {
+	defaultClient, ok := u.githubClient.(*DefaultGitHubClient)
+	if !ok || defaultClient.urls == nil {
+		return
+	}
+	// 复制一份，避免修改与其他服务共享的 URL 配置
+	urls := *defaultClient.urls
+	urls.FastestMirror = mirror
+	defaultClient.urls = &urls
+}
+
+// computedChecksumAlgorithm returns the algorithm used for checksums the updater
+// computes itself, which is SHA1 unless an algorithm is forced.
+func (u *Updater) computedChecksumAlgorithm() ChecksumAlgorithm {
+	if u.checksumAlgorithm == "" || u.checksumAlgorithm == ChecksumAuto {
+		return ChecksumSHA1
+	}
+	return u.checksumAlgorithm
+}
+
+// SetLogger replaces the logger the updater reports to.
+func (u *Updater) SetLogger(logger *zap.Logger) {
+	u.logger = logger
+}
+
+// SetProgressFunc sets the callback receiving the overall update progress from 0 to 100%.
+func (u *Updater) SetProgressFunc(fn ProgressFunc) {
+	u.progressFn = fn
+}
+
 // performUpdate handles the download, extraction, verification, and installation of the update.
 func (u *Updater) performUpdate(tempDir, downloadURL string, latestVersion semver.Version, assetName string) error {
-	binDir := filepath.Join(u.InstallDir, "bin")
 	compressedPath := filepath.Join(tempDir, assetName)
 
-	// Download the archive
-	downloadedData, err := u.downloadWithProgress(downloadURL)
+	defer u.beginProgress("Updating aqua-speed")()
+
+	if u.installKeptArchive(assetName, latestVersion) {
+		utils.Explain(utils.ExplainUpdate, "使用保留的压缩包 %s，未重新下载", assetName)
+		return nil
+	}
+
+	// 下载前检查磁盘空间，避免下载或解压到一半时失败留下不完整的安装
+	if err := u.checkDiskSpace(tempDir, u.archiveSize(downloadURL)); err != nil {
+		return err
+	}
+
+	// 部分下载的数据保存在临时目录中，同一次更新内的重试可以从断点继续
+	downloadedData, err := u.downloadAsset(downloadURL, compressedPath+partialSuffix)
 	if err != nil {
 		return WrapError("download file", err)
 	}
+	u.overall.complete(PhaseDownload)
 
 	// Save the downloaded archive temporarily
 	if err := os.WriteFile(compressedPath, downloadedData, 0644); err != nil {
 		return WrapError("save downloaded archive", err)
 	}
 
+	if err := u.installArchive(compressedPath, latestVersion); err != nil {
+		return err
+	}
+
+	if u.keepDownloadDir != "" {
+		if err := u.keepArchive(compressedPath, assetName); err != nil {
+			u.logger.Warn("Failed to keep downloaded archive", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// beginProgress starts reporting the overall progress of an update and returns
+// a function that ends it. Without a ProgressFunc a progress bar is shown.
+func (u *Updater) beginProgress(description string) func() {
+	progressFn := u.progressFn
+	showBar := progressFn == nil
+	if showBar {
+		progressFn = newProgressBarFunc(description)
+	}
+	u.overall = newOverallProgress(progressFn)
+
+	return func() {
+		u.overall = nil
+		if showBar {
+			fmt.Println() // Add newline for clean output
+		}
+	}
+}
+
+// installArchive extracts, verifies and installs the binaries from a release archive.
+func (u *Updater) installArchive(compressedPath string, latestVersion semver.Version) error {
+	binDir := filepath.Join(u.InstallDir, "bin")
+
+	if len(u.ExtraBinaries) > 0 {
+		return u.installBinaries(compressedPath, binDir, latestVersion)
+	}
+
 	// Read checksum and binary data from archive
-	checksum, binaryData, err := u.readArchiveContents(compressedPath)
+	checksum, binary, err := u.readArchiveContents(compressedPath)
 	if err != nil {
 		return WrapError("read archive contents", err)
 	}
+	checksums, err := u.trustedChecksums(compressedPath, []archiveBinary{*binary}, map[string]string{binary.archiveName: checksum})
+	if err != nil {
+		return err
+	}
+	checksum = checksums[binary.archiveName]
 
 	// Verify and save the binary file
 	destPath := filepath.Join(binDir, u.BinaryName)
-	if err := u.verifyAndSaveBinary(destPath, binaryData, latestVersion, checksum); err != nil {
+	if err := u.verifyAndSaveBinary(destPath, binary, latestVersion, checksum); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// downloadAsset downloads a release asset, falling back to GitHub if the mirror fails.
+// With a partialPath, interrupted downloads are kept there and resumed by later attempts.
+func (u *Updater) downloadAsset(downloadURL, partialPath string) ([]byte, error) {
+	data, err := u.downloadWithRetry(downloadURL, partialPath)
+	if err != nil && u.directDownloadURL != "" && u.directDownloadURL != downloadURL {
+		u.logger.Warn("Mirror download failed, falling back to GitHub",
+			zap.String("mirror", downloadURL),
+			zap.String("direct", u.directDownloadURL),
+			zap.Error(err))
+		// 镜像返回的部分数据不一定可信，从 GitHub 重新完整下载
+		if partialPath != "" {
+			os.Remove(partialPath)
+		}
+		data, err = u.downloadWithRetry(u.directDownloadURL, partialPath)
+	}
+	return data, err
+}
+
+// downloadWithRetry downloads a file, retrying failed attempts according to the retry policy.
+func (u *Updater) downloadWithRetry(downloadURL, partialPath string) ([]byte, error) {
+	policy := utils.GetRetryPolicy()
+
+	var lastErr error
+	for attempt := 1; attempt <= policy.Attempts; attempt++ {
+		data, err := u.downloadWithProgress(downloadURL, partialPath)
+		if err == nil {
+			return data, nil
+		}
+		if errors.Is(err, ErrHostNotAllowed) {
+			return nil, err
+		}
+		lastErr = err
+
+		if attempt < policy.Attempts {
+			backoff := policy.Backoff(attempt)
+			u.logger.Warn("Download failed, retrying",
+				zap.String("url", downloadURL),
+				zap.Int("attempt", attempt),
+				zap.Int("attempts", policy.Attempts),
+				zap.Duration("backoff", backoff),
+				zap.Error(err))
+			time.Sleep(backoff)
+		}
+	}
+
+	return nil, lastErr
+}
+
 // downloadWithProgress downloads a file from the given URL and displays a progress bar.
-func (u *Updater) downloadWithProgress(downloadURL string) ([]byte, error) {
+// With a partialPath the data is written there instead of kept in memory, and data
+// left there by an interrupted attempt is resumed with a range request when the
+// server supports it.
+func (u *Updater) downloadWithProgress(downloadURL, partialPath string) ([]byte, error) {
 	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
 	if err != nil {
 		return nil, WrapError("create download request", err)
 	}
+	if err := checkDownloadHost(req.URL.Hostname()); err != nil {
+		return nil, err
+	}
 
 	// Set proper User-Agent header
 	userAgent := "Aqua-Speed-Updater/" + u.Version.String()
 	req.Header.Set("User-Agent", userAgent)
 
+	var partial *os.File
+	var offset int64
+	if partialPath != "" {
+		partial, err = os.OpenFile(partialPath, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, WrapError("open partial download", err)
+		}
+		defer partial.Close()
+		if offset, err = partial.Seek(0, io.SeekEnd); err != nil {
+			return nil, WrapError("open partial download", err)
+		}
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+	}
+
 	resp, err := u.client.Do(req)
 	if err != nil {
+		if errors.Is(err, ErrHostNotAllowed) {
+			return nil, err
+		}
 		return nil, WrapError("download", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if finalURL := resp.Request.URL; finalURL.String() != downloadURL {
+		u.logger.Debug("Download redirected",
+			zap.String("url", downloadURL),
+			zap.String("finalURL", finalURL.Redacted()))
+		u.checkMirrorHost(downloadURL, finalURL)
+	}
+
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent && contentRangeStart(resp.Header.Get("Content-Range")) == offset:
+		u.logger.Info("Resuming download", zap.String("url", downloadURL), zap.Int64("offset", offset))
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			u.logger.Debug("Server ignored the range request, downloading from the start", zap.String("url", downloadURL))
+		}
+		offset = 0
+	default:
+		if partial != nil {
+			// 无法从断点继续 (例如 416 或范围不符)，清除部分数据，下次重试完整下载
+			partial.Truncate(0)
+		}
 		return nil, WrapError("download", fmt.Errorf("failed with status: %s", resp.Status))
 	}
+	if partial != nil {
+		if err := partial.Truncate(offset); err != nil {
+			return nil, WrapError("write partial download", err)
+		}
+		if _, err := partial.Seek(offset, io.SeekStart); err != nil {
+			return nil, WrapError("write partial download", err)
+		}
+	}
+
+	total := resp.ContentLength
+	if total >= 0 {
+		total += offset
+	}
+	// 不支持范围请求的服务器无法续传，失败时不保留部分数据
+	resumable := resp.StatusCode == http.StatusPartialContent || strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes")
 
 	u.logger.Info("Downloading from", zap.String("url", downloadURL))
 	fmt.Printf("Downloading from '%s' ...\n", downloadURL)
 
-	bar := progressbar.DefaultBytes(
-		resp.ContentLength,
-		"Downloading update",
-	)
+	var body io.Reader = resp.Body
+	var bar *progressbar.ProgressBar
+	if u.overall != nil {
+		body = NewReaderWithProgress(resp.Body, resp.ContentLength, func(current, _ int64) {
+			u.overall.update(PhaseDownload, offset+current, total)
+		})
+	} else {
+		bar = progressbar.DefaultBytes(
+			total,
+			"Downloading update",
+		)
+		bar.Add64(offset)
+		body = io.TeeReader(resp.Body, bar)
+	}
 
+	var dst io.Writer
 	buf := new(bytes.Buffer)
-	_, err = io.Copy(io.MultiWriter(buf, bar), resp.Body)
+	if partial != nil {
+		dst = partial
+	} else {
+		dst = buf
+	}
+	written, err := io.Copy(dst, body)
+	if err == nil && resp.ContentLength >= 0 && written != resp.ContentLength {
+		// A proxy may close the connection early without an error, so compare
+		// against the advertised length when the server provided one
+		err = io.ErrUnexpectedEOF
+	}
 	if err != nil {
+		if partial != nil && !resumable {
+			partial.Truncate(0)
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("%w: received %d of %d bytes", ErrTruncatedDownload, offset+written, total)
+		}
 		return nil, WrapError("download", err)
 	}
 
 	// Ensure progress bar completes and add a newline
-	bar.Finish()
-	fmt.Println() // Add newline for clean output
+	if bar != nil {
+		bar.Finish()
+		fmt.Println() // Add newline for clean output
+	}
 
+	if partial != nil {
+		data, err := os.ReadFile(partialPath)
+		if err != nil {
+			return nil, WrapError("read partial download", err)
+		}
+		return data, nil
+	}
 	return buf.Bytes(), nil
 }
 
-// verifyAndSaveBinary verifies the checksum and saves the binary file.
-func (u *Updater) verifyAndSaveBinary(destPath string, binaryData []byte, latestVersion semver.Version, checksum string) error {
-	// Verify binary file checksum
-	actualChecksum, err := CalculateChecksum(binaryData)
+// contentRangeStart returns the first byte position of a "bytes start-end/size"
+// Content-Range header, or -1 if it cannot be parsed
+func contentRangeStart(contentRange string) int64 {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
 	if err != nil {
-		return WrapError("calculate checksum", err)
+		return -1
 	}
+	return n
+}
 
-	u.logger.Debug("Checksum information",
-		zap.String("filename", u.BinaryName),
-		zap.String("expected checksum", checksum),
-		zap.String("actual checksum", actualChecksum))
+// checkDownloadRedirect returns a redirect policy that logs every hop at debug level,
+// stops after the configured maximum and rejects redirects to hosts downloads may not use.
+func checkDownloadRedirect(logger *zap.Logger) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		maxRedirects := config.Get().Download.MaxRedirects
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		if err := checkDownloadHost(req.URL.Hostname()); err != nil {
+			logger.Warn("Rejected download redirect",
+				zap.String("from", via[len(via)-1].URL.Redacted()),
+				zap.String("to", req.URL.Redacted()),
+				zap.Error(err))
+			return err
+		}
+		logger.Debug("Following download redirect",
+			zap.Int("hop", len(via)),
+			zap.String("from", via[len(via)-1].URL.Redacted()),
+			zap.String("to", req.URL.Redacted()))
+		return nil
+	}
+}
+
+// checkMirrorHost warns when a download through a mirror was redirected to another host,
+// which usually means the mirror does not serve the asset itself.
+func (u *Updater) checkMirrorHost(downloadURL string, finalURL *url.URL) {
+	if u.directDownloadURL == "" || downloadURL == u.directDownloadURL {
+		return
+	}
+
+	mirrorURL, err := url.Parse(downloadURL)
+	if err != nil {
+		return
+	}
+	if !strings.EqualFold(mirrorURL.Hostname(), finalURL.Hostname()) {
+		u.logger.Warn("Mirror redirected the download to a different host",
+			zap.String("mirrorHost", mirrorURL.Hostname()),
+			zap.String("finalHost", finalURL.Hostname()))
+	}
+}
 
-	if actualChecksum != checksum {
-		return WrapError("checksum verification", fmt.Errorf("%w: expected=%s, actual=%s", ErrChecksumMismatch, checksum, actualChecksum))
+// extractProgress returns the progress callback for extracting the archive at path and
+// a function to call once extraction is done. During an update it feeds the overall
+// progress. Otherwise archives with more than one sizeable entry get a single bar over
+// all entries, and the others, or every archive when debugging, a bar per entry (nil).
+func (u *Updater) extractProgress(archivePath string) (FileProgressFunc, func()) {
+	if u.overall != nil {
+		return func(_ string, current, total int64) {
+			u.overall.update(PhaseExtract, current, total)
+		}, func() {}
+	}
+	if u.logger.Core().Enabled(zap.DebugLevel) {
+		return nil, func() {}
+	}
+
+	sizes, err := archiveEntrySizes(archivePath)
+	if err != nil {
+		u.logger.Debug("Failed to read archive entry sizes", zap.Error(err))
+		return nil, func() {}
+	}
+	var total int64
+	sizeable := 0
+	for _, size := range sizes {
+		total += size
+		if size >= minSizeableEntry {
+			sizeable++
+		}
+	}
+	if sizeable <= 1 {
+		return nil, func() {}
+	}
+
+	bar := newExtractBar(total)
+	return bar.update, bar.finish
+}
+
+// verifyAndSaveBinary verifies the checksum and saves the binary file.
+func (u *Updater) verifyAndSaveBinary(destPath string, binary *archiveBinary, latestVersion semver.Version, checksum string) error {
+	if u.overall != nil {
+		u.overall.complete(PhaseExtract)
+	}
+
+	// Verify binary file checksum
+	if err := u.verifyHashed(binary.hasher, checksum); err != nil {
+		return err
+	}
+
+	// Keep the previous binary until the new one is known to run
+	backupPath := destPath + backupSuffix
+	hasBackup, err := backupBinary(destPath, backupPath)
+	if err != nil {
+		return WrapError("back up binary file", err)
 	}
 
 	// Save binary file
-	if err := os.WriteFile(destPath, binaryData, 0755); err != nil {
+	if err := os.WriteFile(destPath, binary.data, 0755); err != nil {
 		u.logger.Error("Failed to save binary file", zap.Error(err))
+		u.restoreBinary(destPath, backupPath, hasBackup)
 		return WrapError("save binary file", err)
 	}
 
+	reported, err := validateBinary(destPath)
+	if err != nil {
+		u.logger.Error("Installed binary failed to run, rolling back",
+			zap.String("binary", destPath),
+			zap.Bool("hasBackup", hasBackup),
+			zap.Error(err))
+		u.restoreBinary(destPath, backupPath, hasBackup)
+		return err
+	}
+	// 版本不符说明压缩包与 Release 不对应，保留它会让 version.txt 记录错误的版本
+	if !reported.Equals(latestVersion) {
+		u.logger.Error("Installed binary reports another version, rolling back",
+			zap.String("binary", destPath),
+			zap.String("reported", reported.String()),
+			zap.String("expected", latestVersion.String()),
+			zap.Bool("hasBackup", hasBackup))
+		u.restoreBinary(destPath, backupPath, hasBackup)
+		return fmt.Errorf("%w: %s reports %s, expected %s", ErrUnexpectedVersion, destPath, reported, latestVersion)
+	}
+	u.logger.Debug("Validated installed binary",
+		zap.String("binary", destPath),
+		zap.String("reported", reported.String()))
+
+	// The backup is kept for the rollback command, together with the version it records
+	if hasBackup {
+		if err := u.backupVersionInfo(); err != nil {
+			u.logger.Warn("Failed to back up version information", zap.Error(err))
+		}
+	}
+
 	// Save version and checksum information
 	if err := u.writeVersionInfo(latestVersion.String(), checksum); err != nil {
-		// If writing version information fails, delete the installed binary file
-		os.Remove(destPath)
+		// If writing version information fails, put the previous binary back
+		u.restoreBinary(destPath, backupPath, hasBackup)
 		return WrapError("save version information", err)
 	}
 
+	if u.overall != nil {
+		u.overall.complete(PhaseVerify)
+	}
 	return nil
 }
 
+// backupBinary moves an existing binary at path to backupPath, so it can be restored if
+// its replacement does not run. It reports whether there was a binary to back up.
+func backupBinary(path, backupPath string) (bool, error) {
+	if !FileExists(path) {
+		return false, nil
+	}
+	if err := os.Rename(path, backupPath); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// restoreBinary puts the binary backed up by backupBinary back at path, or removes
+// the binary at path when there was none before.
+func (u *Updater) restoreBinary(path, backupPath string, hasBackup bool) {
+	if !hasBackup {
+		os.Remove(path)
+		return
+	}
+	if err := os.Rename(backupPath, path); err != nil {
+		u.logger.Error("Failed to restore previous binary",
+			zap.String("backup", backupPath),
+			zap.String("binary", path),
+			zap.Error(err))
+		return
+	}
+	u.logger.Info("Restored previous binary", zap.String("binary", path))
+}
+
 // writeVersionInfo saves version and checksum information.
 func (u *Updater) writeVersionInfo(latestVersion, checksum string) error {
 	versionFile := filepath.Join(u.InstallDir, "version.txt")
@@ -368,16 +868,18 @@ func (u *Updater) writeVersionInfo(latestVersion, checksum string) error {
 }
 
 // readArchiveContents reads checksum and binary data from the archive.
-func (u *Updater) readArchiveContents(archivePath string) (string, []byte, error) {
-	archiveReader, err := NewArchiveReader(archivePath, u.logger)
+func (u *Updater) readArchiveContents(archivePath string) (string, *archiveBinary, error) {
+	progressFn, finishProgress := u.extractProgress(archivePath)
+	archiveReader, err := NewArchiveReaderWithProgress(archivePath, u.logger, progressFn)
 	if err != nil {
 		return "", nil, WrapError("create archive reader", err)
 	}
 	defer archiveReader.Close()
+	defer finishProgress()
 
 	var checksum string
-	var binaryData []byte
-	var foundBinary, foundChecksum bool
+	var binary *archiveBinary
+	var foundChecksum bool
 
 	for {
 		name, reader, err := archiveReader.Next()
@@ -400,46 +902,217 @@ func (u *Updater) readArchiveContents(archivePath string) (string, []byte, error
 			foundChecksum = true
 			u.logger.Debug("Found checksum file", zap.String("checksum", checksum))
 		case u.isTargetBinary(name):
-			binaryData, err = io.ReadAll(reader)
+			data, hasher, err := readHashed(reader)
 			if err != nil {
 				return "", nil, WrapError("read binary file", err)
 			}
-			foundBinary = true
-			u.logger.Debug("Found binary file", zap.Int("size", len(binaryData)))
+			binary = &archiveBinary{archiveName: filepath.Base(name), destName: u.BinaryName, data: data, hasher: hasher}
+			u.logger.Debug("Found binary file", zap.Int("size", len(data)))
 		}
 
-		if foundBinary && foundChecksum {
+		if binary != nil && foundChecksum {
 			break
 		}
 	}
 
-	if !foundBinary {
+	if binary == nil {
 		return "", nil, ErrNoExecutableFound
 	}
 	if !foundChecksum {
-		return "", nil, WrapError("read archive contents", fmt.Errorf("checksum file not found"))
+		checksums, err := u.releaseBinaryChecksums(archivePath, []archiveBinary{*binary})
+		if err != nil {
+			return "", nil, WrapError("read archive contents", err)
+		}
+		checksum = checksums[binary.archiveName]
 	}
 
 	// Verify checksum
-	if err := u.verifyChecksum(binaryData, checksum); err != nil {
+	if err := u.verifyHashed(binary.hasher, checksum); err != nil {
 		return "", nil, err
 	}
 
-	return checksum, binaryData, nil
+	return checksum, binary, nil
+}
+
+// archiveBinary is an executable extracted from a release archive
+type archiveBinary struct {
+	archiveName string // Base name inside the archive, used to look up its checksum
+	destName    string // File name to install it as
+	data        []byte
+	hasher      *ChecksumHasher // Digests of data, computed while it was extracted
+}
+
+// readHashed reads an archive entry into memory, hashing it as it streams out of
+// the archive so that checksum computation overlaps with decompression.
+func readHashed(reader io.Reader) ([]byte, *ChecksumHasher, error) {
+	hasher := NewChecksumHasher()
+	data, err := io.ReadAll(io.TeeReader(reader, hasher))
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, hasher, nil
+}
+
+// installBinaries extracts the main binary and all ExtraBinaries from the archive,
+// verifies each against its checksum entry and installs them into binDir.
+func (u *Updater) installBinaries(archivePath, binDir string, latestVersion semver.Version) error {
+	binaries, checksums, err := u.readArchiveBinaries(archivePath)
+	if err != nil {
+		return WrapError("read archive contents", err)
+	}
+	if checksums, err = u.trustedChecksums(archivePath, binaries, checksums); err != nil {
+		return err
+	}
+
+	var mainBinary *archiveBinary
+	for i := range binaries {
+		binary := &binaries[i]
+		checksum, ok := checksums[binary.archiveName]
+		if !ok {
+			return WrapError("read archive contents", fmt.Errorf("no checksum entry for %s", binary.archiveName))
+		}
+		if err := u.verifyHashed(binary.hasher, checksum); err != nil {
+			return err
+		}
+
+		if binary.destName == u.BinaryName {
+			mainBinary = binary
+			continue
+		}
+
+		destPath := filepath.Join(binDir, binary.destName)
+		if err := os.WriteFile(destPath, binary.data, 0755); err != nil {
+			u.logger.Error("Failed to save binary file", zap.String("binary", binary.destName), zap.Error(err))
+			return WrapError("save binary file", err)
+		}
+		u.logger.Debug("Installed extra binary", zap.String("binary", binary.destName))
+	}
+
+	if mainBinary == nil {
+		return ErrNoExecutableFound
+	}
+
+	// The main binary is saved last, together with the version information
+	destPath := filepath.Join(binDir, u.BinaryName)
+	return u.verifyAndSaveBinary(destPath, mainBinary, latestVersion, checksums[mainBinary.archiveName])
+}
+
+// readArchiveBinaries reads the main binary, every expected extra binary and the
+// per-file checksums from the archive. All expected binaries must be present.
+func (u *Updater) readArchiveBinaries(archivePath string) ([]archiveBinary, map[string]string, error) {
+	progressFn, finishProgress := u.extractProgress(archivePath)
+	archiveReader, err := NewArchiveReaderWithProgress(archivePath, u.logger, progressFn)
+	if err != nil {
+		return nil, nil, WrapError("create archive reader", err)
+	}
+	defer archiveReader.Close()
+	defer finishProgress()
+
+	var binaries []archiveBinary
+	var checksums map[string]string
+	found := make(map[string]bool)
+
+	for {
+		name, reader, err := archiveReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, WrapError("read archive", err)
+		}
+
+		u.logger.Debug("Scanning archive file", zap.String("filename", name))
+
+		baseName := filepath.Base(name)
+		destName := ""
+		switch {
+		case strings.HasSuffix(name, "checksum.txt"):
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, nil, WrapError("read checksum file", err)
+			}
+			checksums = parseChecksums(string(content))
+			continue
+		case u.isTargetBinary(name):
+			destName = u.BinaryName
+		default:
+			for _, extra := range u.ExtraBinaries {
+				if strings.EqualFold(baseName, extra) {
+					destName = extra
+					break
+				}
+			}
+		}
+
+		if destName == "" || found[destName] {
+			continue
+		}
+
+		data, hasher, err := readHashed(reader)
+		if err != nil {
+			return nil, nil, WrapError("read binary file", err)
+		}
+		binaries = append(binaries, archiveBinary{archiveName: baseName, destName: destName, data: data, hasher: hasher})
+		found[destName] = true
+		u.logger.Debug("Found binary file", zap.String("filename", baseName), zap.Int("size", len(data)))
+	}
+
+	if !found[u.BinaryName] {
+		return nil, nil, ErrNoExecutableFound
+	}
+	for _, extra := range u.ExtraBinaries {
+		if !found[extra] {
+			return nil, nil, fmt.Errorf("%w: %s", ErrNoExecutableFound, extra)
+		}
+	}
+	if checksums == nil {
+		checksums, err = u.releaseBinaryChecksums(archivePath, binaries)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return binaries, checksums, nil
 }
 
-// verifyChecksum verifies the binary data against the expected checksum.
+// verifyChecksum verifies the binary data against the expected checksum,
+// using SHA1 or SHA256 depending on its algorithm prefix or length.
 func (u *Updater) verifyChecksum(data []byte, expectedChecksum string) error {
-	actualChecksum, err := CalculateChecksum(data)
+	hasher := NewChecksumHasher()
+	hasher.Write(data)
+	return u.verifyHashed(hasher, expectedChecksum)
+}
+
+// verifyHashed verifies data already fed to hasher against the expected checksum,
+// using the forced checksum algorithm, or SHA1 or SHA256 depending on the algorithm
+// prefix of the expected checksum or, without one, its length.
+func (u *Updater) verifyHashed(hasher *ChecksumHasher, expectedChecksum string) error {
+	prefixed, expectedChecksum := SplitChecksum(strings.TrimSpace(expectedChecksum))
+	algorithm := u.checksumAlgorithm
+	if algorithm == "" || algorithm == ChecksumAuto {
+		algorithm = prefixed
+	} else if prefixed != "" && prefixed != algorithm {
+		return WrapError("checksum verification", fmt.Errorf("%w: checksum is %s but %s is forced", ErrChecksumMismatch, prefixed, algorithm))
+	}
+	if algorithm == "" {
+		var err error
+		algorithm, err = DetectChecksumAlgorithm(strings.ToLower(expectedChecksum))
+		if err != nil {
+			return WrapError("checksum verification", fmt.Errorf("%w: %v", ErrChecksumMismatch, err))
+		}
+	}
+
+	actualChecksum, err := hasher.Sum(algorithm)
 	if err != nil {
 		return WrapError("calculate checksum", err)
 	}
 
 	u.logger.Debug("Checksum verification",
+		zap.String("algorithm", string(algorithm)),
 		zap.String("expected", expectedChecksum),
 		zap.String("actual", actualChecksum))
 
-	if actualChecksum != expectedChecksum {
+	if !strings.EqualFold(actualChecksum, expectedChecksum) {
 		return WrapError("checksum verification", fmt.Errorf("%w: expected=%s, actual=%s", ErrChecksumMismatch, expectedChecksum, actualChecksum))
 	}
 
@@ -464,15 +1137,48 @@ func (u *Updater) isTargetBinary(filename string) bool {
 	return strings.HasPrefix(fileNameWithoutExt, targetNameWithoutExt)
 }
 
-// readChecksumFromContent extracts the checksum from the checksum file content.
+// readChecksumFromContent extracts the checksum from the checksum file content,
+// keeping its algorithm as a prefix when the file names it.
 func readChecksumFromContent(content string) string {
-	// Assume format: "checksum filename"
-	content = strings.TrimSpace(content)
-	parts := strings.Fields(content)
-	if len(parts) > 0 {
-		return parts[0]
+	for _, line := range strings.Split(content, "\n") {
+		if checksum, _ := parseChecksumLine(line); checksum != "" {
+			return checksum
+		}
 	}
-	return content
+	return strings.TrimSpace(content)
+}
+
+// parseChecksums parses checksum file content with one "checksum filename" entry per line,
+// keyed by the base name of each file.
+func parseChecksums(content string) map[string]string {
+	checksums := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		checksum, name := parseChecksumLine(line)
+		if name == "" {
+			continue
+		}
+		checksums[filepath.Base(name)] = checksum
+	}
+	return checksums
+}
+
+// parseChecksumLine parses a "checksum filename" line as written by sha1sum and sha256sum,
+// or an "ALGORITHM (filename) = checksum" line as written by BSD tools. The algorithm of
+// the latter is kept as a prefix of the checksum, such as "sha256:ab12…".
+func parseChecksumLine(line string) (checksum, name string) {
+	fields := strings.Fields(line)
+	switch {
+	case len(fields) == 4 && fields[2] == "=" && strings.HasPrefix(fields[1], "(") && strings.HasSuffix(fields[1], ")"):
+		if algorithm, err := ParseChecksumAlgorithm(fields[0]); err == nil && algorithm != ChecksumAuto {
+			return string(algorithm) + ":" + fields[3], strings.Trim(fields[1], "()")
+		}
+	case len(fields) == 1:
+		return fields[0], ""
+	case len(fields) >= 2:
+		// sha1sum prefixes binary-mode file names with '*'
+		return fields[0], strings.TrimPrefix(fields[1], "*")
+	}
+	return "", ""
 }
 
 // splitRepo splits a repository string into owner and repo parts
@@ -483,3 +1189,30 @@ func splitRepo(fullRepo string) (owner, repo string) {
 	}
 	return parts[0], parts[1]
 }
+
+// maxSkippedAssetsInError caps how many skipped assets a "no matching asset" error lists
+const maxSkippedAssetsInError = 8
+
+// assetSkipReason explains why an asset that does not start with the expected
+// name was not selected, based on what can be parsed from its name.
+func assetSkipReason(template, name string, latestVersion semver.Version) string {
+	osName, arch, version, ok := ParseAssetName(template, name)
+	switch {
+	case !ok:
+		return "name does not match asset template"
+	case osName != runtime.GOOS || NormalizeArch(arch) != NormalizeArch(runtime.GOARCH):
+		return fmt.Sprintf("other platform %s/%s", osName, arch)
+	case strings.TrimPrefix(version, "v") != latestVersion.String():
+		return fmt.Sprintf("other version %s", version)
+	default:
+		return "unexpected name for this platform"
+	}
+}
+
+// summarizeSkipped joins skip reasons for an error message, truncating long lists
+func summarizeSkipped(skipped []string) string {
+	if len(skipped) <= maxSkippedAssetsInError {
+		return strings.Join(skipped, "; ")
+	}
+	return fmt.Sprintf("%s; and %d more", strings.Join(skipped[:maxSkippedAssetsInError], "; "), len(skipped)-maxSkippedAssetsInError)
+}
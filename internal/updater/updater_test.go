@@ -0,0 +1,181 @@
+package updater
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// writeTarGz writes files, in order, to a tar.gz archive at path
+func writeTarGz(t *testing.T, path string, files [][2]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		name, content := file[0], file[1]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstallArchiveMultipleBinaries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the installed binary is a shell script")
+	}
+	setChecksumsPublicKey(t, "")
+
+	main := "#!/bin/sh\necho aqua-speed v1.2.3\n"
+	helper := "#!/bin/sh\necho helper\n"
+	probe := "#!/bin/sh\necho probe\n"
+	checksums := fmt.Sprintf("%s  aqua-speed-linux-x64\n%s  aqua-helper\n%s  aqua-probe\n",
+		sha256Hex([]byte(main)), sha256Hex([]byte(helper)), sha256Hex([]byte(probe)))
+
+	tests := []struct {
+		name    string
+		files   [][2]string
+		wantErr error
+	}{
+		{
+			name: "all binaries",
+			files: [][2]string{
+				{"release/aqua-helper", helper},
+				{"release/aqua-speed-linux-x64", main},
+				{"release/aqua-probe", probe},
+				{"release/checksum.txt", checksums},
+			},
+		},
+		{
+			name: "missing extra binary",
+			files: [][2]string{
+				{"release/aqua-speed-linux-x64", main},
+				{"release/aqua-helper", helper},
+				{"release/checksum.txt", checksums},
+			},
+			wantErr: ErrNoExecutableFound,
+		},
+		{
+			name: "tampered extra binary",
+			files: [][2]string{
+				{"release/aqua-speed-linux-x64", main},
+				{"release/aqua-helper", "#!/bin/sh\necho tampered\n"},
+				{"release/aqua-probe", probe},
+				{"release/checksum.txt", checksums},
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			binDir := filepath.Join(dir, "bin")
+			if err := os.MkdirAll(binDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			archivePath := filepath.Join(dir, "aqua-speed-linux-x64.tar.gz")
+			writeTarGz(t, archivePath, tt.files)
+
+			u := &Updater{
+				InstallDir:    dir,
+				BinaryName:    "aqua-speed-linux-x64",
+				ExtraBinaries: []string{"aqua-helper", "aqua-probe"},
+				logger:        zap.NewNop(),
+			}
+			version, err := ParseVersion("1.2.3")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = u.installArchive(archivePath, version)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("installArchive() error = %v, want %v", err, tt.wantErr)
+				}
+				if _, err := os.Stat(filepath.Join(binDir, "aqua-speed-linux-x64")); !os.IsNotExist(err) {
+					t.Error("main binary installed despite the failure")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("installArchive() error = %v", err)
+			}
+
+			for name, want := range map[string]string{"aqua-speed-linux-x64": main, "aqua-helper": helper, "aqua-probe": probe} {
+				got, err := os.ReadFile(filepath.Join(binDir, name))
+				if err != nil {
+					t.Errorf("%s not installed: %v", name, err)
+					continue
+				}
+				if string(got) != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkReadHashed compares hashing an archive entry while it is decompressed
+// with decompressing it first and hashing the buffered data in a second pass.
+func BenchmarkReadHashed(b *testing.B) {
+	binary := make([]byte, 32<<20)
+	rng := rand.NewChaCha8([32]byte{})
+	rng.Read(binary[:len(binary)/2]) // Half random, half zeros, roughly like an executable
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(binary)
+	gz.Close()
+
+	decompress := func(b *testing.B) io.Reader {
+		reader, err := gzip.NewReader(bytes.NewReader(compressed.Bytes()))
+		if err != nil {
+			b.Fatal(err)
+		}
+		return reader
+	}
+
+	b.Run("single pass", func(b *testing.B) {
+		b.SetBytes(int64(len(binary)))
+		for b.Loop() {
+			if _, _, err := readHashed(decompress(b)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("re-read", func(b *testing.B) {
+		b.SetBytes(int64(len(binary)))
+		for b.Loop() {
+			data, err := io.ReadAll(decompress(b))
+			if err != nil {
+				b.Fatal(err)
+			}
+			NewChecksumHasher().Write(data)
+		}
+	})
+}
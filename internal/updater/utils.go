@@ -2,18 +2,47 @@ package updater
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 )
 
+// archNames maps GOARCH values to the architecture names used in release assets.
+// Architectures not listed keep their GOARCH name.
+var archNames = map[string]string{
+	"amd64": "x64",
+}
+
+// Platform is an OS/architecture combination, using GOOS and GOARCH values.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+// SupportedPlatforms lists the platforms aqua-speed releases are built for.
+var SupportedPlatforms = []Platform{
+	{OS: "linux", Arch: "amd64"},
+	{OS: "linux", Arch: "arm64"},
+	{OS: "windows", Arch: "amd64"},
+	{OS: "darwin", Arch: "amd64"},
+	{OS: "darwin", Arch: "arm64"},
+}
+
+// HostPlatform returns the platform this program is running on.
+func HostPlatform() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
 // NormalizeArch converts GOARCH to a normalized architecture string.
 func NormalizeArch(arch string) string {
-	if arch == "amd64" {
-		return "x64"
+	if name, ok := archNames[arch]; ok {
+		return name
 	}
 	return arch
 }
@@ -27,14 +56,23 @@ func FormatBinaryName(prefix, osName, arch string) string {
 	return name
 }
 
-// FormatCompressedName constructs the compressed archive name based on OS, architecture, and version.
-func FormatCompressedName(prefix, osName, arch, version string) string {
-	version = strings.TrimPrefix(version, "v")
-	if arch == "amd64" {
-		arch = "x64"
-	}
+// FormatAssetName renders a release asset name template, replacing the
+// {os}, {arch} and {version} placeholders. The version is used without
+// its leading "v". {goarch} is replaced with the architecture as is,
+// without the normalization applied to {arch}.
+func FormatAssetName(template, osName, arch, version string) string {
+	return strings.NewReplacer(
+		"{os}", osName,
+		"{arch}", NormalizeArch(arch),
+		"{goarch}", arch,
+		"{version}", strings.TrimPrefix(version, "v"),
+	).Replace(template)
+}
 
-	name := fmt.Sprintf("%s-%s-%s_v%s", prefix, osName, arch, version)
+// FormatCompressedName constructs the compressed archive name from the asset
+// name template, OS, architecture, and version.
+func FormatCompressedName(template, osName, arch, version string) string {
+	name := FormatAssetName(template, osName, arch, version)
 
 	switch osName {
 	case "windows", "darwin":
@@ -44,10 +82,57 @@ func FormatCompressedName(prefix, osName, arch, version string) string {
 	}
 }
 
-// GetInstallDir determines the installation directory based on the OS.
+// ParseAssetName extracts the OS, architecture and version from an archive
+// file name rendered from the asset name template. ok is false if the name
+// does not match the template.
+func ParseAssetName(template, name string) (osName, arch, version string, ok bool) {
+	pattern := regexp.QuoteMeta(template)
+	pattern = strings.NewReplacer(
+		regexp.QuoteMeta("{os}"), `(?P<os>[A-Za-z0-9]+)`,
+		regexp.QuoteMeta("{arch}"), `(?P<arch>[A-Za-z0-9_]+)`,
+		regexp.QuoteMeta("{goarch}"), `(?P<arch>[A-Za-z0-9_]+)`,
+		regexp.QuoteMeta("{version}"), `(?P<version>[0-9A-Za-z.+-]+?)`,
+	).Replace(pattern)
+
+	re, err := regexp.Compile(`^` + pattern + `(\.zip|\.tar\.xz|\.tar\.gz|\.tgz)$`)
+	if err != nil {
+		return "", "", "", false
+	}
+	match := re.FindStringSubmatch(name)
+	if match == nil {
+		return "", "", "", false
+	}
+
+	for i, group := range re.SubexpNames() {
+		switch group {
+		case "os":
+			osName = match[i]
+		case "arch":
+			arch = match[i]
+		case "version":
+			version = match[i]
+		}
+	}
+	return osName, arch, version, true
+}
+
+// installDirOverride is the installation directory set with SetInstallDir, empty for the default
+var installDirOverride string
+
+// SetInstallDir overrides the installation directory of updaters created afterwards,
+// empty to use the OS default
+func SetInstallDir(dir string) {
+	if dir != "" {
+		dir = filepath.Clean(dir)
+	}
+	installDirOverride = dir
+}
 
 // GetInstallDir returns the installation directory for aqua-speed
 func GetInstallDir() string {
+	if installDirOverride != "" {
+		return installDirOverride
+	}
 	switch runtime.GOOS {
 	case "windows":
 		return filepath.Join(os.Getenv("APPDATA"), "aqua-speed")
@@ -61,14 +146,118 @@ func GetInstallDir() string {
 	}
 }
 
+// GetBinaryPath returns the path of the installed aqua-speed binary for this platform
+func GetBinaryPath() string {
+	binaryName := FormatBinaryName("aqua-speed", runtime.GOOS, NormalizeArch(runtime.GOARCH))
+	return filepath.Join(GetInstallDir(), "bin", binaryName)
+}
+
+// GetVersionFilePath returns the path of the file recording the installed version and checksum
+func GetVersionFilePath() string {
+	return filepath.Join(GetInstallDir(), "version.txt")
+}
+
+// ChecksumAlgorithm identifies a supported checksum hash.
+type ChecksumAlgorithm string
+
+const (
+	ChecksumAuto   ChecksumAlgorithm = "auto" // Detect the algorithm from the checksum length
+	ChecksumSHA1   ChecksumAlgorithm = "sha1"
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+)
+
+// ParseChecksumAlgorithm parses a checksum algorithm name: sha1, sha256 or auto.
+func ParseChecksumAlgorithm(name string) (ChecksumAlgorithm, error) {
+	switch algorithm := ChecksumAlgorithm(strings.ToLower(strings.TrimSpace(name))); algorithm {
+	case ChecksumAuto, ChecksumSHA1, ChecksumSHA256:
+		return algorithm, nil
+	default:
+		return "", fmt.Errorf("invalid checksum algorithm %q: must be sha1, sha256 or auto", name)
+	}
+}
+
+// SplitChecksum splits a checksum carrying an algorithm prefix, such as "sha256:ab12…",
+// into the algorithm and the hex checksum. Checksums without a known prefix are
+// returned unchanged with an empty algorithm.
+func SplitChecksum(checksum string) (ChecksumAlgorithm, string) {
+	name, value, found := strings.Cut(checksum, ":")
+	if !found {
+		return "", checksum
+	}
+	algorithm, err := ParseChecksumAlgorithm(name)
+	if err != nil || algorithm == ChecksumAuto {
+		return "", checksum
+	}
+	return algorithm, value
+}
+
+// DetectChecksumAlgorithm infers the algorithm from the length of a hex-encoded checksum.
+func DetectChecksumAlgorithm(checksum string) (ChecksumAlgorithm, error) {
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", fmt.Errorf("invalid checksum %q: %w", checksum, err)
+	}
+	switch len(checksum) {
+	case sha1.Size * 2:
+		return ChecksumSHA1, nil
+	case sha256.Size * 2:
+		return ChecksumSHA256, nil
+	default:
+		return "", fmt.Errorf("unsupported checksum length %d: expected SHA1 or SHA256", len(checksum))
+	}
+}
 
 // CalculateChecksum computes the SHA1 checksum of the given data.
 func CalculateChecksum(data []byte) (string, error) {
-	hash := sha1.New()
-	if _, err := hash.Write(data); err != nil {
+	return CalculateChecksumWith(data, ChecksumSHA1)
+}
+
+// CalculateChecksumWith computes the checksum of the given data using the given algorithm.
+func CalculateChecksumWith(data []byte, algorithm ChecksumAlgorithm) (string, error) {
+	var h hash.Hash
+	switch algorithm {
+	case ChecksumSHA1:
+		h = sha1.New()
+	case ChecksumSHA256:
+		h = sha256.New()
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+	if _, err := h.Write(data); err != nil {
 		return "", fmt.Errorf("failed to calculate checksum: %w", err)
 	}
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChecksumHasher computes the SHA1 and SHA256 digests of everything written to it
+// in a single pass, so data can be hashed while streaming before it is known which
+// algorithm the expected checksum uses.
+type ChecksumHasher struct {
+	sha1   hash.Hash
+	sha256 hash.Hash
+}
+
+// NewChecksumHasher creates an empty ChecksumHasher.
+func NewChecksumHasher() *ChecksumHasher {
+	return &ChecksumHasher{sha1: sha1.New(), sha256: sha256.New()}
+}
+
+// Write adds p to both digests. It never returns an error.
+func (h *ChecksumHasher) Write(p []byte) (int, error) {
+	h.sha1.Write(p)
+	h.sha256.Write(p)
+	return len(p), nil
+}
+
+// Sum returns the hex-encoded digest for the given algorithm.
+func (h *ChecksumHasher) Sum(algorithm ChecksumAlgorithm) (string, error) {
+	switch algorithm {
+	case ChecksumSHA1:
+		return hex.EncodeToString(h.sha1.Sum(nil)), nil
+	case ChecksumSHA256:
+		return hex.EncodeToString(h.sha256.Sum(nil)), nil
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
 }
 
 // FileExists checks if a file exists at the specified path.
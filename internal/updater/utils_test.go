@@ -0,0 +1,185 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestAssetNameCustomTemplate(t *testing.T) {
+	tests := []struct {
+		template    string
+		osName      string
+		arch        string
+		want        string
+		wantVersion string // Empty for templates without {version}
+	}{
+		{template: "aqua-speed-{os}-{arch}_v{version}", osName: "linux", arch: "amd64", want: "aqua-speed-linux-x64_v1.2.3.tar.xz", wantVersion: "1.2.3"},
+		{template: "speedtest_{version}_{os}_{arch}", osName: "linux", arch: "arm64", want: "speedtest_1.2.3_linux_arm64.tar.xz", wantVersion: "1.2.3"},
+		{template: "aqua-{os}-{goarch}", osName: "darwin", arch: "amd64", want: "aqua-darwin-amd64.zip"},
+		{template: "{os}.{arch}.aqua-speed-{version}", osName: "windows", arch: "amd64", want: "windows.x64.aqua-speed-1.2.3.zip", wantVersion: "1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got := FormatCompressedName(tt.template, tt.osName, tt.arch, "v1.2.3")
+			if got != tt.want {
+				t.Fatalf("FormatCompressedName() = %s, want %s", got, tt.want)
+			}
+
+			osName, arch, version, ok := ParseAssetName(tt.template, got)
+			if !ok {
+				t.Fatalf("ParseAssetName(%s) did not match its template", got)
+			}
+			if osName != tt.osName || NormalizeArch(arch) != NormalizeArch(tt.arch) {
+				t.Errorf("ParseAssetName() platform = %s/%s, want %s/%s", osName, arch, tt.osName, tt.arch)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("ParseAssetName() version = %s, want %s", version, tt.wantVersion)
+			}
+		})
+	}
+
+	if _, _, _, ok := ParseAssetName("speedtest_{version}_{os}_{arch}", "aqua-speed-linux-x64_v1.2.3.tar.xz"); ok {
+		t.Error("ParseAssetName() matched a name of another template")
+	}
+}
+
+// releaseClient serves a fixed latest release
+type releaseClient struct {
+	release GitHubRelease
+}
+
+func (c *releaseClient) GetLatestRelease(ctx context.Context, apiURL string) (*GitHubRelease, error) {
+	return &c.release, nil
+}
+
+func (c *releaseClient) GetRawContent(ctx context.Context, rawURL string) ([]byte, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func TestGetLatestVersionCustomTemplate(t *testing.T) {
+	const template = "speedtest_{version}_{os}_{arch}"
+	release := GitHubRelease{TagName: "v2.0.0"}
+	for _, name := range []string{
+		// The default naming scheme no longer matches
+		FormatCompressedName("aqua-speed-{os}-{arch}_v{version}", runtime.GOOS, runtime.GOARCH, "2.0.0"),
+		FormatCompressedName(template, "plan9", runtime.GOARCH, "2.0.0"),
+		FormatCompressedName(template, runtime.GOOS, runtime.GOARCH, "2.0.0"),
+	} {
+		release.Assets = append(release.Assets, struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+			Size               int64  `json:"size"`
+		}{Name: name, BrowserDownloadURL: "https://github.com/alice39s/aqua-speed/releases/download/v2.0.0/" + name})
+	}
+
+	u := &Updater{
+		Repo:          "alice39s/aqua-speed",
+		AssetTemplate: template,
+		logger:        zap.NewNop(),
+		githubClient:  &releaseClient{release: release},
+	}
+	version, downloadURL, assetName, err := u.GetLatestVersion()
+	if err != nil {
+		t.Fatalf("GetLatestVersion() error = %v", err)
+	}
+
+	want := FormatCompressedName(template, runtime.GOOS, runtime.GOARCH, "2.0.0")
+	if version.String() != "2.0.0" || assetName != want {
+		t.Errorf("GetLatestVersion() = %s, %s, want 2.0.0, %s", version, assetName, want)
+	}
+	if downloadURL != release.Assets[2].BrowserDownloadURL {
+		t.Errorf("GetLatestVersion() download URL = %s, want %s", downloadURL, release.Assets[2].BrowserDownloadURL)
+	}
+}
+
+func TestParseChecksumAlgorithm(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ChecksumAlgorithm
+		wantErr bool
+	}{
+		{name: "auto", want: ChecksumAuto},
+		{name: "sha1", want: ChecksumSHA1},
+		{name: "SHA256", want: ChecksumSHA256},
+		{name: " sha256 ", want: ChecksumSHA256},
+		{name: "md5", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChecksumAlgorithm(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseChecksumAlgorithm() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseChecksumAlgorithm() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyHashedForcedAlgorithm(t *testing.T) {
+	data := []byte("aqua-speed binary")
+	sha1Sum := sha1.Sum(data)
+	sha1Hex := hex.EncodeToString(sha1Sum[:])
+	sha256Hex := sha256Hex(data)
+
+	tests := []struct {
+		name      string
+		algorithm ChecksumAlgorithm
+		checksum  string
+		wantErr   bool
+	}{
+		{name: "auto detects sha1", algorithm: ChecksumAuto, checksum: sha1Hex},
+		{name: "auto detects sha256", algorithm: ChecksumAuto, checksum: sha256Hex},
+		{name: "auto follows the prefix", algorithm: ChecksumAuto, checksum: "sha256:" + sha256Hex},
+		{name: "auto rejects an unknown length", algorithm: ChecksumAuto, checksum: sha256Hex[:48], wantErr: true},
+		{name: "forced sha1", algorithm: ChecksumSHA1, checksum: sha1Hex},
+		{name: "forced sha1 rejects a sha256 checksum", algorithm: ChecksumSHA1, checksum: sha256Hex, wantErr: true},
+		{name: "forced sha256", algorithm: ChecksumSHA256, checksum: sha256Hex},
+		{name: "forced sha256 ignores the length", algorithm: ChecksumSHA256, checksum: "  " + sha256Hex + "\n"},
+		{name: "forced sha256 rejects a sha1 checksum", algorithm: ChecksumSHA256, checksum: sha1Hex, wantErr: true},
+		{name: "forced sha256 rejects a sha1 prefix", algorithm: ChecksumSHA256, checksum: "sha1:" + sha1Hex, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &Updater{logger: zap.NewNop()}
+			u.SetChecksumAlgorithm(tt.algorithm)
+			hasher := NewChecksumHasher()
+			hasher.Write(data)
+
+			err := u.verifyHashed(hasher, tt.checksum)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyHashed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrChecksumMismatch) {
+				t.Errorf("verifyHashed() error = %v, want %v", err, ErrChecksumMismatch)
+			}
+		})
+	}
+}
+
+func TestComputedChecksumAlgorithm(t *testing.T) {
+	for algorithm, want := range map[ChecksumAlgorithm]ChecksumAlgorithm{
+		"":             ChecksumSHA1,
+		ChecksumAuto:   ChecksumSHA1,
+		ChecksumSHA1:   ChecksumSHA1,
+		ChecksumSHA256: ChecksumSHA256,
+	} {
+		u := &Updater{}
+		u.SetChecksumAlgorithm(algorithm)
+		if got := u.computedChecksumAlgorithm(); got != want {
+			t.Errorf("computedChecksumAlgorithm() with %q forced = %s, want %s", algorithm, got, want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package updater
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// VerifyArchive extracts the binary for the current platform from a local
+// release archive and verifies it against both the checksum file bundled in
+// the archive and expectedChecksum, without installing anything.
+// expectedChecksum may be a SHA1 or SHA256 hex digest of the binary.
+func (u *Updater) VerifyArchive(archivePath, expectedChecksum string) error {
+	expectedChecksum = strings.TrimSpace(expectedChecksum)
+
+	u.logger.Debug("Verifying archive",
+		zap.String("archive", archivePath),
+		zap.String("expected checksum", expectedChecksum))
+
+	_, binary, err := u.readArchiveContents(archivePath)
+	if err != nil {
+		return WrapError("read archive contents", err)
+	}
+
+	return u.verifyHashed(binary.hasher, expectedChecksum)
+}
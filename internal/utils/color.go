@@ -1,6 +1,19 @@
 package utils
 
-import "github.com/fatih/color"
+import (
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// Color modes accepted by SetColorMode
+const (
+	ColorAuto   = "auto"   // Colors only when stdout is a terminal
+	ColorAlways = "always" // Colors even when output is piped
+	ColorNever  = "never"  // No colors at all
+)
 
 var (
 	Red    = color.New(color.FgRed)
@@ -12,4 +25,56 @@ var (
 	Gray   = color.New(color.FgWhite)
 	Bold   = "\033[1m"
 	Reset  = "\033[0m"
+
+	// autoNoColor is the terminal detection result of the color package (TTY, NO_COLOR and TERM)
+	autoNoColor = color.NoColor
 )
+
+// SetColorMode enables or disables colored output for both the color helpers and tables
+func SetColorMode(mode string) error {
+	switch mode {
+	case ColorAuto:
+		applyColors(!autoNoColor)
+	case ColorAlways:
+		applyColors(true)
+	case ColorNever:
+		applyColors(false)
+	default:
+		return fmt.Errorf("invalid color mode %q (expected %s, %s or %s)", mode, ColorAuto, ColorAlways, ColorNever)
+	}
+	return nil
+}
+
+// applyColors switches all color outputs on or off
+func applyColors(enabled bool) {
+	color.NoColor = !enabled
+	if enabled {
+		text.EnableColors()
+		Bold, Reset = "\033[1m", "\033[0m"
+	} else {
+		text.DisableColors()
+		Bold, Reset = "", ""
+	}
+}
+
+// SetMessageOutput redirects the messages printed with the color helpers to w, so that
+// stdout can be kept for machine-readable output
+func SetMessageOutput(w io.Writer) {
+	color.Output = w
+}
+
+// SpeedColor returns the color for a speed in Mbps: green above good, yellow above fair, red otherwise
+func SpeedColor(mbps, good, fair float64) *color.Color {
+	switch {
+	case mbps > good:
+		return Green
+	case mbps > fair:
+		return Yellow
+	default:
+		return Red
+	}
+}
+
+func init() {
+	applyColors(!autoNoColor)
+}
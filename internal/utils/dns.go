@@ -1,86 +1,263 @@
 package utils
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/miekg/dns"
 )
 
-var (
-	defaultResolver *DNSResolver
-)
+// defaultResolver is replaced on config reload while requests read it
+var defaultResolver atomic.Pointer[DNSResolver]
+
+// minDNSCacheTTL is the shortest time a resolved hostname is cached for
+const minDNSCacheTTL = time.Minute
+
+// DefaultDNSMaxCacheTTL is the longest time a DNS answer is cached for, whatever its TTL
+const DefaultDNSMaxCacheTTL = time.Hour
+
+// DNSEndpoint is a DoH endpoint together with how long and how often it is tried
+type DNSEndpoint struct {
+	Address string
+	Timeout time.Duration // Bounds each record type lookup, including its retries
+	Retries int           // Retries of a failed exchange before the next endpoint is tried
+}
 
 // DNSResolver represents a DNS resolver using DNS over HTTPS
 type DNSResolver struct {
-	endpoint string
-	timeout  time.Duration
-	retries  int
-	client   *dns.Client
+	endpoints []DNSEndpoint
+	// current is the index of the endpoint lookups start from, the last one that answered
+	current atomic.Int32
+	client  *dns.Client
+	// cache holds the answer of each hostname and record type, so the family that
+	// resolved is not queried again while it is fresh when the other one failed
+	cache  *DNSCache
+	maxTTL time.Duration // Longest time an answer is cached for, 0 for no limit
+	// preferIPv6 puts IPv6 addresses before IPv4 ones in resolved addresses
+	preferIPv6 bool
 }
 
-// NewDNSResolver creates a new DNS resolver
-func NewDNSResolver(endpoint string, timeoutSeconds int, retries int) (*DNSResolver, error) {
-	if endpoint == "" {
-		return nil, fmt.Errorf("endpoint cannot be empty")
+// NewDNSResolver creates a new DNS resolver failing over between endpoints in order:
+// when one times out or has no answer for a hostname, the next one is tried.
+func NewDNSResolver(endpoints ...DNSEndpoint) (*DNSResolver, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("at least one endpoint is required")
+	}
+	for i, endpoint := range endpoints {
+		if endpoint.Address == "" {
+			return nil, fmt.Errorf("endpoint %d cannot be empty", i)
+		}
 	}
 
 	return &DNSResolver{
-		endpoint: endpoint,
-		timeout:  time.Duration(timeoutSeconds) * time.Second,
-		retries:  retries,
-		client:   new(dns.Client),
+		endpoints: slices.Clone(endpoints),
+		client:    new(dns.Client),
+		cache:     NewDNSCache(),
+		maxTTL:    DefaultDNSMaxCacheTTL,
 	}, nil
 }
 
-// SetDNSResolver sets the default DNS resolver
+// SetMaxCacheTTL caps how long resolved addresses are cached, regardless of their TTL.
+// 0 removes the cap.
+func (r *DNSResolver) SetMaxCacheTTL(ttl time.Duration) {
+	r.maxTTL = ttl
+}
+
+// ClearCache drops every cached answer from memory, so the next lookups query the
+// endpoints again. A DNS cache file is left as it is.
+func (r *DNSResolver) ClearCache() {
+	r.cache.Clear()
+}
+
+// cacheTTL returns how long a result with the given TTL is cached: at least
+// minDNSCacheTTL, unless the max cache TTL is lower
+func (r *DNSResolver) cacheTTL(ttl time.Duration) time.Duration {
+	ttl = max(ttl, minDNSCacheTTL)
+	if r.maxTTL > 0 {
+		ttl = min(ttl, r.maxTTL)
+	}
+	return ttl
+}
+
+// SetCache replaces the resolver's in-memory cache, e.g. with one loaded from a file
+func (r *DNSResolver) SetCache(cache *DNSCache) {
+	r.cache = cache
+}
+
+// SetPreferIPv6 makes Resolve return IPv6 addresses before IPv4 ones, instead of IPv4 first
+func (r *DNSResolver) SetPreferIPv6(preferIPv6 bool) {
+	r.preferIPv6 = preferIPv6
+}
+
+// SetDNSResolver sets the default DNS resolver, safe to call while requests are in flight
 func SetDNSResolver(resolver *DNSResolver) {
-	defaultResolver = resolver
+	defaultResolver.Store(resolver)
 }
 
 // GetDNSResolver returns the default DNS resolver
 func GetDNSResolver() *DNSResolver {
-	return defaultResolver
+	return defaultResolver.Load()
 }
 
-// Resolve resolves a hostname to its IP addresses
+// Resolve resolves a hostname to its IPv4 and IPv6 addresses, ordered by the preferred
+// family, using the cached answer of each record type while it is fresh. If resolution
+// fails, expired cached answers are returned instead of the error.
 func (r *DNSResolver) Resolve(hostname string) ([]net.IP, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	v4, v4Fresh := r.cache.Get(hostname, dns.TypeA)
+	v6, v6Fresh := r.cache.Get(hostname, dns.TypeAAAA)
+	cached := append(v4, v6...)
+	if v4Fresh && v6Fresh && len(cached) > 0 {
+		return orderByFamily(cached, r.preferIPv6), nil
+	}
+
+	ips, err := r.query(hostname)
+	if err != nil {
+		if len(cached) > 0 {
+			LogWarning("DNS 解析 %s 失败，使用过期的缓存结果: %v", hostname, err)
+			return orderByFamily(cached, r.preferIPv6), nil
+		}
+		return nil, err
+	}
+	return orderByFamily(ips, r.preferIPv6), nil
+}
+
+// query resolves a hostname over the DoH endpoints, starting from the last one that
+// answered and failing over to the others in order, so a blocked provider only costs
+// its timeout once.
+func (r *DNSResolver) query(hostname string) ([]net.IP, error) {
+	start := int(r.current.Load())
+	var errs []error
+	for i := range r.endpoints {
+		index := (start + i) % len(r.endpoints)
+		endpoint := r.endpoints[index]
+
+		ips, err := r.queryEndpoint(endpoint, hostname)
+		if err == nil {
+			r.current.Store(int32(index))
+			return ips, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint.Address, err))
+		if len(r.endpoints) > 1 {
+			LogWarning("DoH 端点 %s 解析 %s 失败: %v", endpoint.Address, hostname, err)
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// queryEndpoint resolves a hostname over one DoH endpoint, returning its A and AAAA records.
+// Both record types are queried concurrently; the lookup only fails if neither resolves, so
+// a host with a single working address family is still resolved. Once it resolves, the
+// answer of each record type that was queried without error is cached, an empty one too.
+func (r *DNSResolver) queryEndpoint(endpoint DNSEndpoint, hostname string) ([]net.IP, error) {
+	var v4, v6 dnsAnswer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		v4 = r.cachedQueryType(endpoint, hostname, dns.TypeA)
+	}()
+	go func() {
+		defer wg.Done()
+		v6 = r.cachedQueryType(endpoint, hostname, dns.TypeAAAA)
+	}()
+	wg.Wait()
+
+	ips := append(v4.ips, v6.ips...)
+	if len(ips) == 0 {
+		if err := cmp.Or(v4.err, v6.err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no A or AAAA records found for %s", hostname)
+	}
+
+	// 空结果只在另一种地址族解析成功时缓存，否则仍应询问其他端点
+	for qtype, answer := range map[uint16]dnsAnswer{dns.TypeA: v4, dns.TypeAAAA: v6} {
+		if !answer.cached && answer.err == nil {
+			r.cache.Put(hostname, qtype, answer.ips, r.cacheTTL(time.Duration(answer.ttl)*time.Second))
+		}
+	}
+	return ips, nil
+}
+
+// dnsAnswer is the outcome of querying a single record type
+type dnsAnswer struct {
+	ips    []net.IP
+	ttl    uint32 // Lowest TTL of the records, 0 if there are none
+	err    error
+	cached bool // Taken from the cache rather than queried
+}
+
+// cachedQueryType returns the cached answer of qtype for a hostname while it is fresh,
+// otherwise queries the endpoint
+func (r *DNSResolver) cachedQueryType(endpoint DNSEndpoint, hostname string, qtype uint16) dnsAnswer {
+	if ips, fresh := r.cache.Get(hostname, qtype); fresh {
+		return dnsAnswer{ips: ips, cached: true}
+	}
+	return r.queryType(endpoint, hostname, qtype)
+}
+
+// queryType resolves the records of qtype (A or AAAA) for a hostname over a DoH endpoint, retrying failed exchanges
+func (r *DNSResolver) queryType(endpoint DNSEndpoint, hostname string, qtype uint16) dnsAnswer {
+	ctx, cancel := context.WithTimeout(context.Background(), endpoint.Timeout)
 	defer cancel()
 
-	var ips []net.IP
 	var lastErr error
-
-	for attempt := 0; attempt <= r.retries; attempt++ {
+	for attempt := 0; attempt <= endpoint.Retries; attempt++ {
 		msg := new(dns.Msg)
-		msg.SetQuestion(dns.Fqdn(hostname), dns.TypeA)
+		msg.SetQuestion(dns.Fqdn(hostname), qtype)
 
-		resp, _, err := r.client.ExchangeContext(ctx, msg, r.endpoint)
+		resp, _, err := r.client.ExchangeContext(ctx, msg, endpoint.Address)
 		if err != nil {
 			lastErr = err
-			if attempt < r.retries {
+			if attempt < endpoint.Retries {
 				time.Sleep(time.Duration(attempt+1) * time.Second)
 				continue
 			}
 			break
 		}
 
+		var answer dnsAnswer
 		for _, ans := range resp.Answer {
-			if a, ok := ans.(*dns.A); ok {
-				ips = append(ips, a.A)
+			var ip net.IP
+			switch rr := ans.(type) {
+			case *dns.A:
+				ip = rr.A
+			case *dns.AAAA:
+				ip = rr.AAAA
+			default:
+				continue
+			}
+			answer.ips = append(answer.ips, ip)
+			if answer.ttl == 0 || ans.Header().Ttl < answer.ttl {
+				answer.ttl = ans.Header().Ttl
 			}
 		}
-
-		if len(ips) > 0 {
-			return ips, nil
-		}
+		// 没有该类型的记录不是错误，另一种地址族可能可以解析
+		return answer
 	}
 
-	if lastErr != nil {
-		return nil, fmt.Errorf("DNS resolution failed after %d attempts: %v", r.retries+1, lastErr)
-	}
+	return dnsAnswer{err: fmt.Errorf("DNS resolution (%s) failed after %d attempts: %v", dns.TypeToString[qtype], endpoint.Retries+1, lastErr)}
+}
 
-	return nil, fmt.Errorf("no A records found for %s", hostname)
+// orderByFamily returns ips with the preferred address family first, keeping the order within each family
+func orderByFamily(ips []net.IP, preferIPv6 bool) []net.IP {
+	ordered := slices.Clone(ips)
+	slices.SortStableFunc(ordered, func(a, b net.IP) int {
+		aIsV6, bIsV6 := a.To4() == nil, b.To4() == nil
+		if aIsV6 == bIsV6 {
+			return 0
+		}
+		if aIsV6 == preferIPv6 {
+			return -1
+		}
+		return 1
+	})
+	return ordered
 }
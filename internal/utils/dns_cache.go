@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// dnsCacheVersion is the version of the DNS cache file format. Version 1 files,
+// which cached the addresses of both families under the bare hostname, are still read.
+const dnsCacheVersion = 2
+
+// DNSCacheEntry is the answer of one record type for a hostname together with when it
+// expires. An entry without IPs records that the hostname has no records of the type.
+type DNSCacheEntry struct {
+	IPs       []string  `json:"ips"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// dnsCacheFile is the on-disk form of a DNS cache
+type dnsCacheFile struct {
+	Version int                      `json:"version"`
+	Entries map[string]DNSCacheEntry `json:"entries"`
+}
+
+// dnsCacheKey returns the key of the qtype answer for hostname, e.g. "example.com/AAAA"
+func dnsCacheKey(hostname string, qtype uint16) string {
+	return hostname + "/" + dns.TypeToString[qtype]
+}
+
+// DNSCache caches the A and AAAA answers of hostnames, optionally persisted to a file.
+// Expired entries are refreshed on lookup but kept as a fallback when refreshing fails.
+type DNSCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]DNSCacheEntry
+}
+
+// NewDNSCache creates an in-memory DNS cache
+func NewDNSCache() *DNSCache {
+	return &DNSCache{entries: make(map[string]DNSCacheEntry)}
+}
+
+// LoadDNSCache loads a DNS cache from path, which is also where later entries are saved.
+// A missing file yields an empty cache; a malformed one is an error.
+func LoadDNSCache(path string) (*DNSCache, error) {
+	cache := NewDNSCache()
+	cache.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DNS cache file: %w", err)
+	}
+
+	var file dnsCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse DNS cache file %s: %w", path, err)
+	}
+	switch file.Version {
+	case 1:
+		// 版本 1 按主机名缓存两种地址族，按地址族拆分为 A 与 AAAA 记录
+		for host, entry := range file.Entries {
+			if err := validateDNSCacheEntry(path, host, entry, false); err != nil {
+				return nil, err
+			}
+			for _, family := range []struct {
+				qtype uint16
+				isV4  bool
+			}{{dns.TypeA, true}, {dns.TypeAAAA, false}} {
+				split := DNSCacheEntry{ExpiresAt: entry.ExpiresAt}
+				for _, ip := range entry.IPs {
+					if (net.ParseIP(ip).To4() != nil) == family.isV4 {
+						split.IPs = append(split.IPs, ip)
+					}
+				}
+				if len(split.IPs) > 0 {
+					cache.entries[dnsCacheKey(host, family.qtype)] = split
+				}
+			}
+		}
+	case dnsCacheVersion:
+		for key, entry := range file.Entries {
+			host, qtype, ok := strings.Cut(key, "/")
+			if !ok || (qtype != "A" && qtype != "AAAA") {
+				return nil, fmt.Errorf("invalid DNS cache file %s: bad key %q", path, key)
+			}
+			if err := validateDNSCacheEntry(path, host, entry, true); err != nil {
+				return nil, err
+			}
+			cache.entries[key] = entry
+		}
+	default:
+		return nil, fmt.Errorf("unsupported DNS cache file version %d in %s", file.Version, path)
+	}
+
+	return cache, nil
+}
+
+// validateDNSCacheEntry checks an entry of the DNS cache file at path for host.
+// allowEmpty accepts entries recording that the host has no records.
+func validateDNSCacheEntry(path, host string, entry DNSCacheEntry, allowEmpty bool) error {
+	if host == "" {
+		return fmt.Errorf("invalid DNS cache file %s: empty hostname", path)
+	}
+	if len(entry.IPs) == 0 && !allowEmpty {
+		return fmt.Errorf("invalid DNS cache file %s: no IPs for %s", path, host)
+	}
+	for _, ip := range entry.IPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid DNS cache file %s: bad IP %q for %s", path, ip, host)
+		}
+	}
+	if entry.ExpiresAt.IsZero() {
+		return fmt.Errorf("invalid DNS cache file %s: missing expires_at for %s", path, host)
+	}
+	return nil
+}
+
+// Get returns the cached qtype answer of hostname and whether it is still fresh.
+// fresh is false when nothing is cached.
+func (c *DNSCache) Get(hostname string, qtype uint16) (ips []net.IP, fresh bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[dnsCacheKey(hostname, qtype)]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	for _, ip := range entry.IPs {
+		ips = append(ips, net.ParseIP(ip))
+	}
+	return ips, time.Now().Before(entry.ExpiresAt)
+}
+
+// Put caches the qtype answer of hostname for ttl and saves the cache if it is backed by a file
+func (c *DNSCache) Put(hostname string, qtype uint16, ips []net.IP, ttl time.Duration) {
+	entry := DNSCacheEntry{ExpiresAt: time.Now().Add(ttl).UTC()}
+	for _, ip := range ips {
+		entry.IPs = append(entry.IPs, ip.String())
+	}
+
+	c.mu.Lock()
+	c.entries[dnsCacheKey(hostname, qtype)] = entry
+	c.mu.Unlock()
+
+	if err := c.Save(); err != nil {
+		LogWarning("保存 DNS 缓存失败: %v", err)
+	}
+}
+
+// Clear drops every entry from memory without saving the cache
+func (c *DNSCache) Clear() {
+	c.mu.Lock()
+	clear(c.entries)
+	c.mu.Unlock()
+}
+
+// Save writes the cache to its file, if any
+func (c *DNSCache) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	data, err := json.MarshalIndent(dnsCacheFile{Version: dnsCacheVersion, Entries: c.entries}, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode DNS cache: %w", err)
+	}
+
+	if err := WriteFileAtomic(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write DNS cache file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// ipStrings returns ips in their string form
+func ipStrings(ips []net.IP) []string {
+	var out []string
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out
+}
+
+func TestDNSCacheKeysByRecordType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dns-cache.json")
+	cache, err := LoadDNSCache(path)
+	if err != nil {
+		t.Fatalf("LoadDNSCache() error = %v", err)
+	}
+
+	cache.Put("example.com", dns.TypeA, []net.IP{net.ParseIP("192.0.2.1")}, time.Hour)
+	cache.Put("example.com", dns.TypeAAAA, nil, -time.Second)
+
+	loaded, err := LoadDNSCache(path)
+	if err != nil {
+		t.Fatalf("LoadDNSCache() of the saved cache error = %v", err)
+	}
+	if ips, fresh := loaded.Get("example.com", dns.TypeA); !fresh || !slices.Equal(ipStrings(ips), []string{"192.0.2.1"}) {
+		t.Errorf("Get(A) = %v, %v, want [192.0.2.1], fresh", ips, fresh)
+	}
+	if ips, fresh := loaded.Get("example.com", dns.TypeAAAA); fresh || len(ips) != 0 {
+		t.Errorf("Get(AAAA) = %v, %v, want an expired empty answer", ips, fresh)
+	}
+	if _, fresh := loaded.Get("other.example.com", dns.TypeA); fresh {
+		t.Error("Get() of an uncached hostname is fresh")
+	}
+}
+
+func TestLoadDNSCacheVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dns-cache.json")
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	data := `{"version": 1, "entries": {
+		"dual.example.com": {"ips": ["192.0.2.1", "2001:db8::1"], "expires_at": "` + expires + `"},
+		"v6.example.com": {"ips": ["2001:db8::2"], "expires_at": "` + expires + `"}
+	}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := LoadDNSCache(path)
+	if err != nil {
+		t.Fatalf("LoadDNSCache() error = %v", err)
+	}
+	tests := []struct {
+		host  string
+		qtype uint16
+		want  []string
+		fresh bool
+	}{
+		{host: "dual.example.com", qtype: dns.TypeA, want: []string{"192.0.2.1"}, fresh: true},
+		{host: "dual.example.com", qtype: dns.TypeAAAA, want: []string{"2001:db8::1"}, fresh: true},
+		{host: "v6.example.com", qtype: dns.TypeAAAA, want: []string{"2001:db8::2"}, fresh: true},
+		// A family missing from a version 1 entry is unknown rather than known to be empty
+		{host: "v6.example.com", qtype: dns.TypeA},
+	}
+	for _, tt := range tests {
+		ips, fresh := cache.Get(tt.host, tt.qtype)
+		if fresh != tt.fresh || !slices.Equal(ipStrings(ips), tt.want) {
+			t.Errorf("Get(%s, %s) = %v, %v, want %v, %v", tt.host, dns.TypeToString[tt.qtype], ips, fresh, tt.want, tt.fresh)
+		}
+	}
+}
+
+func TestLoadDNSCacheInvalid(t *testing.T) {
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	for name, data := range map[string]string{
+		"unknown version":  `{"version": 3, "entries": {}}`,
+		"key without type": `{"version": 2, "entries": {"example.com": {"ips": ["192.0.2.1"], "expires_at": "` + expires + `"}}}`,
+		"unknown type":     `{"version": 2, "entries": {"example.com/MX": {"ips": [], "expires_at": "` + expires + `"}}}`,
+		"bad IP":           `{"version": 2, "entries": {"example.com/A": {"ips": ["192.0.2"], "expires_at": "` + expires + `"}}}`,
+		"missing expiry":   `{"version": 2, "entries": {"example.com/A": {"ips": ["192.0.2.1"]}}}`,
+		"empty version 1":  `{"version": 1, "entries": {"example.com": {"ips": [], "expires_at": "` + expires + `"}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dns-cache.json")
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadDNSCache(path); err == nil {
+				t.Error("LoadDNSCache() error = nil, want the file rejected")
+			}
+		})
+	}
+}
+
+// dnsServer answers A queries for every name with 192.0.2.1 and AAAA queries with no
+// records, counting the queries of each type
+type dnsServer struct {
+	mu      sync.Mutex
+	queries map[uint16]int
+}
+
+func (s *dnsServer) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+	question := req.Question[0]
+	s.mu.Lock()
+	s.queries[question.Qtype]++
+	s.mu.Unlock()
+	if question.Qtype == dns.TypeA {
+		resp.Answer = append(resp.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: question.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+			A:   net.ParseIP("192.0.2.1"),
+		})
+	}
+	w.WriteMsg(resp)
+}
+
+func (s *dnsServer) count(qtype uint16) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries[qtype]
+}
+
+// startDNSServer starts handler on a local UDP port and returns its address
+func startDNSServer(t *testing.T, handler dns.Handler) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	srv := &dns.Server{PacketConn: conn, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go srv.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { srv.Shutdown() })
+	return conn.LocalAddr().String()
+}
+
+func TestDNSResolverCachesEachRecordType(t *testing.T) {
+	server := &dnsServer{queries: make(map[uint16]int)}
+	addr := startDNSServer(t, server)
+
+	resolver, err := NewDNSResolver(DNSEndpoint{Address: addr, Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		ips, err := resolver.Resolve("example.com")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if got := ipStrings(ips); !slices.Equal(got, []string{"192.0.2.1"}) {
+			t.Fatalf("Resolve() = %v, want [192.0.2.1]", got)
+		}
+	}
+	// The empty AAAA answer is cached next to the A one, so neither is asked again
+	if a, aaaa := server.count(dns.TypeA), server.count(dns.TypeAAAA); a != 1 || aaaa != 1 {
+		t.Errorf("queries A, AAAA = %d, %d, want 1, 1", a, aaaa)
+	}
+
+	// Once the AAAA answer expires, only it is queried again
+	resolver.cache.Put("example.com", dns.TypeAAAA, nil, -time.Second)
+	if _, err := resolver.Resolve("example.com"); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if a, aaaa := server.count(dns.TypeA), server.count(dns.TypeAAAA); a != 1 || aaaa != 2 {
+		t.Errorf("queries A, AAAA after the AAAA answer expired = %d, %d, want 1, 2", a, aaaa)
+	}
+
+	// With both answers expired and the endpoint unreachable, the expired ones are used
+	resolver.cache.Put("example.com", dns.TypeA, []net.IP{net.ParseIP("192.0.2.9")}, -time.Second)
+	resolver.cache.Put("example.com", dns.TypeAAAA, nil, -time.Second)
+	resolver.endpoints[0] = DNSEndpoint{Address: "127.0.0.1:1", Timeout: 200 * time.Millisecond}
+	ips, err := resolver.Resolve("example.com")
+	if err != nil || !slices.Equal(ipStrings(ips), []string{"192.0.2.9"}) {
+		t.Errorf("Resolve() with the endpoint down = %v, %v, want the expired [192.0.2.9]", ips, err)
+	}
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// knownDoHEndpoints maps the addresses of public resolvers to the DoH endpoints they
+// publish, so a system configured with one of them can be upgraded to DoH
+var knownDoHEndpoints = map[string]string{
+	"1.1.1.1":              "https://cloudflare-dns.com/dns-query",
+	"1.0.0.1":              "https://cloudflare-dns.com/dns-query",
+	"2606:4700:4700::1111": "https://cloudflare-dns.com/dns-query",
+	"2606:4700:4700::1001": "https://cloudflare-dns.com/dns-query",
+	"8.8.8.8":              "https://dns.google/dns-query",
+	"8.8.4.4":              "https://dns.google/dns-query",
+	"2001:4860:4860::8888": "https://dns.google/dns-query",
+	"2001:4860:4860::8844": "https://dns.google/dns-query",
+	"9.9.9.9":              "https://dns.quad9.net/dns-query",
+	"149.112.112.112":      "https://dns.quad9.net/dns-query",
+	"2620:fe::fe":          "https://dns.quad9.net/dns-query",
+	"2620:fe::9":           "https://dns.quad9.net/dns-query",
+	"223.5.5.5":            "https://dns.alidns.com/dns-query",
+	"223.6.6.6":            "https://dns.alidns.com/dns-query",
+	"2400:3200::1":         "https://dns.alidns.com/dns-query",
+	"2400:3200:baba::1":    "https://dns.alidns.com/dns-query",
+	"119.29.29.29":         "https://doh.pub/dns-query",
+	"208.67.222.222":       "https://doh.opendns.com/dns-query",
+	"208.67.220.220":       "https://doh.opendns.com/dns-query",
+}
+
+// resolvectlTimeout bounds how long resolvectl may take to list the DNS servers
+const resolvectlTimeout = 3 * time.Second
+
+// resolvConfPath is where the system resolver configuration is read from
+const resolvConfPath = "/etc/resolv.conf"
+
+// DiscoverDoHEndpoint returns the DoH endpoint of the first DNS server configured
+// on the system, by systemd-resolved (or DHCP through it) or in resolv.conf, that
+// is a known public resolver. Discovery is best effort: systems rarely expose a
+// DoH endpoint themselves, so only resolvers publishing a well-known one are found.
+func DiscoverDoHEndpoint() (endpoint, server string, err error) {
+	servers := systemDNSServers()
+	for _, server := range servers {
+		if endpoint, ok := knownDoHEndpoints[server]; ok {
+			return endpoint, server, nil
+		}
+	}
+	if len(servers) == 0 {
+		return "", "", fmt.Errorf("no system DNS servers found")
+	}
+	return "", "", fmt.Errorf("none of the system DNS servers (%s) has a known DoH endpoint", strings.Join(servers, ", "))
+}
+
+// systemDNSServers lists the system's DNS servers, preferring resolvectl, which
+// knows the upstream servers behind the local stub resolver, over resolv.conf
+func systemDNSServers() []string {
+	if servers := resolvectlDNSServers(); len(servers) > 0 {
+		return servers
+	}
+	return resolvConfDNSServers()
+}
+
+// resolvectlDNSServers parses the output of `resolvectl dns`, which lists the
+// servers per link, e.g. "Link 2 (eth0): 192.168.1.1 1.1.1.1#cloudflare-dns.com"
+func resolvectlDNSServers() []string {
+	ctx, cancel := context.WithTimeout(context.Background(), resolvectlTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "resolvectl", "dns").Output()
+	if err != nil {
+		return nil
+	}
+
+	var servers []string
+	for _, line := range strings.Split(string(output), "\n") {
+		_, list, found := strings.Cut(line, ": ")
+		if !found {
+			continue
+		}
+		for _, field := range strings.Fields(list) {
+			servers = appendServer(servers, field)
+		}
+	}
+	return servers
+}
+
+// resolvConfDNSServers returns the nameserver entries of resolv.conf
+func resolvConfDNSServers() []string {
+	file, err := os.Open(resolvConfPath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = appendServer(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// appendServer appends a server address once, dropping a "#name" server name
+// indication, a "%zone" or a ":port" suffix
+func appendServer(servers []string, address string) []string {
+	address, _, _ = strings.Cut(address, "#")
+	address, _, _ = strings.Cut(address, "%")
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = strings.Trim(host, "[]")
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return servers
+	}
+	address = ip.String()
+	for _, server := range servers {
+		if server == address {
+			return servers
+		}
+	}
+	return append(servers, address)
+}
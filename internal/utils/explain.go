@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Explain topics, naming the subsystem a decision was made in
+const (
+	ExplainConfig = "config"
+	ExplainMirror = "mirror"
+	ExplainUpdate = "update"
+	ExplainAsset  = "asset"
+	ExplainNodes  = "nodes"
+)
+
+// ExplainEntry is a key decision made during the run, reported by --explain
+type ExplainEntry struct {
+	Topic    string `json:"topic"`
+	Decision string `json:"decision"`
+}
+
+var (
+	explainMu      sync.Mutex
+	explainEntries []ExplainEntry
+)
+
+// Explain records a decision under topic. Decisions are always recorded, as
+// they are few, and only printed when the user asks for them.
+func Explain(topic, format string, args ...any) {
+	explainMu.Lock()
+	defer explainMu.Unlock()
+	explainEntries = append(explainEntries, ExplainEntry{Topic: topic, Decision: fmt.Sprintf(format, args...)})
+}
+
+// ExplainEntries returns the decisions recorded so far, in the order they were made
+func ExplainEntries() []ExplainEntry {
+	explainMu.Lock()
+	defer explainMu.Unlock()
+	return append([]ExplainEntry(nil), explainEntries...)
+}
+
+// WriteExplain writes the recorded decisions to w as text, one per line, or as JSON
+func WriteExplain(w io.Writer, format string) error {
+	entries := ExplainEntries()
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Decisions []ExplainEntry `json:"decisions"`
+		}{Decisions: entries})
+	case "text":
+		if _, err := fmt.Fprintln(w, "本次运行的关键决策:"); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if _, err := fmt.Fprintf(w, "  [%s] %s\n", entry.Topic, entry.Decision); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported explain format: %s", format)
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+)
+
+// GitHubTokenEnv is the environment variable the GitHub token is read from when no flag sets it
+const GitHubTokenEnv = "GITHUB_TOKEN"
+
+// githubTokenHosts are the hosts the GitHub token is sent to. Mirrors are left out,
+// since they are run by third parties and must never see the token.
+var githubTokenHosts = []string{"api.github.com", "github.com", "raw.githubusercontent.com"}
+
+var (
+	// githubToken authenticates GitHub requests, raising the API rate limit from 60 to 5000 requests per hour
+	githubToken string
+)
+
+// SetGitHubToken sets the token sent with requests to GitHub, empty for anonymous requests
+func SetGitHubToken(token string) {
+	githubToken = strings.TrimSpace(token)
+}
+
+// GetGitHubToken returns the configured GitHub token
+func GetGitHubToken() string {
+	return githubToken
+}
+
+// AuthorizeGitHubRequest adds the GitHub token to req as a bearer token when one is set
+// and req goes to GitHub itself rather than a mirror. It reports whether it was added.
+func AuthorizeGitHubRequest(req *http.Request) bool {
+	if githubToken == "" || req.URL.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(req.URL.Hostname())
+	for _, tokenHost := range githubTokenHosts {
+		if host == tokenHost {
+			req.Header.Set("Authorization", "Bearer "+githubToken)
+			return true
+		}
+	}
+	return false
+}
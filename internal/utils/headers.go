@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strings"
+)
+
+// sensitiveHeaderWords mark header names whose values must not be logged
+var sensitiveHeaderWords = []string{"auth", "token", "key", "secret", "cookie", "session", "password"}
+
+var (
+	// customHeaders are added to every request of the HTTP clients created by NewHTTPClient
+	customHeaders http.Header
+)
+
+// ParseHeader parses and validates a header in the "Name: Value" form
+func ParseHeader(line string) (name, value string, err error) {
+	name, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", fmt.Errorf("invalid header %q: expected \"Name: Value\"", line)
+	}
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool { return !isHeaderNameChar(r) }) {
+		return "", "", fmt.Errorf("invalid header %q: bad header name", line)
+	}
+	if strings.ContainsFunc(value, func(r rune) bool { return r < ' ' && r != '\t' || r == 0x7f }) {
+		return "", "", fmt.Errorf("invalid header %q: value contains control characters", line)
+	}
+	if strings.EqualFold(name, "Host") || strings.EqualFold(name, "Content-Length") {
+		return "", "", fmt.Errorf("invalid header %q: %s cannot be overridden", line, name)
+	}
+	return textproto.CanonicalMIMEHeaderKey(name), value, nil
+}
+
+// isHeaderNameChar reports whether r may appear in a header name (an RFC 9110 token)
+func isHeaderNameChar(r rune) bool {
+	return r < 0x7f && (r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+		strings.ContainsRune("!#$%&'*+-.^_`|~", r))
+}
+
+// ParseHeaders parses headers in the "Name: Value" form, later entries replacing
+// earlier ones with the same name
+func ParseHeaders(lines []string) (http.Header, error) {
+	headers := make(http.Header, len(lines))
+	for _, line := range lines {
+		name, value, err := ParseHeader(line)
+		if err != nil {
+			return nil, err
+		}
+		headers.Set(name, value)
+	}
+	return headers, nil
+}
+
+// SetHeaders sets the headers added to requests of HTTP clients created afterwards
+func SetHeaders(headers http.Header) {
+	customHeaders = headers
+}
+
+// GetHeaders returns the configured custom headers
+func GetHeaders() http.Header {
+	return customHeaders
+}
+
+// IsSensitiveHeader reports whether the value of the header name may carry credentials
+func IsSensitiveHeader(name string) bool {
+	lower := strings.ToLower(name)
+	return slices.ContainsFunc(sensitiveHeaderWords, func(word string) bool {
+		return strings.Contains(lower, word)
+	})
+}
+
+// RedactHeaders returns headers for logging, with the values of sensitive headers masked
+func RedactHeaders(headers http.Header) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for name, values := range headers {
+		value := strings.Join(values, ", ")
+		if IsSensitiveHeader(name) {
+			value = "***"
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// RedactHeaderLines returns headers in the "Name: Value" form with the values of
+// sensitive headers masked, for showing a configuration
+func RedactHeaderLines(lines []string) []string {
+	redacted := make([]string, len(lines))
+	for i, line := range lines {
+		name, _, ok := strings.Cut(line, ":")
+		if ok && IsSensitiveHeader(strings.TrimSpace(name)) {
+			line = strings.TrimSpace(name) + ": ***"
+		}
+		redacted[i] = line
+	}
+	return redacted
+}
+
+// headerTransport adds the custom headers to requests before sending them
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 重定向到其他主机时不添加敏感请求头，避免凭据泄露给重定向的目标
+	sameHost := true
+	if req.Response != nil {
+		origin := req.Response.Request
+		for origin.Response != nil {
+			origin = origin.Response.Request
+		}
+		sameHost = strings.EqualFold(origin.URL.Host, req.URL.Host)
+	}
+
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		if sameHost || !IsSensitiveHeader(name) {
+			req.Header[name] = slices.Clone(values)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRedactHeaderLines(t *testing.T) {
+	lines := []string{
+		"Authorization: Bearer secret",
+		"Cookie: session=abc",
+		"X-Api-Key:abc",
+		"User-Agent: aqua-speed",
+		"Accept-Language: zh-CN",
+	}
+	want := []string{
+		"Authorization: ***",
+		"Cookie: ***",
+		"X-Api-Key: ***",
+		"User-Agent: aqua-speed",
+		"Accept-Language: zh-CN",
+	}
+
+	got := RedactHeaderLines(lines)
+	if !slices.Equal(got, want) {
+		t.Errorf("RedactHeaderLines() = %q, want %q", got, want)
+	}
+	if lines[0] != "Authorization: Bearer secret" {
+		t.Errorf("RedactHeaderLines() changed its input: %q", lines[0])
+	}
+}
@@ -1,56 +1,162 @@
 package utils
 
 import (
+	"bufio"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
-const maxAttempts = 3
 const connectTimeout = 10 * time.Second
 const maxTime = 30 * time.Second
 const apiMaxTime = 15 * time.Second
 
-// HttpGet 发送 HTTP GET 请求
+// TransportOptions tunes connection reuse and protocol settings of an HTTP transport
+type TransportOptions struct {
+	MaxIdleConns        int           // Idle connections kept across all hosts
+	MaxIdleConnsPerHost int           // Idle connections kept per host
+	IdleConnTimeout     time.Duration // How long an idle connection is kept for reuse
+	ForceAttemptHTTP2   bool          // Try HTTP/2 even with a customized transport
+	DisableCompression  bool          // Do not request gzip from the server
+	Retry               bool          // Retry idempotent requests that fail transiently, following the retry policy
+	Limit               bool          // Share the connection limit set with SetConnectionLimit
+}
+
+var (
+	// DownloadTransportOptions suits single large downloads of already compressed
+	// release archives: few idle connections, and no transport compression since
+	// gzip on top of xz/zip only costs CPU on both ends.
+	DownloadTransportOptions = TransportOptions{
+		MaxIdleConns:        4,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     30 * time.Second,
+		ForceAttemptHTTP2:   true,
+		DisableCompression:  true,
+		Limit:               true,
+	}
+
+	// ProbeTransportOptions suits many small API, config and mirror requests:
+	// connections are kept around for reuse and JSON responses may be compressed.
+	ProbeTransportOptions = TransportOptions{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		ForceAttemptHTTP2:   true,
+		DisableCompression:  false,
+	}
+
+	// APITransportOptions suits API, config and node list requests whose content is
+	// needed rather than their latency: like ProbeTransportOptions, but transient
+	// failures are retried.
+	APITransportOptions = TransportOptions{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		ForceAttemptHTTP2:   true,
+		DisableCompression:  false,
+		Retry:               true,
+	}
+)
+
+// NewTransport creates an HTTP transport based on the default one with the given tuning,
+// connecting through the proxy set with SetProxy if any
+func NewTransport(opts TransportOptions) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = opts.MaxIdleConns
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = opts.IdleConnTimeout
+	transport.ForceAttemptHTTP2 = opts.ForceAttemptHTTP2
+	transport.DisableCompression = opts.DisableCompression
+	if proxy := GetProxy(); proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return transport
+}
+
+// NewHTTPClient creates an HTTP client with the given timeout and transport tuning,
+// adding the headers set with SetHeaders to its requests and recording them in the
+// request log set with SetRequestLog. With opts.Retry, every attempt is logged
+// separately and the timeout covers all attempts. With opts.Limit, each attempt
+// waits for a slot of the connection limit, which is free during retry backoffs.
+func NewHTTPClient(timeout time.Duration, opts TransportOptions) *http.Client {
+	var transport http.RoundTripper = NewTransport(opts)
+	if requestLog != nil {
+		transport = &loggingTransport{base: transport, log: requestLog}
+	}
+	if opts.Limit && connectionSlots != nil {
+		transport = &limitTransport{base: transport, slots: connectionSlots}
+	}
+	if opts.Retry {
+		transport = NewRetryTransport(transport)
+	}
+	if len(customHeaders) > 0 {
+		transport = &headerTransport{base: transport, headers: customHeaders}
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+// HttpGet 发送 HTTP GET 请求，按重试策略重试暂时性的失败
 func HttpGet(url string) (*http.Response, error) {
-	attempt := 1
-
-	for attempt <= maxAttempts {
-		LogDebug("正在请求 %s", url)
-
-		client := &http.Client{
-			Timeout: maxTime,
-		}
-
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("创建请求失败: %w", err)
-		}
-
-		req.Header.Set("User-Agent", "aqua-speed-tools/1.0.0")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			LogWarning("请求失败，尝试第 %d/%d 次", attempt, maxAttempts)
-			attempt++
-			if attempt <= maxAttempts {
-				time.Sleep(2 * time.Second)
-			}
-			continue
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			LogWarning("请求失败，尝试第 %d/%d 次", attempt, maxAttempts)
-			attempt++
-			if attempt <= maxAttempts {
-				time.Sleep(2 * time.Second)
-			}
-			continue
-		}
-
-		return resp, nil
-	}
-
-	return nil, fmt.Errorf("请求失败: %s", url)
+	LogDebug("正在请求 %s", url)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", "aqua-speed-tools/1.0.0")
+
+	resp, err := NewHTTPClient(maxTime, APITransportOptions).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("请求失败: %s: %s", url, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// gzipReadCloser closes both the gzip reader and the underlying body
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func (g *gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
+
+// ResponseBody returns the response body, decompressing it when it is gzip-encoded
+// and the transport did not already do so (e.g. compression disabled, or a mirror
+// sending gzip data without the Content-Encoding header)
+func ResponseBody(resp *http.Response) (io.ReadCloser, error) {
+	if resp.Uncompressed {
+		return resp.Body, nil
+	}
+
+	buffered := bufio.NewReader(resp.Body)
+	magic, _ := buffered.Peek(2)
+	isGzip := strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") ||
+		(len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b)
+
+	if !isGzip {
+		return struct {
+			io.Reader
+			io.Closer
+		}{buffered, resp.Body}, nil
+	}
+
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gzip response: %w", err)
+	}
+	return &gzipReadCloser{Reader: gz, body: resp.Body}, nil
 }
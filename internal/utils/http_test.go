@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gzipped returns data compressed with gzip
+func gzipped(t testing.TB, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResponseBody(t *testing.T) {
+	nodes := []byte(`{"nodes": [{"id": "hk-1", "name": "Hong Kong"}]}`)
+
+	tests := []struct {
+		name     string
+		opts     TransportOptions
+		encoding string // Content-Encoding sent by the server
+		body     []byte
+	}{
+		{name: "plain JSON", opts: ProbeTransportOptions, body: nodes},
+		{name: "decompressed by the transport", opts: ProbeTransportOptions, encoding: "gzip", body: gzipped(t, nodes)},
+		{name: "transport compression disabled", opts: DownloadTransportOptions, encoding: "gzip", body: gzipped(t, nodes)},
+		{name: "gzip without Content-Encoding", opts: ProbeTransportOptions, body: gzipped(t, nodes)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(tt.body)
+			}))
+			t.Cleanup(srv.Close)
+
+			resp, err := NewHTTPClient(5*time.Second, tt.opts).Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, err := ResponseBody(resp)
+			if err != nil {
+				t.Fatalf("ResponseBody() error = %v", err)
+			}
+			defer body.Close()
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !json.Valid(data) || !bytes.Equal(data, nodes) {
+				t.Errorf("ResponseBody() = %q, want %q", data, nodes)
+			}
+		})
+	}
+}
+
+// BenchmarkDownloadTransport downloads an already compressed archive from a server
+// that, like some CDNs, gzips responses whenever the client accepts it
+func BenchmarkDownloadTransport(b *testing.B) {
+	archive := make([]byte, 32<<20)
+	rand.NewChaCha8([32]byte{}).Read(archive) // xz output is incompressible
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write(archive)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write(archive)
+		gz.Close()
+	}))
+	b.Cleanup(srv.Close)
+
+	for _, tt := range []struct {
+		name string
+		opts TransportOptions
+	}{
+		{name: "download options", opts: DownloadTransportOptions},
+		{name: "probe options", opts: ProbeTransportOptions},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			client := NewHTTPClient(time.Minute, tt.opts)
+			b.SetBytes(int64(len(archive)))
+			for b.Loop() {
+				resp, err := client.Get(srv.URL)
+				if err != nil {
+					b.Fatal(err)
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+// BenchmarkProbeTransport sends bursts of small parallel requests to one host, as
+// mirror tests and node list fetches do
+func BenchmarkProbeTransport(b *testing.B) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"ok": true}`)
+	}))
+	b.Cleanup(srv.Close)
+
+	for _, tt := range []struct {
+		name string
+		opts TransportOptions
+	}{
+		{name: "probe options", opts: ProbeTransportOptions},
+		{name: "download options", opts: DownloadTransportOptions},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			client := NewHTTPClient(time.Minute, tt.opts)
+			for b.Loop() {
+				var wg sync.WaitGroup
+				for range 8 {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						resp, err := client.Get(srv.URL)
+						if err != nil {
+							b.Error(err)
+							return
+						}
+						io.Copy(io.Discard, resp.Body)
+						resp.Body.Close()
+					}()
+				}
+				wg.Wait()
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// connectionSlots caps the requests in flight of the clients created with
+// TransportOptions.Limit, nil for no limit
+var connectionSlots chan struct{}
+
+// SetConnectionLimit caps how many requests the HTTP clients created afterwards with
+// TransportOptions.Limit have in flight together. A request holds its slot until its
+// response body is closed. A limit below one removes the cap.
+func SetConnectionLimit(limit int) {
+	if limit < 1 {
+		connectionSlots = nil
+		return
+	}
+	connectionSlots = make(chan struct{}, limit)
+}
+
+// limitTransport waits for a free connection slot before sending a request
+type limitTransport struct {
+	base  http.RoundTripper
+	slots chan struct{}
+}
+
+// RoundTrip sends req once a slot is free, releasing the slot when the response body is closed
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	release := sync.OnceFunc(func() { <-t.slots })
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnClose releases a connection slot when the body is closed
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnectionLimit(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	SetConnectionLimit(2)
+	t.Cleanup(func() { SetConnectionLimit(0) })
+	limited := NewHTTPClient(5*time.Second, DownloadTransportOptions)
+	unlimited := NewHTTPClient(5*time.Second, ProbeTransportOptions)
+
+	tests := []struct {
+		name   string
+		client *http.Client
+		want   func(peak int32) bool
+	}{
+		{name: "download client", client: limited, want: func(peak int32) bool { return peak <= 2 }},
+		{name: "probe client", client: unlimited, want: func(peak int32) bool { return peak > 2 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peak.Store(0)
+			var wg sync.WaitGroup
+			for range 6 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					resp, err := tt.client.Get(srv.URL)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}()
+			}
+			wg.Wait()
+			if !tt.want(peak.Load()) {
+				t.Errorf("peak concurrent requests = %d", peak.Load())
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 var (
 	IsDebug bool
+	LogFile string // Additional file the logs are written to, empty to disable
 	logger  *zap.Logger
 )
 
@@ -31,6 +32,10 @@ func initLogger() *zap.Logger {
 		config.OutputPaths = []string{"stdout"}
 		config.ErrorOutputPaths = []string{"stderr"}
 	}
+	if LogFile != "" {
+		config.OutputPaths = append(config.OutputPaths, LogFile)
+		config.ErrorOutputPaths = append(config.ErrorOutputPaths, LogFile)
+	}
 
 	l, err := config.Build(
 		zap.AddCaller(),
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrConfirmationRequired is returned when a confirmation cannot be asked because
+// standard input is not a terminal and --assume-yes was not given
+var ErrConfirmationRequired = errors.New("confirmation required but standard input is not a terminal; rerun with --assume-yes")
+
+var (
+	// assumeYes answers every confirmation with yes without asking
+	assumeYes bool
+)
+
+// SetAssumeYes makes Confirm answer yes without asking, for scripted use
+func SetAssumeYes(yes bool) {
+	assumeYes = yes
+}
+
+// GetAssumeYes reports whether confirmations are answered automatically
+func GetAssumeYes() bool {
+	return assumeYes
+}
+
+// Confirm asks a yes/no question, defaulting to no, and reads the answer from in.
+// It returns true without asking when SetAssumeYes is enabled, and
+// ErrConfirmationRequired when in is a standard input that is not a terminal,
+// rather than blocking on or misreading piped input.
+func Confirm(in io.Reader, question string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if file, ok := in.(*os.File); ok && !isTerminal(file) {
+		return false, ErrConfirmationRequired
+	}
+
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, nil
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// isTerminal reports whether file is a character device such as a terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
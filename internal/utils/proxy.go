@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// proxySchemes are the proxy URL schemes supported by the HTTP transport
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+var (
+	// proxyURL is the proxy all HTTP clients connect through, nil to use the environment
+	proxyURL *url.URL
+)
+
+// ParseProxyURL parses and validates a proxy URL such as socks5://127.0.0.1:1080
+func ParseProxyURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", rawURL, err)
+	}
+	if !slices.Contains(proxySchemes, strings.ToLower(parsed.Scheme)) {
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be one of %s", rawURL, strings.Join(proxySchemes, ", "))
+	}
+	if parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", rawURL)
+	}
+	return parsed, nil
+}
+
+// SetProxy sets the proxy used by HTTP clients created afterwards, nil to use the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+func SetProxy(proxy *url.URL) {
+	proxyURL = proxy
+}
+
+// GetProxy returns the configured proxy, nil if none is set
+func GetProxy() *url.URL {
+	return proxyURL
+}
+
+// ProxyEnv returns the environment variables that point child processes at proxy,
+// in both the upper and lower case spellings tools look for
+func ProxyEnv(proxy *url.URL) []string {
+	var env []string
+	for _, name := range []string{"ALL_PROXY", "HTTP_PROXY", "HTTPS_PROXY"} {
+		env = append(env, name+"="+proxy.String(), strings.ToLower(name)+"="+proxy.String())
+	}
+	return env
+}
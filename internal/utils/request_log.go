@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RequestLogEntry is one HTTP request written to the request log
+type RequestLogEntry struct {
+	Time           time.Time         `json:"time"`
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	RedirectedFrom string            `json:"redirectedFrom,omitempty"` // URL of the previous hop when following a redirect
+	Hop            int               `json:"hop,omitempty"`            // Number of redirects followed to reach this request
+	Headers        map[string]string `json:"headers,omitempty"`        // Request headers, sensitive values redacted
+	Status         int               `json:"status,omitempty"`
+	HeadersMs      float64           `json:"headersMs"`  // Time until the response headers arrived
+	DurationMs     float64           `json:"durationMs"` // Time until the response body was read or closed
+	Bytes          int64             `json:"bytes"`      // Response body bytes read
+	Error          string            `json:"error,omitempty"`
+}
+
+// RequestLog writes every HTTP request of the clients created by NewHTTPClient to
+// a file as JSON Lines, regardless of the log level
+type RequestLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+var (
+	// requestLog is the request log set with SetRequestLog, nil when disabled
+	requestLog *RequestLog
+)
+
+// OpenRequestLog opens path for appending request log entries
+func OpenRequestLog(path string) (*RequestLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open request log: %w", err)
+	}
+	return &RequestLog{file: file}, nil
+}
+
+// SetRequestLog sets the request log of HTTP clients created afterwards, nil to disable it
+func SetRequestLog(log *RequestLog) {
+	requestLog = log
+}
+
+// write appends entry as a single line
+func (l *RequestLog) write(entry RequestLogEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		LogWarning("写入请求日志失败: %v", err)
+	}
+}
+
+// Close closes the request log file
+func (l *RequestLog) Close() error {
+	return l.file.Close()
+}
+
+// loggingTransport records each request it sends, including every hop of a redirect chain
+type loggingTransport struct {
+	base http.RoundTripper
+	log  *RequestLog
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	entry := RequestLogEntry{
+		Time:    time.Now(),
+		Method:  req.Method,
+		URL:     req.URL.Redacted(),
+		Headers: RedactHeaders(req.Header),
+	}
+	for prev := req.Response; prev != nil; prev = prev.Request.Response {
+		if entry.Hop == 0 {
+			entry.RedirectedFrom = prev.Request.URL.Redacted()
+		}
+		entry.Hop++
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	entry.HeadersMs = milliseconds(time.Since(entry.Time))
+	if err != nil {
+		entry.DurationMs = entry.HeadersMs
+		entry.Error = err.Error()
+		t.log.write(entry)
+		return nil, err
+	}
+
+	entry.Status = resp.StatusCode
+	resp.Body = &loggedBody{ReadCloser: resp.Body, entry: entry, log: t.log}
+	return resp, nil
+}
+
+// loggedBody counts the bytes read from a response body and writes the log entry
+// once the body is fully read or closed
+type loggedBody struct {
+	io.ReadCloser
+	entry RequestLogEntry
+	log   *RequestLog
+	bytes atomic.Int64
+	once  sync.Once
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes.Add(int64(n))
+	if err != nil && err != io.EOF {
+		b.finish(err)
+	} else if err == io.EOF {
+		b.finish(nil)
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	b.finish(nil)
+	return b.ReadCloser.Close()
+}
+
+// finish writes the entry the first time it is called
+func (b *loggedBody) finish(err error) {
+	b.once.Do(func() {
+		b.entry.DurationMs = milliseconds(time.Since(b.entry.Time))
+		b.entry.Bytes = b.bytes.Load()
+		if err != nil {
+			b.entry.Error = err.Error()
+		}
+		b.log.write(b.entry)
+	})
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls how often a failed request is attempted and how long to wait in between
+type RetryPolicy struct {
+	Attempts    int           // Total number of attempts, including the first one
+	BaseBackoff time.Duration // Wait before the first retry, doubled for each further retry
+	MaxBackoff  time.Duration // Upper bound for the wait between attempts
+}
+
+var (
+	// DefaultRetryPolicy matches the historical behavior of 3 attempts 2 seconds apart
+	DefaultRetryPolicy = RetryPolicy{
+		Attempts:    3,
+		BaseBackoff: 2 * time.Second,
+		MaxBackoff:  2 * time.Second,
+	}
+
+	// retryPolicy is replaced on config reload while requests read it, nil means the default
+	retryPolicy atomic.Pointer[RetryPolicy]
+)
+
+// SetRetryPolicy sets the retry policy used by HTTP helpers and the updater, safe to call
+// while requests are in flight
+func SetRetryPolicy(policy RetryPolicy) {
+	retryPolicy.Store(&policy)
+}
+
+// GetRetryPolicy returns the current retry policy
+func GetRetryPolicy() RetryPolicy {
+	if policy := retryPolicy.Load(); policy != nil {
+		return *policy
+	}
+	return DefaultRetryPolicy
+}
+
+// JitteredBackoff returns Backoff(retry) randomized to between half and all of it,
+// so clients failing together do not retry in lockstep
+func (p RetryPolicy) JitteredBackoff(retry int) time.Duration {
+	backoff := p.Backoff(retry)
+	if backoff <= 1 {
+		return backoff
+	}
+	half := backoff / 2
+	return half + rand.N(backoff-half)
+}
+
+// Backoff returns how long to wait before the given retry, starting at 1
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.BaseBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// noRetryKey marks a request context whose requests the retry transport sends only once
+type noRetryKey struct{}
+
+// WithoutRetry returns a context whose requests are not retried by the retry transport,
+// for callers that retry them with their own policy
+func WithoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// RetryTransport retries idempotent requests that failed with a network error or a
+// transient status, waiting between attempts as the retry policy and Retry-After say
+type RetryTransport struct {
+	base   http.RoundTripper
+	policy *RetryPolicy // nil follows GetRetryPolicy
+}
+
+// NewRetryTransport creates a transport retrying requests sent over base with the
+// current retry policy
+func NewRetryTransport(base http.RoundTripper) *RetryTransport {
+	return &RetryTransport{base: base}
+}
+
+// SetPolicy makes the transport retry with policy instead of the current retry policy
+func (t *RetryTransport) SetPolicy(policy RetryPolicy) {
+	t.policy = &policy
+}
+
+// RoundTrip sends req, retrying it while the retry policy allows
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := GetRetryPolicy()
+	if t.policy != nil {
+		policy = *t.policy
+	}
+	if policy.Attempts <= 1 || !isRetryableRequest(req) || req.Context().Value(noRetryKey{}) != nil {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if attempt >= policy.Attempts || !isRetryableResult(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		wait := policy.Backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				// 服务器要求的等待时间超过上限时不再重试，直接返回该响应
+				if retryAfter > policy.MaxBackoff {
+					return resp, nil
+				}
+				wait = retryAfter
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		if err != nil {
+			LogWarning("请求 %s 失败: %v，%s 后重试 (%d/%d)", req.URL.Redacted(), err, wait, attempt+1, policy.Attempts)
+		} else {
+			LogWarning("请求 %s 返回 %s，%s 后重试 (%d/%d)", req.URL.Redacted(), resp.Status, wait, attempt+1, policy.Attempts)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryableRequest reports whether req may be sent again without side effects
+func isRetryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// isRetryableResult reports whether a request that ended with resp or err is worth retrying
+func isRetryableResult(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		// 服务器不支持该请求，重试也不会成功
+		return false
+	}
+	return resp.StatusCode >= 500
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
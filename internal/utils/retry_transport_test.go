@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"cmp"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyTransport fails the first failures requests with err, or with status if err is
+// nil, and answers 200 afterwards, recording when each attempt was made
+type flakyTransport struct {
+	failures   int
+	err        error
+	status     int
+	retryAfter string
+	attempts   []time.Time
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.attempts = append(f.attempts, time.Now())
+	if len(f.attempts) <= f.failures {
+		if f.err != nil {
+			return nil, f.err
+		}
+		resp := &http.Response{StatusCode: f.status, Status: http.StatusText(f.status), Header: make(http.Header), Body: io.NopCloser(strings.NewReader("")), Request: req}
+		if f.retryAfter != "" {
+			resp.Header.Set("Retry-After", f.retryAfter)
+		}
+		return resp, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader("ok")), Request: req}, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, BaseBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+	errNetwork := errors.New("connection reset")
+
+	tests := []struct {
+		name         string
+		method       string
+		base         *flakyTransport
+		wantAttempts int
+		wantStatus   int
+		wantErr      error
+		// wantBackoffs are the least waits expected before each retry
+		wantBackoffs []time.Duration
+	}{
+		{
+			name:         "network error retried",
+			base:         &flakyTransport{failures: 2, err: errNetwork},
+			wantAttempts: 3,
+			wantStatus:   http.StatusOK,
+			wantBackoffs: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		},
+		{
+			name:         "server error retried",
+			base:         &flakyTransport{failures: 1, status: http.StatusInternalServerError},
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+			wantBackoffs: []time.Duration{10 * time.Millisecond},
+		},
+		{
+			name:         "attempts exhausted",
+			base:         &flakyTransport{failures: 5, status: http.StatusServiceUnavailable},
+			wantAttempts: 3,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantBackoffs: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		},
+		{
+			name:         "last network error returned",
+			base:         &flakyTransport{failures: 5, err: errNetwork},
+			wantAttempts: 3,
+			wantErr:      errNetwork,
+		},
+		{
+			name:         "client error not retried",
+			base:         &flakyTransport{failures: 1, status: http.StatusNotFound},
+			wantAttempts: 1,
+			wantStatus:   http.StatusNotFound,
+		},
+		{
+			name:         "not implemented not retried",
+			base:         &flakyTransport{failures: 1, status: http.StatusNotImplemented},
+			wantAttempts: 1,
+			wantStatus:   http.StatusNotImplemented,
+		},
+		{
+			name:         "post not retried",
+			method:       http.MethodPost,
+			base:         &flakyTransport{failures: 1, status: http.StatusServiceUnavailable},
+			wantAttempts: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+		{
+			name:         "retry-after over the max backoff returned",
+			base:         &flakyTransport{failures: 1, status: http.StatusTooManyRequests, retryAfter: "60"},
+			wantAttempts: 1,
+			wantStatus:   http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := NewRetryTransport(tt.base)
+			transport.SetPolicy(policy)
+
+			req, err := http.NewRequest(cmp.Or(tt.method, http.MethodGet), "http://example.com/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := transport.RoundTrip(req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RoundTrip() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("RoundTrip() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+
+			if len(tt.base.attempts) != tt.wantAttempts {
+				t.Fatalf("attempts = %d, want %d", len(tt.base.attempts), tt.wantAttempts)
+			}
+			for i, want := range tt.wantBackoffs {
+				if waited := tt.base.attempts[i+1].Sub(tt.base.attempts[i]); waited < want {
+					t.Errorf("wait before retry %d = %s, want at least %s", i+1, waited, want)
+				}
+			}
+		})
+	}
+}
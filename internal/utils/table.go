@@ -8,6 +8,10 @@ import (
 	"github.com/jedib0t/go-pretty/v6/text"
 )
 
+// UTF8BOM is the byte order mark that makes spreadsheet programs such as Excel
+// on Windows read a CSV file as UTF-8 instead of the system code page
+const UTF8BOM = "\ufeff"
+
 type Table struct {
 	writer table.Writer
 }
@@ -108,9 +112,11 @@ func (t *Table) Print() {
 	t.writer.Render()
 }
 
-// RenderHTML outputs HTML format
+// RenderHTML outputs the table as a complete HTML document declaring UTF-8,
+// so that Chinese names display correctly whatever the browser's default encoding
 func (t *Table) RenderHTML() string {
-	return t.writer.RenderHTML()
+	return "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n</head>\n<body>\n" +
+		t.writer.RenderHTML() + "\n</body>\n</html>\n"
 }
 
 // RenderMarkdown outputs Markdown format
@@ -58,16 +58,17 @@ func isURLAccessible(rawURL string) bool {
 
 	// If DNS resolver is set, use it
 	if resolver := GetDNSResolver(); resolver != nil {
+		// Resolve only fails when neither A nor AAAA records resolve
 		ips, err := resolver.Resolve(parsedURL.Hostname())
 		if err != nil {
 			LogWarning("DNS resolution failed for %s: %v", parsedURL.Hostname(), err)
 			return false
 		}
-		// Consider accessible if we can resolve the IP
+		// Consider accessible if we can resolve an IPv4 or IPv6 address
 		return len(ips) > 0
 	}
 
-	// Otherwise use system default DNS resolver
+	// Otherwise use system default DNS resolver, which returns both address families
 	ips, err := net.LookupIP(parsedURL.Hostname())
 	if err != nil {
 		LogWarning("DNS lookup failed for %s: %v", parsedURL.Hostname(), err)
@@ -151,45 +152,27 @@ func findBestRawURL(urls []string) string {
 	for _, url := range urls {
 		go func(u string) {
 			start := time.Now()
-			client := &http.Client{
-				Timeout: 10 * time.Second,
-			}
+			client := NewHTTPClient(10*time.Second, APITransportOptions)
 
-			var bestLatency time.Duration
+			var latency time.Duration
 			var success bool
 
-			for attempt := 0; attempt <= 3; attempt++ {
-				if attempt > 0 {
-					LogDebug("Retrying Raw URL %s: attempt %d/3", u, attempt)
-					time.Sleep(time.Second * time.Duration(attempt))
-				}
-
-				req, err := http.NewRequest(http.MethodGet, u, nil)
-				if err != nil {
-					continue
-				}
-
+			req, err := http.NewRequest(http.MethodGet, u, nil)
+			if err == nil {
 				// Set proper User-Agent header
 				req.Header.Set("User-Agent", GetUserAgent("Aqua-Speed-URLTester"))
 
-				resp, err := client.Do(req)
-				if err != nil {
-					continue
-				}
-				resp.Body.Close()
-
-				if resp.StatusCode == http.StatusOK {
-					latency := time.Since(start)
-					if bestLatency == 0 || latency < bestLatency {
-						bestLatency = latency
+				if resp, err := client.Do(req); err == nil {
+					resp.Body.Close()
+					if resp.StatusCode == http.StatusOK {
+						latency = time.Since(start)
+						success = true
 					}
-					success = true
-					break
 				}
 			}
 
 			if success {
-				results <- result{url: u, latency: bestLatency}
+				results <- result{url: u, latency: latency}
 			} else {
 				results <- result{url: u, latency: time.Hour} // Use large latency for failed URLs
 			}
@@ -3,6 +3,10 @@ package utils
 var (
 	// AppVersion holds the application version, set by main package
 	AppVersion = "unknown"
+
+	// Commit is the commit the tool was built from, injected at build time with
+	// -ldflags "-X 'aqua-speed-tools/internal/utils.Commit=<hash>'"
+	Commit = ""
 )
 
 // SetAppVersion sets the global application version